@@ -0,0 +1,125 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/vancanhuit/monkey/internal/ast"
+	"github.com/vancanhuit/monkey/internal/evaluator"
+	"github.com/vancanhuit/monkey/internal/lexer"
+	"github.com/vancanhuit/monkey/internal/parser"
+	"github.com/vancanhuit/monkey/internal/types"
+)
+
+// runCheck implements "monkey check [-types] [FILE...]". It reports the
+// type errors in each file, or in stdin without files, and with -types
+// prints the types inferred for the top-level bindings. The program is not
+// run, but its macro bodies are, to expand them.
+func runCheck(args []string) int {
+	flags := flag.NewFlagSet("check", flag.ContinueOnError)
+	showTypes := flags.Bool("types", false, "print the types of the top-level bindings")
+	flags.Usage = func() {
+		out := flags.Output()
+		fmt.Fprintln(out, "Usage: monkey check [-types] [FILE...]")
+		flags.PrintDefaults()
+		fmt.Fprintln(out, "The program is not run, but the bodies of its macros are evaluated to")
+		fmt.Fprintln(out, "expand them, and any output they print is written as usual.")
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+	status := 0
+	for _, path := range paths {
+		if code := checkFile(path, *showTypes); code != 0 {
+			status = code
+		}
+	}
+	return status
+}
+
+func checkFile(path string, showTypes bool) int {
+	src, err := readSource(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "monkey check: %s\n", err)
+		return 1
+	}
+	name := path
+	if path == "-" {
+		name = "<stdin>"
+	}
+
+	program := expandedProgram(name, src)
+	if program == nil {
+		return 1
+	}
+
+	env := programEnv()
+	errors := types.Check(program, env)
+	for _, err := range errors {
+		fmt.Fprintf(os.Stderr, "%s:%s\n", name, err)
+	}
+
+	if showTypes {
+		printed := map[string]bool{}
+		for _, stmt := range program.Statements {
+			let, ok := stmt.(*ast.LetStatement)
+			if !ok || printed[let.Name.Value] {
+				continue
+			}
+			printed[let.Name.Value] = true
+			if s, ok := env.Lookup(let.Name.Value); ok {
+				fmt.Printf("%s: %s\n", let.Name.Value, s)
+			}
+		}
+	}
+	if len(errors) != 0 {
+		return 1
+	}
+	return 0
+}
+
+// macroLimits bounds the macro bodies evaluated by expandedProgram, which
+// expands macros for commands that do not run the program itself.
+var macroLimits = evaluator.Limits{
+	MaxSteps:  10_000_000,
+	Timeout:   10 * time.Second,
+	MaxMemory: 256 << 20,
+}
+
+// expandedProgram parses src and expands its macros, as they are before
+// the program runs, under macroLimits and with no access to the files or
+// the network. It reports any errors under name and returns nil.
+func expandedProgram(name, src string) *ast.Program {
+	p := parser.New(lexer.New(src))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		for _, msg := range p.Errors() {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", name, msg)
+		}
+		return nil
+	}
+
+	macros := evaluator.NewMacroEnvironment(evaluator.Config{Limits: macroLimits})
+	evaluator.DefineMacros(program, macros)
+	if _, err := evaluator.ExpandMacros(program, macros); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", name, err)
+		return nil
+	}
+	return program
+}
+
+// programEnv binds the names monkey run defines for programs, besides the
+// builtins.
+func programEnv() *types.Env {
+	env := types.NewEnv()
+	env.Define("args", &types.Array{Elem: types.String})
+	env.Define("exit", types.MustParse("fn(int?) -> null"))
+	return env
+}
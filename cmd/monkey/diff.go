@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+type edit struct {
+	op   byte // ' ', '-' or '+'
+	line string
+}
+
+// diffLines returns the shortest sequence of edits turning a into b, found
+// with Myers' algorithm.
+func diffLines(a, b []string) []edit {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+	var trace [][]int
+
+search:
+	for d := 0; d <= n+m; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	var edits []edit
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+		prevK := k - 1
+		if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+			prevK = k + 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			edits = append(edits, edit{' ', a[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			edits = append(edits, edit{'+', b[y-1]})
+			y--
+		} else {
+			edits = append(edits, edit{'-', a[x-1]})
+			x--
+		}
+	}
+	for x > 0 && y > 0 {
+		edits = append(edits, edit{' ', a[x-1]})
+		x--
+		y--
+	}
+
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
+
+// writeDiff writes the changes from before to after as a unified diff.
+func writeDiff(w io.Writer, name string, before, after []byte) {
+	edits := diffLines(splitLines(string(before)), splitLines(string(after)))
+
+	fmt.Fprintf(w, "--- %s.orig\n+++ %s\n", name, name)
+	aLine, bLine := 0, 0
+	for i := 0; i < len(edits); {
+		if edits[i].op == ' ' {
+			aLine++
+			bLine++
+			i++
+			continue
+		}
+
+		// The hunk runs from the context before this change to the context
+		// after the last change that is close enough to be merged with it.
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := i + 1
+		for j := i; j < len(edits) && j-end < 2*diffContext; j++ {
+			if edits[j].op != ' ' {
+				end = j + 1
+			}
+		}
+		end += diffContext
+		if end > len(edits) {
+			end = len(edits)
+		}
+
+		aStart, bStart := aLine-(i-start), bLine-(i-start)
+		aCount, bCount := 0, 0
+		for _, e := range edits[start:end] {
+			if e.op != '+' {
+				aCount++
+			}
+			if e.op != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(w, "@@ -%s +%s @@\n", hunkRange(aStart, aCount), hunkRange(bStart, bCount))
+		for _, e := range edits[start:end] {
+			fmt.Fprintf(w, "%c%s\n", e.op, e.line)
+		}
+
+		for _, e := range edits[i:end] {
+			if e.op != '+' {
+				aLine++
+			}
+			if e.op != '-' {
+				bLine++
+			}
+		}
+		i = end
+	}
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
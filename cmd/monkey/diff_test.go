@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffLines(t *testing.T) {
+	a := []string{"a", "b", "c", "d"}
+	b := []string{"a", "c", "d", "e"}
+
+	var ops strings.Builder
+	for _, e := range diffLines(a, b) {
+		ops.WriteString(string(e.op) + e.line + " ")
+	}
+	require.Equal(t, " a -b  c  d +e ", ops.String())
+}
+
+func TestWriteDiff(t *testing.T) {
+	before := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	after := "1\nTWO\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n"
+
+	var out strings.Builder
+	writeDiff(&out, "x.mk", []byte(before), []byte(after))
+	expected := `--- x.mk.orig
++++ x.mk
+@@ -1,5 +1,5 @@
+ 1
+-2
++TWO
+ 3
+ 4
+ 5
+@@ -10,3 +10,4 @@
+ 10
+ 11
+ 12
++13
+`
+	require.Equal(t, expected, out.String())
+
+	out.Reset()
+	writeDiff(&out, "x.mk", nil, []byte("a\n"))
+	require.Equal(t, "--- x.mk.orig\n+++ x.mk\n@@ -0,0 +1,1 @@\n+a\n", out.String())
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/vancanhuit/monkey/internal/printer"
+)
+
+// runFmt implements "monkey fmt [-w] [-d] [FILE...]". Without files it
+// formats stdin to stdout; otherwise it prints each formatted file, or with
+// -w rewrites it and with -d shows the changes as a diff.
+func runFmt(args []string) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	write := flags.Bool("w", false, "write the result to the source file instead of stdout")
+	diff := flags.Bool("d", false, "print a diff instead of the formatted source")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: monkey fmt [-w] [-d] [FILE...]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() == 0 {
+		if *write {
+			fmt.Fprintln(os.Stderr, "monkey fmt: cannot use -w with standard input")
+			return 2
+		}
+		return formatFile("-", false, *diff)
+	}
+
+	status := 0
+	for _, path := range flags.Args() {
+		if code := formatFile(path, *write, *diff); code != 0 {
+			status = code
+		}
+	}
+	return status
+}
+
+func formatFile(path string, write, diff bool) int {
+	src, err := readSource(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "monkey fmt: %s\n", err)
+		return 1
+	}
+	name := path
+	if path == "-" {
+		name = "<stdin>"
+	}
+
+	formatted, err := printer.Format([]byte(src))
+	if err != nil {
+		for _, msg := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", name, msg)
+		}
+		return 1
+	}
+
+	changed := !bytes.Equal([]byte(src), formatted)
+	if diff && changed {
+		writeDiff(os.Stdout, name, []byte(src), formatted)
+	}
+	if write && changed {
+		info, err := os.Stat(path)
+		if err == nil {
+			err = os.WriteFile(path, formatted, info.Mode().Perm())
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "monkey fmt: %s\n", err)
+			return 1
+		}
+	}
+	if !write && !diff {
+		_, _ = io.Copy(os.Stdout, bytes.NewReader(formatted))
+	}
+	return 0
+}
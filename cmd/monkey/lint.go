@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/vancanhuit/monkey/internal/lint"
+)
+
+// runLint implements "monkey lint [-disable RULE,...] [FILE...]". It
+// reports the findings of the lint rules in each file, or in stdin without
+// files.
+func runLint(args []string) int {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	disable := flags.String("disable", "", "comma-separated IDs of the rules to turn off")
+	flags.Usage = func() {
+		out := flags.Output()
+		fmt.Fprintln(out, "Usage: monkey lint [-disable RULE,...] [FILE...]")
+		flags.PrintDefaults()
+		fmt.Fprintln(out, "Rules:")
+		for _, id := range lint.RuleIDs() {
+			fmt.Fprintf(out, "  %-20s %s\n", id, lint.Rules[id])
+		}
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	disabled := map[string]bool{}
+	if *disable != "" {
+		for _, id := range strings.Split(*disable, ",") {
+			id = strings.TrimSpace(id)
+			if _, ok := lint.Rules[id]; !ok {
+				fmt.Fprintf(os.Stderr, "monkey lint: unknown rule %q\n", id)
+				return 2
+			}
+			disabled[id] = true
+		}
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+	status := 0
+	for _, path := range paths {
+		if code := lintFile(path, disabled); code != 0 {
+			status = code
+		}
+	}
+	return status
+}
+
+func lintFile(path string, disabled map[string]bool) int {
+	src, err := readSource(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "monkey lint: %s\n", err)
+		return 1
+	}
+	name := path
+	if path == "-" {
+		name = "<stdin>"
+	}
+
+	program := expandedProgram(name, src)
+	if program == nil {
+		return 1
+	}
+	findings := lint.Lint(program, programEnv(), disabled)
+	for _, f := range findings {
+		fmt.Fprintf(os.Stderr, "%s:%s\n", name, f)
+	}
+	if len(findings) != 0 {
+		return 1
+	}
+	return 0
+}
@@ -2,13 +2,68 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/user"
+	"path/filepath"
 
+	"github.com/vancanhuit/monkey/internal/evaluator"
 	"github.com/vancanhuit/monkey/internal/repl"
 )
 
+const usage = `Usage:
+  monkey                    start the REPL, or run the program piped to stdin
+  monkey run [-allow-fs] [-allow-net] FILE [ARGS]
+                            run the program in FILE ("-" reads stdin)
+  monkey [-allow-fs] [-allow-net] -e EXPR [ARGS]
+                            evaluate EXPR and print its value
+  monkey fmt [-w] [-d] [FILE...]
+                            format Monkey source code
+  monkey parse [-json] [FILE]
+                            print the syntax tree of FILE (default stdin)
+  monkey check [-types] [FILE...]
+                            report type errors without running the program
+                            (macro bodies are still evaluated to expand them)
+  monkey lint [-disable RULE,...] [FILE...]
+                            report likely mistakes such as unused bindings
+`
+
 func main() {
+	os.Exit(runMain(os.Args[1:]))
+}
+
+func runMain(args []string) int {
+	if len(args) == 0 {
+		if isTerminal(os.Stdin) {
+			startREPL()
+			return 0
+		}
+		return runFile("-", nil, evaluator.Config{})
+	}
+
+	switch args[0] {
+	case "run":
+		return runCommand(args[1:])
+	case "fmt":
+		return runFmt(args[1:])
+	case "parse":
+		return runParse(args[1:])
+	case "check":
+		return runCheck(args[1:])
+	case "lint":
+		return runLint(args[1:])
+	case "-e", "-allow-fs", "-allow-net":
+		return runCommand(args)
+	case "-h", "--help", "help":
+		fmt.Print(usage)
+		return 0
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+}
+
+func startREPL() {
 	user, err := user.Current()
 	if err != nil {
 		panic(err)
@@ -16,5 +71,32 @@ func main() {
 
 	fmt.Printf("Hello %s! This is the Monkey programming language!\n", user.Username)
 	fmt.Println("Feel free to type in commands")
-	repl.Start(os.Stdin, os.Stdout)
+	if err := repl.StartTerminal(historyFile(user.HomeDir)); err != nil {
+		fmt.Fprintf(os.Stderr, "monkey: cannot save history: %s\n", err)
+	}
+}
+
+// historyFile returns where the REPL keeps its history: $MONKEY_HISTORY if
+// set, otherwise .monkey_history in the home directory.
+func historyFile(home string) string {
+	if path, ok := os.LookupEnv("MONKEY_HISTORY"); ok {
+		return path
+	}
+	return filepath.Join(home, ".monkey_history")
+}
+
+// isTerminal reports whether f is an interactive terminal rather than a
+// pipe or a regular file.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func readSource(path string) (string, error) {
+	if path == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		return string(data), err
+	}
+	data, err := os.ReadFile(path)
+	return string(data), err
 }
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/vancanhuit/monkey/internal/lexer"
+	"github.com/vancanhuit/monkey/internal/parser"
+)
+
+// runParse implements "monkey parse [-json] [FILE]". It parses FILE, or
+// stdin when FILE is missing or "-", and prints the syntax tree: fully
+// parenthesized by default, or as JSON in the schema of ast.Program.
+func runParse(args []string) int {
+	flags := flag.NewFlagSet("parse", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print the syntax tree as JSON")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: monkey parse [-json] [FILE]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+
+	path := "-"
+	if flags.NArg() == 1 {
+		path = flags.Arg(0)
+	}
+	src, err := readSource(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "monkey parse: %s\n", err)
+		return 1
+	}
+	name := path
+	if path == "-" {
+		name = "<stdin>"
+	}
+
+	p := parser.New(lexer.New(src))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		for _, msg := range p.Errors() {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", name, msg)
+		}
+		return 1
+	}
+
+	if !*asJSON {
+		for _, stmt := range program.Statements {
+			fmt.Println(stmt.String())
+		}
+		return 0
+	}
+	data, err := json.MarshalIndent(program, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "monkey parse: %s\n", err)
+		return 1
+	}
+	fmt.Println(string(data))
+	return 0
+}
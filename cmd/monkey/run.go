@@ -0,0 +1,153 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/vancanhuit/monkey/internal/evaluator"
+	"github.com/vancanhuit/monkey/internal/lexer"
+	"github.com/vancanhuit/monkey/internal/object"
+	"github.com/vancanhuit/monkey/internal/parser"
+)
+
+// runCommand implements "monkey run [-allow-fs] [-allow-net] FILE [ARGS]"
+// and "monkey [-allow-fs] [-allow-net] -e EXPR [ARGS]". Scripts have
+// neither files nor the network unless the flags grant them.
+func runCommand(args []string) int {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	allowFS := flags.Bool("allow-fs", false, "grant the fs module, rooted at the working directory")
+	allowNet := flags.Bool("allow-net", false, "grant the http module")
+	var expr *string
+	flags.Func("e", "evaluate `EXPR` and print its value instead of running a file", func(s string) error {
+		expr = &s
+		return nil
+	})
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: monkey run [-allow-fs] [-allow-net] (-e EXPR | FILE) [ARGS]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg := scriptConfig(*allowFS, *allowNet)
+	if expr != nil {
+		return runSource(*expr, flags.Args(), cfg, true)
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+	return runFile(flags.Arg(0), flags.Args()[1:], cfg)
+}
+
+func runFile(path string, args []string, cfg evaluator.Config) int {
+	src, err := readSource(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "monkey: %s\n", err)
+		return 1
+	}
+	return runSource(src, args, cfg, false)
+}
+
+// errExit is returned by exit to stop the program.
+var errExit = &object.Error{Message: "exit"}
+
+// runSource evaluates src in an environment configured by cfg, with args
+// bound as an array of strings, and returns the process exit code: the
+// argument to exit, 1 if the program failed, or 0 otherwise. If
+// printResult is set, a non-null result is printed.
+func runSource(src string, args []string, cfg evaluator.Config, printResult bool) int {
+	p := parser.New(lexer.New(src))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		for _, msg := range p.Errors() {
+			fmt.Fprintf(os.Stderr, "parse error: %s\n", msg)
+		}
+		return 1
+	}
+
+	macros := evaluator.NewMacroEnvironment(cfg)
+	evaluator.DefineMacros(program, macros)
+	expanded, err := evaluator.ExpandMacros(program, macros)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		return 1
+	}
+
+	status := 0
+	env := evaluator.NewEnvironment(cfg)
+	env.Set("args", stringArray(args))
+	env.Set("exit", exitBuiltin(&status))
+
+	result := evaluator.Eval(expanded, env)
+	if result == errExit {
+		return status
+	}
+	if err, ok := result.(*object.Error); ok {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err.Message)
+		return 1
+	}
+	if printResult && result != nil && result != evaluator.Null {
+		fmt.Println(result.Inspect())
+	}
+	return 0
+}
+
+// scriptConfig grants scripts run from the command line the files below
+// the working directory if allowFS is set, and the network if allowNet is.
+func scriptConfig(allowFS, allowNet bool) evaluator.Config {
+	var cfg evaluator.Config
+	if allowNet {
+		cfg.HTTP = http.DefaultTransport
+	}
+	if allowFS {
+		if wd, err := os.Getwd(); err == nil {
+			cfg.FS = &evaluator.FSConfig{Roots: []string{wd}}
+		}
+	}
+	return cfg
+}
+
+func stringArray(values []string) *object.Array {
+	elements := make([]object.Object, len(values))
+	for i, v := range values {
+		elements[i] = &object.String{Value: v}
+	}
+	return &object.Array{Elements: elements}
+}
+
+// exitBuiltin returns exit, which stores its argument, 0 by default, in
+// status and stops the program. The argument must be a valid exit status,
+// between 0 and 255.
+func exitBuiltin(status *int) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			code := 0
+			switch len(args) {
+			case 0:
+			case 1:
+				n, ok := args[0].(*object.Integer)
+				if !ok {
+					return &object.Error{
+						Message: fmt.Sprintf("argument to `exit` must be INTEGER, got %s", args[0].Type()),
+					}
+				}
+				if n.Value < 0 || n.Value > 255 {
+					return &object.Error{
+						Message: fmt.Sprintf("exit status must be between 0 and 255, got %d", n.Value),
+					}
+				}
+				code = int(n.Value)
+			default:
+				return &object.Error{
+					Message: fmt.Sprintf("wrong number of arguments. got=%d, want=0 or 1", len(args)),
+				}
+			}
+			*status = code
+			return errExit
+		},
+	}
+}
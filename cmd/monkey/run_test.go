@@ -0,0 +1,118 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vancanhuit/monkey/internal/evaluator"
+)
+
+// capture runs f with stdin reading from input and returns what it wrote
+// to stdout and stderr along with its result.
+func capture(t *testing.T, input string, f func() int) (string, string, int) {
+	t.Helper()
+
+	dir := t.TempDir()
+	files := make([]*os.File, 3)
+	for i, name := range []string{"stdin", "stdout", "stderr"} {
+		file, err := os.Create(filepath.Join(dir, name))
+		require.NoError(t, err)
+		defer file.Close()
+		files[i] = file
+	}
+	_, err := files[0].WriteString(input)
+	require.NoError(t, err)
+	_, err = files[0].Seek(0, io.SeekStart)
+	require.NoError(t, err)
+
+	stdin, stdout, stderr := os.Stdin, os.Stdout, os.Stderr
+	os.Stdin, os.Stdout, os.Stderr = files[0], files[1], files[2]
+	code := f()
+	os.Stdin, os.Stdout, os.Stderr = stdin, stdout, stderr
+
+	out, err := os.ReadFile(files[1].Name())
+	require.NoError(t, err)
+	errOut, err := os.ReadFile(files[2].Name())
+	require.NoError(t, err)
+	return string(out), string(errOut), code
+}
+
+func TestRunSource(t *testing.T) {
+	tests := []struct {
+		src         string
+		args        []string
+		printResult bool
+		stdout      string
+		stderr      string
+		code        int
+	}{
+		{`puts("hi"); 1 + 1`, nil, false, "hi\n", "", 0},
+		{`1 + 1`, nil, true, "2\n", "", 0},
+		{`puts(1)`, nil, true, "1\n", "", 0},
+		{`len(args)`, []string{"a", "b"}, true, "2\n", "", 0},
+		{`args[1]`, []string{"a", "b"}, true, "b\n", "", 0},
+		{`puts(1); exit(3); puts(2)`, nil, false, "1\n", "", 3},
+		{`let f = fn() { exit(4) }; map([1], fn(x) { f() }); 5`, nil, true, "", "", 4},
+		{`exit()`, nil, true, "", "", 0},
+		{`exit(255)`, nil, true, "", "", 255},
+		{`exit(256)`, nil, false, "", "error: exit status must be between 0 and 255, got 256\n", 1},
+		{`exit(-1)`, nil, false, "", "error: exit status must be between 0 and 255, got -1\n", 1},
+		{`exit("a")`, nil, false, "", "error: argument to `exit` must be INTEGER, got STRING\n", 1},
+		{`1 + true`, nil, true, "", "error: type mismatch: INTEGER + BOOLEAN\n", 1},
+		{`puts(1`, nil, false, "", "parse error: expected next token to be ), got EOF instead\n", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			stdout, stderr, code := capture(t, "", func() int {
+				return runSource(tt.src, tt.args, evaluator.Config{}, tt.printResult)
+			})
+			require.Equal(t, tt.stdout, stdout)
+			require.Equal(t, tt.stderr, stderr)
+			require.Equal(t, tt.code, code)
+		})
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "script.mk")
+	require.NoError(t, os.WriteFile(script, []byte(`puts(args); exit(len(args))`), 0o600))
+
+	tests := []struct {
+		name   string
+		args   []string
+		stdin  string
+		stdout string
+		stderr string
+		code   int
+	}{
+		{"file", []string{"run", script, "a", "-b"}, "", "[a, -b]\n", "", 2},
+		{"stdin", []string{"run", "-", "x"}, `puts(args[0])`, "x\n", "", 0},
+		{"piped", nil, `puts(3)`, "3\n", "", 0},
+		{"missing file", []string{"run", "missing.mk"}, "", "", "monkey: open missing.mk: no such file or directory\n", 1},
+		{"expression", []string{"-e", "args", "a"}, "", "[a]\n", "", 0},
+		{"flags before -e", []string{"-allow-fs", "-e", `fs.exists("missing.mk")`}, "", "false\n", "", 0},
+		{"no fs by default", []string{"-e", `fs.exists("x")`}, "", "", "error: identifier not found: fs\n", 1},
+		{"no http by default", []string{"run", "-", "x"}, `http.get("http://localhost/")`, "", "error: identifier not found: http\n", 1},
+		{
+			"http allowed",
+			[]string{"-allow-net", "-e", `http.get("x")`},
+			"",
+			"",
+			"error: request failed: Get \"x\": unsupported protocol scheme \"\"\n",
+			1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr, code := capture(t, tt.stdin, func() int { return runMain(tt.args) })
+			require.Equal(t, tt.stdout, stdout)
+			require.Equal(t, tt.stderr, stderr)
+			require.Equal(t, tt.code, code)
+		})
+	}
+}
@@ -22,6 +22,12 @@ type Expression interface {
 	expressionNode()
 }
 
+// TypeExpression is a type annotation, as in "let x: [int] = []".
+type TypeExpression interface {
+	Node
+	typeNode()
+}
+
 type Program struct {
 	Statements []Statement
 }
@@ -59,6 +65,7 @@ func (expr *Identifier) String() string {
 type LetStatement struct {
 	Token token.Token
 	Name  *Identifier
+	Type  TypeExpression // nil unless annotated
 	Value Expression
 }
 
@@ -71,6 +78,9 @@ func (stmt *LetStatement) String() string {
 
 	out.WriteString(stmt.TokenLiteral() + " ")
 	out.WriteString(stmt.Name.String())
+	if stmt.Type != nil {
+		out.WriteString(": " + stmt.Type.String())
+	}
 	out.WriteString(" = ")
 	if stmt.Value != nil {
 		out.WriteString(stmt.Value.String())
@@ -148,6 +158,19 @@ func (stmt *IntegerLiteral) String() string {
 	return stmt.Token.Literal
 }
 
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (expr *FloatLiteral) expressionNode() {}
+func (expr *FloatLiteral) TokenLiteral() string {
+	return expr.Token.Literal
+}
+func (expr *FloatLiteral) String() string {
+	return expr.Token.Literal
+}
+
 type PrefixExpression struct {
 	Token    token.Token
 	Operator string
@@ -231,7 +254,11 @@ func (expr *IfExpression) String() string {
 type FunctionLiteral struct {
 	Token      token.Token
 	Parameters []*Identifier
-	Body       *BlockStatement
+	// ParameterTypes holds the annotations of the parameters, nil for
+	// those without one. It is nil if no parameter is annotated.
+	ParameterTypes []TypeExpression
+	ReturnType     TypeExpression // nil unless annotated
+	Body           *BlockStatement
 }
 
 func (expr *FunctionLiteral) expressionNode() {}
@@ -241,6 +268,49 @@ func (expr *FunctionLiteral) TokenLiteral() string {
 func (expr *FunctionLiteral) String() string {
 	var out bytes.Buffer
 
+	params := []string{}
+	for i, p := range expr.Parameters {
+		if t := expr.ParameterType(i); t != nil {
+			params = append(params, p.String()+": "+t.String())
+			continue
+		}
+		params = append(params, p.String())
+	}
+
+	out.WriteString(expr.TokenLiteral())
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ","))
+	out.WriteString(")")
+	if expr.ReturnType != nil {
+		out.WriteString(" -> " + expr.ReturnType.String() + " ")
+	}
+	out.WriteString(expr.Body.String())
+
+	return out.String()
+}
+
+// ParameterType returns the annotation of the i-th parameter, or nil if
+// it has none.
+func (expr *FunctionLiteral) ParameterType(i int) TypeExpression {
+	if i < len(expr.ParameterTypes) {
+		return expr.ParameterTypes[i]
+	}
+	return nil
+}
+
+type MacroLiteral struct {
+	Token      token.Token
+	Parameters []*Identifier
+	Body       *BlockStatement
+}
+
+func (expr *MacroLiteral) expressionNode() {}
+func (expr *MacroLiteral) TokenLiteral() string {
+	return expr.Token.Literal
+}
+func (expr *MacroLiteral) String() string {
+	var out bytes.Buffer
+
 	params := []string{}
 	for _, p := range expr.Parameters {
 		params = append(params, p.String())
@@ -339,9 +409,28 @@ func (o *IndexExpression) String() string {
 	return out.String()
 }
 
+type MemberExpression struct {
+	Token    token.Token
+	Left     Expression
+	Property *Identifier
+}
+
+func (o *MemberExpression) expressionNode() {}
+func (o *MemberExpression) TokenLiteral() string {
+	return o.Token.Literal
+}
+func (o *MemberExpression) String() string {
+	return o.Left.String() + "." + o.Property.String()
+}
+
+type HashPair struct {
+	Key   Expression
+	Value Expression
+}
+
 type HashLiteral struct {
 	Token token.Token
-	Pairs map[Expression]Expression
+	Pairs []HashPair
 }
 
 func (o *HashLiteral) expressionNode() {}
@@ -352,8 +441,8 @@ func (o *HashLiteral) String() string {
 	var out bytes.Buffer
 
 	pairs := []string{}
-	for key, value := range o.Pairs {
-		pairs = append(pairs, key.String()+":"+value.String())
+	for _, pair := range o.Pairs {
+		pairs = append(pairs, pair.Key.String()+":"+pair.Value.String())
 	}
 
 	out.WriteString("{")
@@ -362,3 +451,83 @@ func (o *HashLiteral) String() string {
 
 	return out.String()
 }
+
+type AssignExpression struct {
+	Token  token.Token
+	Target *IndexExpression
+	Value  Expression
+}
+
+func (o *AssignExpression) expressionNode() {}
+func (o *AssignExpression) TokenLiteral() string {
+	return o.Token.Literal
+}
+func (o *AssignExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(o.Target.String())
+	out.WriteString(" = ")
+	out.WriteString(o.Value.String())
+
+	return out.String()
+}
+
+// NamedType is a type written as a name, such as int or any. A single
+// lowercase letter stands for a type that is not fixed by the annotation.
+type NamedType struct {
+	Token token.Token
+	Name  string
+}
+
+func (t *NamedType) typeNode() {}
+func (t *NamedType) TokenLiteral() string {
+	return t.Token.Literal
+}
+func (t *NamedType) String() string {
+	return t.Name
+}
+
+type ArrayType struct {
+	Token token.Token
+	Elem  TypeExpression
+}
+
+func (t *ArrayType) typeNode() {}
+func (t *ArrayType) TokenLiteral() string {
+	return t.Token.Literal
+}
+func (t *ArrayType) String() string {
+	return "[" + t.Elem.String() + "]"
+}
+
+type HashType struct {
+	Token token.Token
+	Key   TypeExpression
+	Value TypeExpression
+}
+
+func (t *HashType) typeNode() {}
+func (t *HashType) TokenLiteral() string {
+	return t.Token.Literal
+}
+func (t *HashType) String() string {
+	return "{" + t.Key.String() + ": " + t.Value.String() + "}"
+}
+
+type FunctionType struct {
+	Token      token.Token
+	Parameters []TypeExpression
+	Result     TypeExpression
+}
+
+func (t *FunctionType) typeNode() {}
+func (t *FunctionType) TokenLiteral() string {
+	return t.Token.Literal
+}
+func (t *FunctionType) String() string {
+	params := []string{}
+	for _, p := range t.Parameters {
+		params = append(params, p.String())
+	}
+	return "fn(" + strings.Join(params, ", ") + ") -> " + t.Result.String()
+}
@@ -0,0 +1,187 @@
+package ast
+
+import "fmt"
+
+// Copy returns a deep copy of node, so that the copy can be changed with
+// Modify without affecting the original. Tokens are copied along with the
+// nodes that hold them.
+func Copy(node Node) Node {
+	switch n := node.(type) {
+	case nil:
+		return nil
+	case *Program:
+		return &Program{Statements: copyStatements(n.Statements)}
+	case *BlockStatement:
+		return copyBlock(n)
+	case *LetStatement:
+		return &LetStatement{
+			Token: n.Token,
+			Name:  copyIdentifier(n.Name),
+			Type:  copyType(n.Type),
+			Value: copyExpression(n.Value),
+		}
+	case *ReturnStatement:
+		return &ReturnStatement{Token: n.Token, Value: copyExpression(n.Value)}
+	case *ExpressionStatement:
+		return &ExpressionStatement{Token: n.Token, Expression: copyExpression(n.Expression)}
+	case *Identifier:
+		return copyIdentifier(n)
+	case *IntegerLiteral:
+		c := *n
+		return &c
+	case *FloatLiteral:
+		c := *n
+		return &c
+	case *StringLiteral:
+		c := *n
+		return &c
+	case *Boolean:
+		c := *n
+		return &c
+	case *PrefixExpression:
+		return &PrefixExpression{Token: n.Token, Operator: n.Operator, Right: copyExpression(n.Right)}
+	case *InfixExpression:
+		return &InfixExpression{
+			Token:    n.Token,
+			Left:     copyExpression(n.Left),
+			Operator: n.Operator,
+			Right:    copyExpression(n.Right),
+		}
+	case *IfExpression:
+		return &IfExpression{
+			Token:       n.Token,
+			Condition:   copyExpression(n.Condition),
+			Consequence: copyBlock(n.Consequence),
+			Alternative: copyBlock(n.Alternative),
+		}
+	case *FunctionLiteral:
+		return &FunctionLiteral{
+			Token:          n.Token,
+			Parameters:     copyIdentifiers(n.Parameters),
+			ParameterTypes: copyTypes(n.ParameterTypes),
+			ReturnType:     copyType(n.ReturnType),
+			Body:           copyBlock(n.Body),
+		}
+	case *MacroLiteral:
+		return &MacroLiteral{Token: n.Token, Parameters: copyIdentifiers(n.Parameters), Body: copyBlock(n.Body)}
+	case *CallExpression:
+		return &CallExpression{
+			Token:     n.Token,
+			Function:  copyExpression(n.Function),
+			Arguments: copyExpressions(n.Arguments),
+		}
+	case *ArrayLiteral:
+		return &ArrayLiteral{Token: n.Token, Elements: copyExpressions(n.Elements)}
+	case *IndexExpression:
+		return copyIndex(n)
+	case *MemberExpression:
+		return &MemberExpression{Token: n.Token, Left: copyExpression(n.Left), Property: copyIdentifier(n.Property)}
+	case *HashLiteral:
+		var pairs []HashPair
+		if n.Pairs != nil {
+			pairs = make([]HashPair, len(n.Pairs))
+			for i, pair := range n.Pairs {
+				pairs[i] = HashPair{Key: copyExpression(pair.Key), Value: copyExpression(pair.Value)}
+			}
+		}
+		return &HashLiteral{Token: n.Token, Pairs: pairs}
+	case *AssignExpression:
+		return &AssignExpression{Token: n.Token, Target: copyIndex(n.Target), Value: copyExpression(n.Value)}
+	case *NamedType:
+		c := *n
+		return &c
+	case *ArrayType:
+		return &ArrayType{Token: n.Token, Elem: copyType(n.Elem)}
+	case *HashType:
+		return &HashType{Token: n.Token, Key: copyType(n.Key), Value: copyType(n.Value)}
+	case *FunctionType:
+		return &FunctionType{Token: n.Token, Parameters: copyTypes(n.Parameters), Result: copyType(n.Result)}
+	default:
+		panic(fmt.Sprintf("ast.Copy: unexpected node type %T", n))
+	}
+}
+
+// The helpers below keep nil children nil, rather than turning them into
+// typed nil pointers stored in an interface.
+
+func copyExpression(expr Expression) Expression {
+	if expr == nil {
+		return nil
+	}
+	return Copy(expr).(Expression)
+}
+
+func copyType(t TypeExpression) TypeExpression {
+	if t == nil {
+		return nil
+	}
+	return Copy(t).(TypeExpression)
+}
+
+func copyTypes(types []TypeExpression) []TypeExpression {
+	if types == nil {
+		return nil
+	}
+	c := make([]TypeExpression, len(types))
+	for i, t := range types {
+		c[i] = copyType(t)
+	}
+	return c
+}
+
+func copyIdentifier(ident *Identifier) *Identifier {
+	if ident == nil {
+		return nil
+	}
+	c := *ident
+	return &c
+}
+
+func copyBlock(block *BlockStatement) *BlockStatement {
+	if block == nil {
+		return nil
+	}
+	return &BlockStatement{Token: block.Token, Statements: copyStatements(block.Statements)}
+}
+
+func copyIndex(index *IndexExpression) *IndexExpression {
+	if index == nil {
+		return nil
+	}
+	return &IndexExpression{Token: index.Token, Left: copyExpression(index.Left), Index: copyExpression(index.Index)}
+}
+
+func copyStatements(stmts []Statement) []Statement {
+	if stmts == nil {
+		return nil
+	}
+	c := make([]Statement, len(stmts))
+	for i, s := range stmts {
+		if s != nil {
+			c[i] = Copy(s).(Statement)
+		}
+	}
+	return c
+}
+
+func copyExpressions(exprs []Expression) []Expression {
+	if exprs == nil {
+		return nil
+	}
+	c := make([]Expression, len(exprs))
+	for i, e := range exprs {
+		c[i] = copyExpression(e)
+	}
+	return c
+}
+
+func copyIdentifiers(idents []*Identifier) []*Identifier {
+	if idents == nil {
+		return nil
+	}
+	c := make([]*Identifier, len(idents))
+	for i, ident := range idents {
+		c[i] = copyIdentifier(ident)
+	}
+	return c
+}
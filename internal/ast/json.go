@@ -0,0 +1,516 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/vancanhuit/monkey/internal/token"
+)
+
+// A program is encoded as a tree of JSON objects, one per node. Every
+// object has a "type" naming the node (for example "LetStatement") and,
+// except for the program itself, a "token" holding the type, literal and
+// position of the node's token. The node's own fields follow under their
+// lower-cased Go names, except for the type annotation of a let
+// statement, which is under "annotation". Missing children are null and
+// lists are always arrays, so every node of a given type has the same set
+// of keys. A hash pair is an object with "key" and "value", and the
+// parameter types of a function have one entry per parameter.
+
+// MarshalJSON encodes the program and every node in it.
+func (p *Program) MarshalJSON() ([]byte, error) {
+	return json.Marshal(encodeNode(p))
+}
+
+// UnmarshalJSON decodes a program encoded by MarshalJSON.
+func (p *Program) UnmarshalJSON(data []byte) error {
+	d := &decoder{}
+	node := d.node(data)
+	if d.err != nil {
+		return d.err
+	}
+	program, ok := node.(*Program)
+	if !ok {
+		return fmt.Errorf("expected Program, got %s", nodeType(node))
+	}
+	*p = *program
+	return nil
+}
+
+// object is a JSON object that keeps its keys in order, so that every
+// node starts with its type.
+type object []field
+
+type field struct {
+	key   string
+	value interface{}
+}
+
+func (o object) MarshalJSON() ([]byte, error) {
+	var out bytes.Buffer
+	out.WriteString("{")
+	for i, f := range o {
+		if i > 0 {
+			out.WriteString(",")
+		}
+		key, err := json.Marshal(f.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		out.Write(key)
+		out.WriteString(":")
+		out.Write(value)
+	}
+	out.WriteString("}")
+	return out.Bytes(), nil
+}
+
+func encodeNode(node Node) interface{} {
+	switch n := node.(type) {
+	case nil:
+		return nil
+	case *Program:
+		return object{{"type", "Program"}, {"statements", encodeStatements(n.Statements)}}
+	case *LetStatement:
+		return encodeObject("LetStatement", n.Token, object{
+			{"name", encodeIdentifier(n.Name)},
+			{"annotation", encodeType(n.Type)},
+			{"value", encodeExpression(n.Value)},
+		})
+	case *ReturnStatement:
+		return encodeObject("ReturnStatement", n.Token, object{{"value", encodeExpression(n.Value)}})
+	case *ExpressionStatement:
+		return encodeObject("ExpressionStatement", n.Token, object{{"expression", encodeExpression(n.Expression)}})
+	case *BlockStatement:
+		return encodeObject("BlockStatement", n.Token, object{{"statements", encodeStatements(n.Statements)}})
+	case *Identifier:
+		return encodeObject("Identifier", n.Token, object{{"value", n.Value}})
+	case *IntegerLiteral:
+		return encodeObject("IntegerLiteral", n.Token, object{{"value", n.Value}})
+	case *FloatLiteral:
+		return encodeObject("FloatLiteral", n.Token, object{{"value", n.Value}})
+	case *StringLiteral:
+		return encodeObject("StringLiteral", n.Token, object{{"value", n.Value}})
+	case *Boolean:
+		return encodeObject("Boolean", n.Token, object{{"value", n.Value}})
+	case *PrefixExpression:
+		return encodeObject("PrefixExpression", n.Token, object{
+			{"operator", n.Operator},
+			{"right", encodeExpression(n.Right)},
+		})
+	case *InfixExpression:
+		return encodeObject("InfixExpression", n.Token, object{
+			{"left", encodeExpression(n.Left)},
+			{"operator", n.Operator},
+			{"right", encodeExpression(n.Right)},
+		})
+	case *IfExpression:
+		return encodeObject("IfExpression", n.Token, object{
+			{"condition", encodeExpression(n.Condition)},
+			{"consequence", encodeBlock(n.Consequence)},
+			{"alternative", encodeBlock(n.Alternative)},
+		})
+	case *FunctionLiteral:
+		params := make([]interface{}, len(n.Parameters))
+		for i, p := range n.Parameters {
+			params[i] = encodeIdentifier(p)
+		}
+		paramTypes := make([]interface{}, len(n.Parameters))
+		for i := range n.Parameters {
+			paramTypes[i] = encodeType(n.ParameterType(i))
+		}
+		return encodeObject("FunctionLiteral", n.Token, object{
+			{"parameters", params},
+			{"parameterTypes", paramTypes},
+			{"returnType", encodeType(n.ReturnType)},
+			{"body", encodeBlock(n.Body)},
+		})
+	case *MacroLiteral:
+		params := make([]interface{}, len(n.Parameters))
+		for i, p := range n.Parameters {
+			params[i] = encodeIdentifier(p)
+		}
+		return encodeObject("MacroLiteral", n.Token, object{
+			{"parameters", params},
+			{"body", encodeBlock(n.Body)},
+		})
+	case *CallExpression:
+		return encodeObject("CallExpression", n.Token, object{
+			{"function", encodeExpression(n.Function)},
+			{"arguments", encodeExpressions(n.Arguments)},
+		})
+	case *ArrayLiteral:
+		return encodeObject("ArrayLiteral", n.Token, object{{"elements", encodeExpressions(n.Elements)}})
+	case *IndexExpression:
+		return encodeObject("IndexExpression", n.Token, object{
+			{"left", encodeExpression(n.Left)},
+			{"index", encodeExpression(n.Index)},
+		})
+	case *MemberExpression:
+		return encodeObject("MemberExpression", n.Token, object{
+			{"left", encodeExpression(n.Left)},
+			{"property", encodeIdentifier(n.Property)},
+		})
+	case *HashLiteral:
+		pairs := make([]interface{}, len(n.Pairs))
+		for i, pair := range n.Pairs {
+			pairs[i] = object{{"key", encodeExpression(pair.Key)}, {"value", encodeExpression(pair.Value)}}
+		}
+		return encodeObject("HashLiteral", n.Token, object{{"pairs", pairs}})
+	case *AssignExpression:
+		var target interface{}
+		if n.Target != nil {
+			target = encodeNode(n.Target)
+		}
+		return encodeObject("AssignExpression", n.Token, object{
+			{"target", target},
+			{"value", encodeExpression(n.Value)},
+		})
+	case *NamedType:
+		return encodeObject("NamedType", n.Token, object{{"name", n.Name}})
+	case *ArrayType:
+		return encodeObject("ArrayType", n.Token, object{{"elem", encodeType(n.Elem)}})
+	case *HashType:
+		return encodeObject("HashType", n.Token, object{
+			{"key", encodeType(n.Key)},
+			{"value", encodeType(n.Value)},
+		})
+	case *FunctionType:
+		params := make([]interface{}, len(n.Parameters))
+		for i, p := range n.Parameters {
+			params[i] = encodeType(p)
+		}
+		return encodeObject("FunctionType", n.Token, object{
+			{"parameters", params},
+			{"result", encodeType(n.Result)},
+		})
+	default:
+		panic(fmt.Sprintf("ast: cannot encode node of type %T", n))
+	}
+}
+
+func encodeObject(typ string, tok token.Token, fields object) object {
+	return append(object{{"type", typ}, {"token", tok}}, fields...)
+}
+
+// The helpers below turn typed nil pointers into JSON null rather than
+// letting them reach encodeNode as non-nil interfaces.
+
+func encodeExpression(expr Expression) interface{} {
+	if expr == nil {
+		return nil
+	}
+	return encodeNode(expr)
+}
+
+func encodeType(t TypeExpression) interface{} {
+	if t == nil {
+		return nil
+	}
+	return encodeNode(t)
+}
+
+func encodeIdentifier(ident *Identifier) interface{} {
+	if ident == nil {
+		return nil
+	}
+	return encodeNode(ident)
+}
+
+func encodeBlock(block *BlockStatement) interface{} {
+	if block == nil {
+		return nil
+	}
+	return encodeNode(block)
+}
+
+func encodeStatements(stmts []Statement) []interface{} {
+	encoded := make([]interface{}, len(stmts))
+	for i, s := range stmts {
+		encoded[i] = encodeNode(s)
+	}
+	return encoded
+}
+
+func encodeExpressions(exprs []Expression) []interface{} {
+	encoded := make([]interface{}, len(exprs))
+	for i, e := range exprs {
+		encoded[i] = encodeExpression(e)
+	}
+	return encoded
+}
+
+// decoder rebuilds nodes from their JSON encoding, keeping the first error
+// so that the code walking the tree need not check after every field.
+type decoder struct {
+	err error
+}
+
+func (d *decoder) fail(format string, a ...interface{}) {
+	if d.err == nil {
+		d.err = fmt.Errorf(format, a...)
+	}
+}
+
+func (d *decoder) fields(data json.RawMessage) map[string]json.RawMessage {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		d.fail("invalid node: %s", err)
+	}
+	return fields
+}
+
+func (d *decoder) value(data json.RawMessage, v interface{}) {
+	if d.err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		d.fail("invalid node: %s", err)
+	}
+}
+
+// node decodes a single node, returning nil for JSON null.
+func (d *decoder) node(data json.RawMessage) Node {
+	if d.err != nil || isNull(data) {
+		return nil
+	}
+	f := d.fields(data)
+	if d.err != nil {
+		return nil
+	}
+
+	var typ string
+	d.value(f["type"], &typ)
+	var tok token.Token
+	if typ != "Program" {
+		d.value(f["token"], &tok)
+	}
+	if d.err != nil {
+		return nil
+	}
+
+	switch typ {
+	case "Program":
+		return &Program{Statements: d.statements(f["statements"])}
+	case "LetStatement":
+		return &LetStatement{
+			Token: tok,
+			Name:  d.identifier(f["name"]),
+			Type:  d.typeExpression(f["annotation"]),
+			Value: d.expression(f["value"]),
+		}
+	case "ReturnStatement":
+		return &ReturnStatement{Token: tok, Value: d.expression(f["value"])}
+	case "ExpressionStatement":
+		return &ExpressionStatement{Token: tok, Expression: d.expression(f["expression"])}
+	case "BlockStatement":
+		return &BlockStatement{Token: tok, Statements: d.statements(f["statements"])}
+	case "Identifier":
+		n := &Identifier{Token: tok}
+		d.value(f["value"], &n.Value)
+		return n
+	case "IntegerLiteral":
+		n := &IntegerLiteral{Token: tok}
+		d.value(f["value"], &n.Value)
+		return n
+	case "FloatLiteral":
+		n := &FloatLiteral{Token: tok}
+		d.value(f["value"], &n.Value)
+		return n
+	case "StringLiteral":
+		n := &StringLiteral{Token: tok}
+		d.value(f["value"], &n.Value)
+		return n
+	case "Boolean":
+		n := &Boolean{Token: tok}
+		d.value(f["value"], &n.Value)
+		return n
+	case "PrefixExpression":
+		n := &PrefixExpression{Token: tok, Right: d.expression(f["right"])}
+		d.value(f["operator"], &n.Operator)
+		return n
+	case "InfixExpression":
+		n := &InfixExpression{Token: tok, Left: d.expression(f["left"]), Right: d.expression(f["right"])}
+		d.value(f["operator"], &n.Operator)
+		return n
+	case "IfExpression":
+		return &IfExpression{
+			Token:       tok,
+			Condition:   d.expression(f["condition"]),
+			Consequence: d.block(f["consequence"]),
+			Alternative: d.block(f["alternative"]),
+		}
+	case "FunctionLiteral":
+		return &FunctionLiteral{
+			Token:          tok,
+			Parameters:     d.parameters(f["parameters"]),
+			ParameterTypes: d.parameterTypes(f["parameterTypes"]),
+			ReturnType:     d.typeExpression(f["returnType"]),
+			Body:           d.block(f["body"]),
+		}
+	case "MacroLiteral":
+		return &MacroLiteral{Token: tok, Parameters: d.parameters(f["parameters"]), Body: d.block(f["body"])}
+	case "CallExpression":
+		return &CallExpression{Token: tok, Function: d.expression(f["function"]), Arguments: d.expressions(f["arguments"])}
+	case "ArrayLiteral":
+		return &ArrayLiteral{Token: tok, Elements: d.expressions(f["elements"])}
+	case "IndexExpression":
+		return &IndexExpression{Token: tok, Left: d.expression(f["left"]), Index: d.expression(f["index"])}
+	case "MemberExpression":
+		return &MemberExpression{Token: tok, Left: d.expression(f["left"]), Property: d.identifier(f["property"])}
+	case "HashLiteral":
+		var raw []struct {
+			Key   json.RawMessage `json:"key"`
+			Value json.RawMessage `json:"value"`
+		}
+		d.value(f["pairs"], &raw)
+		pairs := make([]HashPair, len(raw))
+		for i, pair := range raw {
+			pairs[i] = HashPair{Key: d.expression(pair.Key), Value: d.expression(pair.Value)}
+		}
+		return &HashLiteral{Token: tok, Pairs: pairs}
+	case "AssignExpression":
+		n := &AssignExpression{Token: tok, Value: d.expression(f["value"])}
+		if target := d.node(f["target"]); target != nil {
+			n.Target, _ = target.(*IndexExpression)
+			if n.Target == nil {
+				d.fail("expected IndexExpression, got %s", nodeType(target))
+			}
+		}
+		return n
+	case "NamedType":
+		n := &NamedType{Token: tok}
+		d.value(f["name"], &n.Name)
+		return n
+	case "ArrayType":
+		return &ArrayType{Token: tok, Elem: d.typeExpression(f["elem"])}
+	case "HashType":
+		return &HashType{Token: tok, Key: d.typeExpression(f["key"]), Value: d.typeExpression(f["value"])}
+	case "FunctionType":
+		return &FunctionType{Token: tok, Parameters: d.types(f["parameters"]), Result: d.typeExpression(f["result"])}
+	default:
+		d.fail("unknown node type %q", typ)
+		return nil
+	}
+}
+
+func (d *decoder) expression(data json.RawMessage) Expression {
+	node := d.node(data)
+	if node == nil {
+		return nil
+	}
+	expr, ok := node.(Expression)
+	if !ok {
+		d.fail("expected an expression, got %s", nodeType(node))
+	}
+	return expr
+}
+
+func (d *decoder) identifier(data json.RawMessage) *Identifier {
+	node := d.node(data)
+	if node == nil {
+		return nil
+	}
+	ident, ok := node.(*Identifier)
+	if !ok {
+		d.fail("expected Identifier, got %s", nodeType(node))
+	}
+	return ident
+}
+
+func (d *decoder) parameters(data json.RawMessage) []*Identifier {
+	var raw []json.RawMessage
+	d.value(data, &raw)
+	params := make([]*Identifier, len(raw))
+	for i, p := range raw {
+		params[i] = d.identifier(p)
+	}
+	return params
+}
+
+func (d *decoder) typeExpression(data json.RawMessage) TypeExpression {
+	node := d.node(data)
+	if node == nil {
+		return nil
+	}
+	t, ok := node.(TypeExpression)
+	if !ok {
+		d.fail("expected a type, got %s", nodeType(node))
+	}
+	return t
+}
+
+func (d *decoder) types(data json.RawMessage) []TypeExpression {
+	var raw []json.RawMessage
+	d.value(data, &raw)
+	types := make([]TypeExpression, len(raw))
+	for i, r := range raw {
+		types[i] = d.typeExpression(r)
+	}
+	return types
+}
+
+// parameterTypes decodes the parameter types of a function, which are nil
+// unless at least one of them is set, as the parser leaves them.
+func (d *decoder) parameterTypes(data json.RawMessage) []TypeExpression {
+	types := d.types(data)
+	for _, t := range types {
+		if t != nil {
+			return types
+		}
+	}
+	return nil
+}
+
+func (d *decoder) block(data json.RawMessage) *BlockStatement {
+	node := d.node(data)
+	if node == nil {
+		return nil
+	}
+	block, ok := node.(*BlockStatement)
+	if !ok {
+		d.fail("expected BlockStatement, got %s", nodeType(node))
+	}
+	return block
+}
+
+func (d *decoder) statements(data json.RawMessage) []Statement {
+	var raw []json.RawMessage
+	d.value(data, &raw)
+	stmts := make([]Statement, 0, len(raw))
+	for _, r := range raw {
+		node := d.node(r)
+		stmt, ok := node.(Statement)
+		if !ok {
+			d.fail("expected a statement, got %s", nodeType(node))
+		}
+		stmts = append(stmts, stmt)
+	}
+	return stmts
+}
+
+func (d *decoder) expressions(data json.RawMessage) []Expression {
+	var raw []json.RawMessage
+	d.value(data, &raw)
+	exprs := make([]Expression, len(raw))
+	for i, r := range raw {
+		exprs[i] = d.expression(r)
+	}
+	return exprs
+}
+
+func isNull(data json.RawMessage) bool {
+	return len(data) == 0 || string(data) == "null"
+}
+
+func nodeType(node Node) string {
+	if node == nil {
+		return "null"
+	}
+	return fmt.Sprintf("%T", node)[len("*ast."):]
+}
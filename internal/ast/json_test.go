@@ -0,0 +1,109 @@
+package ast
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vancanhuit/monkey/internal/token"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	program := &Program{Statements: []Statement{
+		&ReturnStatement{
+			Token: token.Token{Type: token.Return, Literal: "return", Line: 1, Column: 1},
+			Value: &PrefixExpression{
+				Token:    token.Token{Type: token.Bang, Literal: "!", Line: 1, Column: 8},
+				Operator: "!",
+				Right:    &Boolean{Token: token.Token{Type: token.True, Literal: "true", Line: 1, Column: 9}, Value: true},
+			},
+		},
+		&ReturnStatement{Token: token.Token{Type: token.Return, Literal: "return", Line: 2, Column: 1}},
+	}}
+
+	data, err := json.Marshal(program)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"type": "Program",
+		"statements": [
+			{
+				"type": "ReturnStatement",
+				"token": {"type": "RETURN", "literal": "return", "line": 1, "column": 1},
+				"value": {
+					"type": "PrefixExpression",
+					"token": {"type": "!", "literal": "!", "line": 1, "column": 8},
+					"operator": "!",
+					"right": {
+						"type": "Boolean",
+						"token": {"type": "TRUE", "literal": "true", "line": 1, "column": 9},
+						"value": true
+					}
+				}
+			},
+			{
+				"type": "ReturnStatement",
+				"token": {"type": "RETURN", "literal": "return", "line": 2, "column": 1},
+				"value": null
+			}
+		]
+	}`, string(data))
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	testCases := []*Program{
+		{Statements: []Statement{}},
+		sampleProgram(),
+		{Statements: []Statement{
+			exprStmt(&FunctionLiteral{Parameters: []*Identifier{}, Body: block()}),
+			exprStmt(&MacroLiteral{Parameters: []*Identifier{ident("x")}, Body: block(exprStmt(ident("x")))}),
+			exprStmt(&CallExpression{Function: ident("f"), Arguments: []Expression{}}),
+			exprStmt(&HashLiteral{Pairs: []HashPair{}}),
+			exprStmt(&IfExpression{Condition: integer(0), Consequence: block()}),
+			&LetStatement{Name: ident("big"), Value: integer(9223372036854775807)},
+		}},
+	}
+
+	for _, program := range testCases {
+		data, err := json.Marshal(program)
+		require.NoError(t, err)
+
+		decoded := &Program{}
+		require.NoError(t, json.Unmarshal(data, decoded))
+		require.Equal(t, program, decoded)
+	}
+}
+
+func TestUnmarshalJSONErrors(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{`{"type": "Identifier", "token": {}, "value": "x"}`, "expected Program, got Identifier"},
+		{`{"type": "Program", "statements": [{"type": "Macro", "token": {}}]}`, `unknown node type "Macro"`},
+		{`{"type": "Program", "statements": [null]}`, "expected a statement, got null"},
+		{
+			`{"type": "Program", "statements": [{"type": "Identifier", "token": {}, "value": "x"}]}`,
+			"expected a statement, got Identifier",
+		},
+		{
+			`{"type": "Program", "statements": [{"type": "ReturnStatement", "token": {}, "value": {"type": "BlockStatement", "token": {}, "statements": []}}]}`,
+			"expected an expression, got BlockStatement",
+		},
+		{
+			`{"type": "Program", "statements": [{"type": "LetStatement", "token": {}, "name": {"type": "Boolean", "token": {}, "value": true}, "value": null}]}`,
+			"expected Identifier, got Boolean",
+		},
+		{
+			`{"type": "Program", "statements": [{"type": "ExpressionStatement", "token": {}, "expression": {"type": "IntegerLiteral", "token": {}, "value": "1"}}]}`,
+			"invalid node: json: cannot unmarshal string into Go value of type int64",
+		},
+	}
+
+	for _, tc := range testCases {
+		err := json.Unmarshal([]byte(tc.input), &Program{})
+		require.EqualError(t, err, tc.expected, tc.input)
+	}
+
+	err := json.Unmarshal([]byte(`[]`), &Program{})
+	require.ErrorContains(t, err, "invalid node: json: cannot unmarshal array")
+}
@@ -0,0 +1,59 @@
+package ast
+
+import "github.com/vancanhuit/monkey/internal/token"
+
+// Start returns the leftmost token of node, which gives its position in
+// the source. It returns the zero token for a program.
+func Start(node Node) token.Token {
+	switch n := node.(type) {
+	case *LetStatement:
+		return n.Token
+	case *ReturnStatement:
+		return n.Token
+	case *ExpressionStatement:
+		return n.Token
+	case *InfixExpression:
+		return Start(n.Left)
+	case *CallExpression:
+		return Start(n.Function)
+	case *IndexExpression:
+		return Start(n.Left)
+	case *MemberExpression:
+		return Start(n.Left)
+	case *AssignExpression:
+		return Start(n.Target)
+	case *Identifier:
+		return n.Token
+	case *IntegerLiteral:
+		return n.Token
+	case *FloatLiteral:
+		return n.Token
+	case *StringLiteral:
+		return n.Token
+	case *Boolean:
+		return n.Token
+	case *PrefixExpression:
+		return n.Token
+	case *IfExpression:
+		return n.Token
+	case *FunctionLiteral:
+		return n.Token
+	case *MacroLiteral:
+		return n.Token
+	case *ArrayLiteral:
+		return n.Token
+	case *HashLiteral:
+		return n.Token
+	case *BlockStatement:
+		return n.Token
+	case *NamedType:
+		return n.Token
+	case *ArrayType:
+		return n.Token
+	case *HashType:
+		return n.Token
+	case *FunctionType:
+		return n.Token
+	}
+	return token.Token{}
+}
@@ -0,0 +1,285 @@
+package ast
+
+import "fmt"
+
+// A Visitor's Visit method is invoked for each node encountered by Walk.
+// If the result visitor w is not nil, Walk visits each of the children of
+// node with the visitor w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses an AST in depth-first order: it starts by calling
+// v.Visit(node); node must not be nil. If the visitor w returned by
+// v.Visit(node) is not nil, Walk is invoked recursively with visitor w for
+// each of the non-nil children of node, followed by a call of w.Visit(nil).
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		walkStatements(v, n.Statements)
+
+	case *BlockStatement:
+		walkStatements(v, n.Statements)
+
+	case *LetStatement:
+		Walk(v, n.Name)
+		if n.Type != nil {
+			Walk(v, n.Type)
+		}
+		if n.Value != nil {
+			Walk(v, n.Value)
+		}
+
+	case *ReturnStatement:
+		if n.Value != nil {
+			Walk(v, n.Value)
+		}
+
+	case *ExpressionStatement:
+		if n.Expression != nil {
+			Walk(v, n.Expression)
+		}
+
+	case *Identifier, *IntegerLiteral, *FloatLiteral, *StringLiteral, *Boolean:
+		// nothing to do
+
+	case *PrefixExpression:
+		Walk(v, n.Right)
+
+	case *InfixExpression:
+		Walk(v, n.Left)
+		Walk(v, n.Right)
+
+	case *IfExpression:
+		Walk(v, n.Condition)
+		Walk(v, n.Consequence)
+		if n.Alternative != nil {
+			Walk(v, n.Alternative)
+		}
+
+	case *FunctionLiteral:
+		for i, p := range n.Parameters {
+			Walk(v, p)
+			if t := n.ParameterType(i); t != nil {
+				Walk(v, t)
+			}
+		}
+		if n.ReturnType != nil {
+			Walk(v, n.ReturnType)
+		}
+		Walk(v, n.Body)
+
+	case *MacroLiteral:
+		for _, p := range n.Parameters {
+			Walk(v, p)
+		}
+		Walk(v, n.Body)
+
+	case *CallExpression:
+		Walk(v, n.Function)
+		walkExpressions(v, n.Arguments)
+
+	case *ArrayLiteral:
+		walkExpressions(v, n.Elements)
+
+	case *IndexExpression:
+		Walk(v, n.Left)
+		Walk(v, n.Index)
+
+	case *MemberExpression:
+		Walk(v, n.Left)
+		Walk(v, n.Property)
+
+	case *HashLiteral:
+		for _, pair := range n.Pairs {
+			Walk(v, pair.Key)
+			Walk(v, pair.Value)
+		}
+
+	case *AssignExpression:
+		Walk(v, n.Target)
+		Walk(v, n.Value)
+
+	case *NamedType:
+		// nothing to do
+
+	case *ArrayType:
+		Walk(v, n.Elem)
+
+	case *HashType:
+		Walk(v, n.Key)
+		Walk(v, n.Value)
+
+	case *FunctionType:
+		for _, p := range n.Parameters {
+			Walk(v, p)
+		}
+		Walk(v, n.Result)
+
+	default:
+		panic(fmt.Sprintf("ast.Walk: unexpected node type %T", n))
+	}
+
+	v.Visit(nil)
+}
+
+func walkStatements(v Visitor, stmts []Statement) {
+	for _, s := range stmts {
+		Walk(v, s)
+	}
+}
+
+func walkExpressions(v Visitor, exprs []Expression) {
+	for _, e := range exprs {
+		Walk(v, e)
+	}
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses an AST in depth-first order: it starts by calling
+// f(node); node must not be nil. If f returns true, Inspect invokes f
+// recursively for each of the non-nil children of node, followed by a
+// call of f(nil).
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
+
+// ModifierFunc returns the replacement for a node, or the node itself to
+// leave it unchanged.
+type ModifierFunc func(Node) Node
+
+// Modify rewrites an AST bottom-up: the children of node are modified
+// first and stored back in place, then modifier is applied to node itself
+// and its result returned. A replacement must have the same kind as the
+// node it replaces: a statement for a statement, an expression for an
+// expression, an identifier for a parameter or a name, a type for a type
+// annotation, and so on. Nodes of the wrong kind are dropped, leaving the
+// field nil.
+func Modify(node Node, modifier ModifierFunc) Node {
+	switch n := node.(type) {
+	case *Program:
+		n.Statements = modifyStatements(n.Statements, modifier)
+
+	case *BlockStatement:
+		n.Statements = modifyStatements(n.Statements, modifier)
+
+	case *LetStatement:
+		n.Name, _ = Modify(n.Name, modifier).(*Identifier)
+		if n.Type != nil {
+			n.Type, _ = Modify(n.Type, modifier).(TypeExpression)
+		}
+		if n.Value != nil {
+			n.Value, _ = Modify(n.Value, modifier).(Expression)
+		}
+
+	case *ReturnStatement:
+		if n.Value != nil {
+			n.Value, _ = Modify(n.Value, modifier).(Expression)
+		}
+
+	case *ExpressionStatement:
+		if n.Expression != nil {
+			n.Expression, _ = Modify(n.Expression, modifier).(Expression)
+		}
+
+	case *PrefixExpression:
+		n.Right, _ = Modify(n.Right, modifier).(Expression)
+
+	case *InfixExpression:
+		n.Left, _ = Modify(n.Left, modifier).(Expression)
+		n.Right, _ = Modify(n.Right, modifier).(Expression)
+
+	case *IfExpression:
+		n.Condition, _ = Modify(n.Condition, modifier).(Expression)
+		n.Consequence, _ = Modify(n.Consequence, modifier).(*BlockStatement)
+		if n.Alternative != nil {
+			n.Alternative, _ = Modify(n.Alternative, modifier).(*BlockStatement)
+		}
+
+	case *FunctionLiteral:
+		for i, p := range n.Parameters {
+			n.Parameters[i], _ = Modify(p, modifier).(*Identifier)
+		}
+		for i, t := range n.ParameterTypes {
+			if t != nil {
+				n.ParameterTypes[i], _ = Modify(t, modifier).(TypeExpression)
+			}
+		}
+		if n.ReturnType != nil {
+			n.ReturnType, _ = Modify(n.ReturnType, modifier).(TypeExpression)
+		}
+		n.Body, _ = Modify(n.Body, modifier).(*BlockStatement)
+
+	case *MacroLiteral:
+		for i, p := range n.Parameters {
+			n.Parameters[i], _ = Modify(p, modifier).(*Identifier)
+		}
+		n.Body, _ = Modify(n.Body, modifier).(*BlockStatement)
+
+	case *CallExpression:
+		n.Function, _ = Modify(n.Function, modifier).(Expression)
+		n.Arguments = modifyExpressions(n.Arguments, modifier)
+
+	case *ArrayLiteral:
+		n.Elements = modifyExpressions(n.Elements, modifier)
+
+	case *IndexExpression:
+		n.Left, _ = Modify(n.Left, modifier).(Expression)
+		n.Index, _ = Modify(n.Index, modifier).(Expression)
+
+	case *MemberExpression:
+		n.Left, _ = Modify(n.Left, modifier).(Expression)
+		n.Property, _ = Modify(n.Property, modifier).(*Identifier)
+
+	case *HashLiteral:
+		for i, pair := range n.Pairs {
+			n.Pairs[i].Key, _ = Modify(pair.Key, modifier).(Expression)
+			n.Pairs[i].Value, _ = Modify(pair.Value, modifier).(Expression)
+		}
+
+	case *AssignExpression:
+		n.Target, _ = Modify(n.Target, modifier).(*IndexExpression)
+		n.Value, _ = Modify(n.Value, modifier).(Expression)
+
+	case *ArrayType:
+		n.Elem, _ = Modify(n.Elem, modifier).(TypeExpression)
+
+	case *HashType:
+		n.Key, _ = Modify(n.Key, modifier).(TypeExpression)
+		n.Value, _ = Modify(n.Value, modifier).(TypeExpression)
+
+	case *FunctionType:
+		for i, p := range n.Parameters {
+			n.Parameters[i], _ = Modify(p, modifier).(TypeExpression)
+		}
+		n.Result, _ = Modify(n.Result, modifier).(TypeExpression)
+	}
+
+	return modifier(node)
+}
+
+func modifyStatements(stmts []Statement, modifier ModifierFunc) []Statement {
+	for i, s := range stmts {
+		stmts[i], _ = Modify(s, modifier).(Statement)
+	}
+	return stmts
+}
+
+func modifyExpressions(exprs []Expression, modifier ModifierFunc) []Expression {
+	for i, e := range exprs {
+		exprs[i], _ = Modify(e, modifier).(Expression)
+	}
+	return exprs
+}
@@ -0,0 +1,265 @@
+package ast
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vancanhuit/monkey/internal/token"
+)
+
+func ident(name string) *Identifier {
+	return &Identifier{Token: token.Token{Type: token.Identifier, Literal: name}, Value: name}
+}
+
+func integer(value int64) *IntegerLiteral {
+	literal := fmt.Sprint(value)
+	return &IntegerLiteral{Token: token.Token{Type: token.Integer, Literal: literal}, Value: value}
+}
+
+func str(value string) *StringLiteral {
+	return &StringLiteral{Token: token.Token{Type: token.String, Literal: value}, Value: value}
+}
+
+func block(stmts ...Statement) *BlockStatement {
+	return &BlockStatement{Token: token.Token{Type: token.LeftBrace, Literal: "{"}, Statements: append([]Statement{}, stmts...)}
+}
+
+func exprStmt(expr Expression) *ExpressionStatement {
+	return &ExpressionStatement{Expression: expr}
+}
+
+func infix(left Expression, op string, right Expression) *InfixExpression {
+	return &InfixExpression{Token: token.Token{Literal: op}, Left: left, Operator: op, Right: right}
+}
+
+// sampleProgram builds a program that has every kind of node:
+//
+//	let f = fn(a, b) { if (a) { return -a; } else { b } };
+//	f(1, 2.5)[{"k": true}.k] = [m.x + 1, "s"];
+func sampleProgram() *Program {
+	fn := &FunctionLiteral{
+		Token:      token.Token{Type: token.Function, Literal: "fn"},
+		Parameters: []*Identifier{ident("a"), ident("b")},
+		Body: block(exprStmt(&IfExpression{
+			Token:     token.Token{Type: token.If, Literal: "if"},
+			Condition: ident("a"),
+			Consequence: block(&ReturnStatement{
+				Token: token.Token{Type: token.Return, Literal: "return"},
+				Value: &PrefixExpression{Token: token.Token{Literal: "-"}, Operator: "-", Right: ident("a")},
+			}),
+			Alternative: block(exprStmt(ident("b"))),
+		})),
+	}
+	call := &CallExpression{
+		Token:     token.Token{Type: token.LeftParen, Literal: "("},
+		Function:  ident("f"),
+		Arguments: []Expression{integer(1), &FloatLiteral{Token: token.Token{Type: token.Float, Literal: "2.5"}, Value: 2.5}},
+	}
+	hash := &HashLiteral{
+		Token: token.Token{Type: token.LeftBrace, Literal: "{"},
+		Pairs: []HashPair{{Key: str("k"), Value: &Boolean{Token: token.Token{Type: token.True, Literal: "true"}, Value: true}}},
+	}
+	assign := &AssignExpression{
+		Token: token.Token{Type: token.Assign, Literal: "="},
+		Target: &IndexExpression{
+			Token: token.Token{Type: token.LeftBracket, Literal: "["},
+			Left:  call,
+			Index: &MemberExpression{Token: token.Token{Type: token.Dot, Literal: "."}, Left: hash, Property: ident("k")},
+		},
+		Value: &ArrayLiteral{
+			Token: token.Token{Type: token.LeftBracket, Literal: "["},
+			Elements: []Expression{
+				infix(&MemberExpression{Token: token.Token{Type: token.Dot, Literal: "."}, Left: ident("m"), Property: ident("x")}, "+", integer(1)),
+				str("s"),
+			},
+		},
+	}
+	return &Program{Statements: []Statement{
+		&LetStatement{Token: token.Token{Type: token.Let, Literal: "let"}, Name: ident("f"), Value: fn},
+		exprStmt(assign),
+	}}
+}
+
+func describe(node Node) string {
+	switch n := node.(type) {
+	case *Identifier:
+		return n.Value
+	case *IntegerLiteral, *FloatLiteral, *StringLiteral, *Boolean:
+		return n.String()
+	default:
+		return nodeType(node)
+	}
+}
+
+func TestInspect(t *testing.T) {
+	var visited []string
+	Inspect(sampleProgram(), func(node Node) bool {
+		if node != nil {
+			visited = append(visited, describe(node))
+		}
+		return true
+	})
+
+	expected := []string{
+		"Program",
+		"LetStatement", "f", "FunctionLiteral", "a", "b",
+		"BlockStatement", "ExpressionStatement", "IfExpression", "a",
+		"BlockStatement", "ReturnStatement", "PrefixExpression", "a",
+		"BlockStatement", "ExpressionStatement", "b",
+		"ExpressionStatement", "AssignExpression", "IndexExpression",
+		"CallExpression", "f", "1", "2.5",
+		"MemberExpression", "HashLiteral", "k", "true", "k",
+		"ArrayLiteral", "InfixExpression", "MemberExpression", "m", "x", "1", "s",
+	}
+	require.Equal(t, expected, visited)
+}
+
+func TestInspectPrune(t *testing.T) {
+	var identifiers []string
+	Inspect(sampleProgram(), func(node Node) bool {
+		if ident, ok := node.(*Identifier); ok {
+			identifiers = append(identifiers, ident.Value)
+		}
+		_, isFunction := node.(*FunctionLiteral)
+		return !isFunction
+	})
+
+	require.Equal(t, []string{"f", "f", "k", "m", "x"}, identifiers)
+}
+
+type depthVisitor struct {
+	depth    int
+	maxDepth *int
+	ends     *int
+}
+
+func (v depthVisitor) Visit(node Node) Visitor {
+	if node == nil {
+		*v.ends++
+		return nil
+	}
+	if v.depth > *v.maxDepth {
+		*v.maxDepth = v.depth
+	}
+	return depthVisitor{depth: v.depth + 1, maxDepth: v.maxDepth, ends: v.ends}
+}
+
+func TestWalk(t *testing.T) {
+	maxDepth, ends := 0, 0
+	program := &Program{Statements: []Statement{exprStmt(infix(integer(1), "+", integer(2)))}}
+	Walk(depthVisitor{maxDepth: &maxDepth, ends: &ends}, program)
+
+	require.Equal(t, 3, maxDepth)
+	require.Equal(t, 5, ends)
+}
+
+func TestModify(t *testing.T) {
+	one := func() Expression { return integer(1) }
+	two := func() Expression { return integer(2) }
+
+	turnOneIntoTwo := func(node Node) Node {
+		integer, ok := node.(*IntegerLiteral)
+		if !ok || integer.Value != 1 {
+			return node
+		}
+		integer.Value = 2
+		integer.Token.Literal = "2"
+		return integer
+	}
+
+	testCases := []struct {
+		input    Node
+		expected Node
+	}{
+		{one(), two()},
+		{&Program{Statements: []Statement{exprStmt(one())}}, &Program{Statements: []Statement{exprStmt(two())}}},
+		{infix(one(), "+", two()), infix(two(), "+", two())},
+		{infix(two(), "+", one()), infix(two(), "+", two())},
+		{
+			&PrefixExpression{Operator: "-", Right: one()},
+			&PrefixExpression{Operator: "-", Right: two()},
+		},
+		{
+			&IndexExpression{Left: one(), Index: one()},
+			&IndexExpression{Left: two(), Index: two()},
+		},
+		{
+			&IfExpression{Condition: one(), Consequence: block(exprStmt(one())), Alternative: block(exprStmt(one()))},
+			&IfExpression{Condition: two(), Consequence: block(exprStmt(two())), Alternative: block(exprStmt(two()))},
+		},
+		{
+			&ReturnStatement{Value: one()},
+			&ReturnStatement{Value: two()},
+		},
+		{
+			&LetStatement{Name: ident("x"), Value: one()},
+			&LetStatement{Name: ident("x"), Value: two()},
+		},
+		{
+			&FunctionLiteral{Parameters: []*Identifier{}, Body: block(exprStmt(one()))},
+			&FunctionLiteral{Parameters: []*Identifier{}, Body: block(exprStmt(two()))},
+		},
+		{
+			&CallExpression{Function: one(), Arguments: []Expression{one(), two()}},
+			&CallExpression{Function: two(), Arguments: []Expression{two(), two()}},
+		},
+		{
+			&ArrayLiteral{Elements: []Expression{one(), one()}},
+			&ArrayLiteral{Elements: []Expression{two(), two()}},
+		},
+		{
+			&HashLiteral{Pairs: []HashPair{{Key: one(), Value: one()}, {Key: one(), Value: one()}}},
+			&HashLiteral{Pairs: []HashPair{{Key: two(), Value: two()}, {Key: two(), Value: two()}}},
+		},
+		{
+			&MemberExpression{Left: one(), Property: ident("x")},
+			&MemberExpression{Left: two(), Property: ident("x")},
+		},
+		{
+			&AssignExpression{Target: &IndexExpression{Left: one(), Index: one()}, Value: one()},
+			&AssignExpression{Target: &IndexExpression{Left: two(), Index: two()}, Value: two()},
+		},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, Modify(tc.input, turnOneIntoTwo))
+	}
+}
+
+func TestModifyReplacesNodes(t *testing.T) {
+	program := sampleProgram()
+
+	renamed := Modify(program, func(node Node) Node {
+		if id, ok := node.(*Identifier); ok && id.Value == "a" {
+			return ident("arg")
+		}
+		return node
+	})
+
+	require.Same(t, program, renamed)
+	require.Equal(t,
+		"let f = fn(arg,b)ifarg return (-arg);else b;"+
+			"(f(1, 2.5)[{k:true}.k]) = [(m.x + 1), s]",
+		renamed.String())
+}
+
+func TestCopy(t *testing.T) {
+	program := sampleProgram()
+	program.Statements = append(program.Statements, exprStmt(&MacroLiteral{Token: token.Token{Type: token.Macro, Literal: "macro"}, Parameters: []*Identifier{ident("x")}, Body: block()}))
+	copied := Copy(program)
+
+	require.Equal(t, program, copied)
+	require.NotSame(t, program, copied)
+
+	Modify(copied, func(node Node) Node {
+		if id, ok := node.(*Identifier); ok {
+			id.Value = "changed"
+		}
+		if integer, ok := node.(*IntegerLiteral); ok {
+			return &StringLiteral{Token: integer.Token, Value: "changed"}
+		}
+		return node
+	})
+	require.Equal(t, sampleProgram().String()+"macro(x)", program.String())
+}
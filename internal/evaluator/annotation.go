@@ -0,0 +1,92 @@
+package evaluator
+
+import (
+	"github.com/vancanhuit/monkey/internal/ast"
+	"github.com/vancanhuit/monkey/internal/object"
+)
+
+// checkType returns an error naming what obj is if obj does not have the
+// annotated type t, and nil if it does or t is nil.
+func checkType(what string, obj object.Object, t ast.TypeExpression) *object.Error {
+	if t == nil || hasType(obj, t, map[typedObject]bool{}) {
+		return nil
+	}
+	return newError("%s must be %s, got %s", what, t, obj.Type())
+}
+
+// checkReturnType checks the value returned by fn against its annotation.
+// A function whose body produces no value returns null.
+func checkReturnType(fn *object.Function, result object.Object) *object.Error {
+	if result == nil {
+		result = Null
+	}
+	return checkType("return value", result, fn.ReturnType)
+}
+
+// typedObject records an array or hash being checked against a type, so
+// that one containing itself is not checked forever.
+type typedObject struct {
+	obj object.Object
+	typ ast.TypeExpression
+}
+
+// hasType reports whether obj has type t. Integers are accepted as floats,
+// as they are in arithmetic. Type variables such as a match any value;
+// the annotation does not require all of them to be the same.
+func hasType(obj object.Object, t ast.TypeExpression, seen map[typedObject]bool) bool {
+	switch t := t.(type) {
+	case *ast.NamedType:
+		switch t.Name {
+		case "int":
+			return obj.Type() == object.IntegerObj
+		case "float":
+			return isNumber(obj)
+		case "string":
+			return obj.Type() == object.StringObj
+		case "bool":
+			return obj.Type() == object.BooleanObj
+		case "null":
+			return obj.Type() == object.NullObj
+		case "time":
+			return obj.Type() == object.TimeObj
+		default:
+			return true
+		}
+	case *ast.ArrayType:
+		array, ok := obj.(*object.Array)
+		if !ok {
+			return false
+		}
+		if key := (typedObject{obj, t}); !seen[key] {
+			seen[key] = true
+			for _, e := range array.Elements {
+				if !hasType(e, t.Elem, seen) {
+					return false
+				}
+			}
+		}
+		return true
+	case *ast.HashType:
+		hash, ok := obj.(*object.Hash)
+		if !ok {
+			return false
+		}
+		if key := (typedObject{obj, t}); !seen[key] {
+			seen[key] = true
+			for _, pair := range hash.Pairs() {
+				if !hasType(pair.Key, t.Key, seen) || !hasType(pair.Value, t.Value, seen) {
+					return false
+				}
+			}
+		}
+		return true
+	case *ast.FunctionType:
+		switch fn := obj.(type) {
+		case *object.Function:
+			return len(fn.Parameters) == len(t.Parameters)
+		case *object.Builtin:
+			return true
+		}
+	}
+	return false
+}
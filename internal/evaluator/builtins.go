@@ -2,10 +2,45 @@ package evaluator
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/vancanhuit/monkey/internal/object"
 )
 
+// modules holds builtins that are accessed through a name, as in json.parse.
+var modules = map[string]*object.Module{}
+
+// Global returns the builtin function or module with the given name, which
+// is visible in every environment unless shadowed by a binding.
+func Global(name string) (object.Object, bool) {
+	if builtin, ok := builtins[name]; ok {
+		return builtin, true
+	}
+	if module, ok := modules[name]; ok {
+		return module, true
+	}
+	return nil, false
+}
+
+// GlobalNames returns the names of the builtin functions and modules in
+// sorted order.
+func GlobalNames() []string {
+	names := make([]string, 0, len(builtins)+len(modules))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	for name := range modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// push and rest leave their argument untouched and return a new array,
+// while append!, pop and delete modify the array or hash they are given.
+// The builtins returning a value their argument already holds are guarded
+// so that it is not charged again, and append! charges only the elements
+// it adds.
 var builtins = map[string]*object.Builtin{
 	"len": {
 		Fn: func(args ...object.Object) object.Object {
@@ -33,7 +68,7 @@ var builtins = map[string]*object.Builtin{
 		},
 	},
 	"first": {
-		Fn: func(args ...object.Object) object.Object {
+		Guarded: func(_ object.Guard, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return &object.Error{
 					Message: fmt.Sprintf("wrong number of arguments. got=%d, want=1", len(args)),
@@ -57,7 +92,7 @@ var builtins = map[string]*object.Builtin{
 		},
 	},
 	"last": {
-		Fn: func(args ...object.Object) object.Object {
+		Guarded: func(_ object.Guard, args ...object.Object) object.Object {
 			if len(args) != 1 {
 				return &object.Error{
 					Message: fmt.Sprintf(
@@ -125,6 +160,130 @@ var builtins = map[string]*object.Builtin{
 			return &object.Array{Elements: newElements}
 		},
 	},
+	"append!": {
+		Guarded: func(g object.Guard, args ...object.Object) object.Object {
+			if len(args) < 1 {
+				return &object.Error{
+					Message: fmt.Sprintf(
+						"wrong number of arguments. got=%d, want at least 1",
+						len(args))}
+			}
+			if args[0].Type() != object.ArrayObj {
+				return &object.Error{
+					Message: fmt.Sprintf(
+						"argument to `append!` must be ARRAY, got %s",
+						args[0].Type())}
+			}
+			if err := alloc(g, 8*len(args[1:])); err != nil {
+				return err
+			}
+			arr := args[0].(*object.Array)
+			arr.Elements = append(arr.Elements, args[1:]...)
+			return arr
+		},
+	},
+	"pop": {
+		Guarded: func(_ object.Guard, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return &object.Error{
+					Message: fmt.Sprintf(
+						"wrong number of arguments. got=%d, want=1",
+						len(args))}
+			}
+			if args[0].Type() != object.ArrayObj {
+				return &object.Error{
+					Message: fmt.Sprintf(
+						"argument to `pop` must be ARRAY, got %s",
+						args[0].Type())}
+			}
+			arr := args[0].(*object.Array)
+			length := len(arr.Elements)
+			if length == 0 {
+				return Null
+			}
+			last := arr.Elements[length-1]
+			arr.Elements[length-1] = nil
+			arr.Elements = arr.Elements[:length-1]
+			return last
+		},
+	},
+	"delete": {
+		Guarded: func(_ object.Guard, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return &object.Error{
+					Message: fmt.Sprintf(
+						"wrong number of arguments. got=%d, want=2",
+						len(args))}
+			}
+			if args[0].Type() != object.HashObj {
+				return &object.Error{
+					Message: fmt.Sprintf(
+						"argument to `delete` must be HASH, got %s",
+						args[0].Type())}
+			}
+			hash := args[0].(*object.Hash)
+			if _, ok := object.HashKeyOf(args[1]); !ok {
+				return &object.Error{
+					Message: fmt.Sprintf(
+						"unusable as hash key: %s", args[1].Type())}
+			}
+			pair, ok := hash.Delete(args[1])
+			if !ok {
+				return Null
+			}
+			return pair.Value
+		},
+	},
+	"keys": {
+		Guarded: func(g object.Guard, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return &object.Error{
+					Message: fmt.Sprintf(
+						"wrong number of arguments. got=%d, want=1",
+						len(args))}
+			}
+			if args[0].Type() != object.HashObj {
+				return &object.Error{
+					Message: fmt.Sprintf(
+						"argument to `keys` must be HASH, got %s",
+						args[0].Type())}
+			}
+			pairs := args[0].(*object.Hash).Pairs()
+			if err := alloc(g, 8*len(pairs)); err != nil {
+				return err
+			}
+			keys := make([]object.Object, len(pairs))
+			for i, pair := range pairs {
+				keys[i] = pair.Key
+			}
+			return &object.Array{Elements: keys}
+		},
+	},
+	"values": {
+		Guarded: func(g object.Guard, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return &object.Error{
+					Message: fmt.Sprintf(
+						"wrong number of arguments. got=%d, want=1",
+						len(args))}
+			}
+			if args[0].Type() != object.HashObj {
+				return &object.Error{
+					Message: fmt.Sprintf(
+						"argument to `values` must be HASH, got %s",
+						args[0].Type())}
+			}
+			pairs := args[0].(*object.Hash).Pairs()
+			if err := alloc(g, 8*len(pairs)); err != nil {
+				return err
+			}
+			values := make([]object.Object, len(pairs))
+			for i, pair := range pairs {
+				values[i] = pair.Value
+			}
+			return &object.Array{Elements: values}
+		},
+	},
 	"puts": {
 		Fn: func(args ...object.Object) object.Object {
 			for _, arg := range args {
@@ -0,0 +1,469 @@
+package evaluator
+
+import (
+	"sort"
+
+	"github.com/vancanhuit/monkey/internal/object"
+)
+
+func init() {
+	for name, builtin := range collectionBuiltins {
+		builtins[name] = builtin
+	}
+}
+
+// collectionBuiltins take callbacks, which may be Monkey functions or other
+// builtins, and return new arrays rather than modifying their arguments.
+// Unlike the functions of the library modules, such as strings.split, they
+// are bound globally like len and push.
+var collectionBuiltins = map[string]*object.Builtin{
+	"map": {
+		Guarded: func(g object.Guard, args ...object.Object) object.Object {
+			if err := checkCallbackArgs("map", args); err != nil {
+				return err
+			}
+			elements := args[0].(*object.Array).Elements
+			if err := alloc(g, 8*len(elements)); err != nil {
+				return err
+			}
+			result := make([]object.Object, len(elements))
+			for i, e := range elements {
+				mapped := applyFunction(g, args[1], []object.Object{e})
+				if isError(mapped) {
+					return mapped
+				}
+				result[i] = mapped
+			}
+			return &object.Array{Elements: result}
+		},
+	},
+	"filter": {
+		Guarded: func(g object.Guard, args ...object.Object) object.Object {
+			if err := checkCallbackArgs("filter", args); err != nil {
+				return err
+			}
+			result := []object.Object{}
+			for _, e := range args[0].(*object.Array).Elements {
+				keep := applyFunction(g, args[1], []object.Object{e})
+				if isError(keep) {
+					return keep
+				}
+				if isTruthy(keep) {
+					result = append(result, e)
+				}
+			}
+			if err := alloc(g, 8*len(result)); err != nil {
+				return err
+			}
+			return &object.Array{Elements: result}
+		},
+	},
+	"reduce": {
+		Guarded: func(g object.Guard, args ...object.Object) object.Object {
+			if len(args) != 2 && len(args) != 3 {
+				return newError("wrong number of arguments. got=%d, want=2 or 3", len(args))
+			}
+			if err := checkCallbackArgs("reduce", args[:2]); err != nil {
+				return err
+			}
+			elements := args[0].(*object.Array).Elements
+			var acc object.Object
+			if len(args) == 3 {
+				acc = args[2]
+			} else if len(elements) > 0 {
+				acc, elements = elements[0], elements[1:]
+			} else {
+				return Null
+			}
+			for _, e := range elements {
+				acc = applyFunction(g, args[1], []object.Object{acc, e})
+				if isError(acc) {
+					return acc
+				}
+			}
+			return acc
+		},
+	},
+	"each": {
+		Guarded: func(g object.Guard, args ...object.Object) object.Object {
+			if err := checkCallbackArgs("each", args); err != nil {
+				return err
+			}
+			for _, e := range args[0].(*object.Array).Elements {
+				if result := applyFunction(g, args[1], []object.Object{e}); isError(result) {
+					return result
+				}
+			}
+			return Null
+		},
+	},
+	"find": {
+		Guarded: func(g object.Guard, args ...object.Object) object.Object {
+			if err := checkCallbackArgs("find", args); err != nil {
+				return err
+			}
+			for _, e := range args[0].(*object.Array).Elements {
+				found := applyFunction(g, args[1], []object.Object{e})
+				if isError(found) {
+					return found
+				}
+				if isTruthy(found) {
+					return e
+				}
+			}
+			return Null
+		},
+	},
+	"any": {
+		Guarded: func(g object.Guard, args ...object.Object) object.Object {
+			if err := checkCallbackArgs("any", args); err != nil {
+				return err
+			}
+			for _, e := range args[0].(*object.Array).Elements {
+				result := applyFunction(g, args[1], []object.Object{e})
+				if isError(result) {
+					return result
+				}
+				if isTruthy(result) {
+					return True
+				}
+			}
+			return False
+		},
+	},
+	"all": {
+		Guarded: func(g object.Guard, args ...object.Object) object.Object {
+			if err := checkCallbackArgs("all", args); err != nil {
+				return err
+			}
+			for _, e := range args[0].(*object.Array).Elements {
+				result := applyFunction(g, args[1], []object.Object{e})
+				if isError(result) {
+					return result
+				}
+				if !isTruthy(result) {
+					return False
+				}
+			}
+			return True
+		},
+	},
+	"zip": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) == 0 {
+				return newError("wrong number of arguments. got=0, want at least 1")
+			}
+			length := -1
+			for i, arg := range args {
+				arr, ok := arg.(*object.Array)
+				if !ok {
+					return newError("argument %d to `zip` must be ARRAY, got %s", i+1, arg.Type())
+				}
+				if length < 0 || len(arr.Elements) < length {
+					length = len(arr.Elements)
+				}
+			}
+			result := make([]object.Object, length)
+			for i := range result {
+				tuple := make([]object.Object, len(args))
+				for j, arg := range args {
+					tuple[j] = arg.(*object.Array).Elements[i]
+				}
+				result[i] = &object.Array{Elements: tuple}
+			}
+			return &object.Array{Elements: result}
+		},
+	},
+	"range": {
+		Guarded: func(g object.Guard, args ...object.Object) object.Object {
+			if len(args) < 1 || len(args) > 3 {
+				return newError("wrong number of arguments. got=%d, want=1 to 3", len(args))
+			}
+			bounds := make([]int64, len(args))
+			for i, arg := range args {
+				integer, ok := arg.(*object.Integer)
+				if !ok {
+					return newError("argument %d to `range` must be INTEGER, got %s", i+1, arg.Type())
+				}
+				bounds[i] = integer.Value
+			}
+			start, end, step := int64(0), bounds[0], int64(1)
+			if len(bounds) > 1 {
+				start, end = bounds[0], bounds[1]
+			}
+			if len(bounds) > 2 {
+				step = bounds[2]
+			}
+			if step == 0 {
+				return newError("step passed to `range` must not be zero")
+			}
+			n := rangeLength(start, end, step)
+			if n > maxArrayLength {
+				return newError("`range` would produce %d elements, more than %d", n, maxArrayLength)
+			}
+			if err := alloc(g, 8*int(n)); err != nil {
+				return err
+			}
+			result := make([]object.Object, n)
+			for i := range result {
+				result[i] = &object.Integer{Value: start}
+				start += step
+			}
+			return &object.Array{Elements: result}
+		},
+	},
+	"sort": {
+		Guarded: func(g object.Guard, args ...object.Object) object.Object {
+			if len(args) == 2 {
+				if err := checkCallbackArgs("sort", args); err != nil {
+					return err
+				}
+			} else if err := checkArgs("sort", args, object.ArrayObj); err != nil {
+				return err
+			}
+			elements := args[0].(*object.Array).Elements
+			if err := alloc(g, 8*len(elements)); err != nil {
+				return err
+			}
+			result := make([]object.Object, len(elements))
+			copy(result, elements)
+
+			var sortErr object.Object
+			sort.SliceStable(result, func(i, j int) bool {
+				if sortErr != nil {
+					return false
+				}
+				less, err := lessThan(g, args[1:], result[i], result[j])
+				if err != nil {
+					sortErr = err
+				}
+				return less
+			})
+			if sortErr != nil {
+				return sortErr
+			}
+			return &object.Array{Elements: result}
+		},
+	},
+	"reverse": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) == 1 && args[0].Type() == object.StringObj {
+				runes := []rune(args[0].(*object.String).Value)
+				for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+					runes[i], runes[j] = runes[j], runes[i]
+				}
+				return &object.String{Value: string(runes)}
+			}
+			if err := checkArgs("reverse", args, object.ArrayObj); err != nil {
+				return err
+			}
+			elements := args[0].(*object.Array).Elements
+			result := make([]object.Object, len(elements))
+			for i, e := range elements {
+				result[len(elements)-1-i] = e
+			}
+			return &object.Array{Elements: result}
+		},
+	},
+	"flatten": {
+		Guarded: func(g object.Guard, args ...object.Object) object.Object {
+			depth := int64(-1)
+			if len(args) == 2 {
+				if err := checkArgs("flatten", args, object.ArrayObj, object.IntegerObj); err != nil {
+					return err
+				}
+				depth = args[1].(*object.Integer).Value
+			} else if err := checkArgs("flatten", args, object.ArrayObj); err != nil {
+				return err
+			}
+			result := []object.Object{}
+			if err := flatten(g, &result, args[0].(*object.Array), depth, map[*object.Array]bool{}); err != nil {
+				return err
+			}
+			return &object.Array{Elements: result}
+		},
+	},
+	"contains": {
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkSearchArgs("contains", args); err != nil {
+				return err
+			}
+			return nativeBoolToBooleanObject(indexOf(args[0].(*object.Array), args[1]) >= 0)
+		},
+	},
+	"indexOf": {
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkSearchArgs("indexOf", args); err != nil {
+				return err
+			}
+			return &object.Integer{Value: int64(indexOf(args[0].(*object.Array), args[1]))}
+		},
+	},
+	"uniq": {
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("uniq", args, object.ArrayObj); err != nil {
+				return err
+			}
+			seen := object.NewHash()
+			result := []object.Object{}
+			for _, e := range args[0].(*object.Array).Elements {
+				if _, ok := object.HashKeyOf(e); ok {
+					if _, dup := seen.Get(e); dup {
+						continue
+					}
+					seen.Set(e, True)
+				} else if indexOf(&object.Array{Elements: result}, e) >= 0 {
+					continue
+				}
+				result = append(result, e)
+			}
+			return &object.Array{Elements: result}
+		},
+	},
+	"groupBy": {
+		Guarded: func(g object.Guard, args ...object.Object) object.Object {
+			if err := checkCallbackArgs("groupBy", args); err != nil {
+				return err
+			}
+			groups := object.NewHash()
+			for _, e := range args[0].(*object.Array).Elements {
+				key := applyFunction(g, args[1], []object.Object{e})
+				if isError(key) {
+					return key
+				}
+				if _, ok := object.HashKeyOf(key); !ok {
+					return newError("unusable as hash key: %s", key.Type())
+				}
+				if pair, ok := groups.Get(key); ok {
+					group := pair.Value.(*object.Array)
+					group.Elements = append(group.Elements, e)
+				} else {
+					groups.Set(key, &object.Array{Elements: []object.Object{e}})
+				}
+			}
+			if err := alloc(g, objectSize(groups)+8*len(args[0].(*object.Array).Elements)); err != nil {
+				return err
+			}
+			return groups
+		},
+	},
+}
+
+// checkCallbackArgs validates the (array, function) arguments shared by
+// most collection builtins.
+func checkCallbackArgs(name string, args []object.Object) *object.Error {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+	if args[0].Type() != object.ArrayObj {
+		return newError("argument 1 to `%s` must be ARRAY, got %s", name, args[0].Type())
+	}
+	if !isCallable(args[1]) {
+		return newError("argument 2 to `%s` must be FUNCTION, got %s", name, args[1].Type())
+	}
+	return nil
+}
+
+// maxArrayLength caps the number of elements of the arrays builtins
+// generate, such as those of range, whose size is otherwise only bounded
+// by the host's memory.
+const maxArrayLength = 1 << 27
+
+// rangeLength returns the number of elements range(start, end, step) has.
+// It is computed without overflowing, however far apart start and end are.
+func rangeLength(start, end, step int64) uint64 {
+	switch {
+	case step > 0 && start < end:
+		return (uint64(end)-uint64(start)-1)/uint64(step) + 1
+	case step < 0 && start > end:
+		// -step is -2^63 for the smallest step, which is 2^63 as a uint64.
+		return (uint64(start)-uint64(end)-1)/uint64(-step) + 1
+	}
+	return 0
+}
+
+// indexOf returns the position of the first element of arr equal to obj,
+// or -1.
+func indexOf(arr *object.Array, obj object.Object) int {
+	for i, e := range arr.Elements {
+		if object.Equal(e, obj) {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkSearchArgs validates the arguments of a builtin looking for a value
+// of any type in an array.
+func checkSearchArgs(name string, args []object.Object) *object.Error {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2", len(args))
+	}
+	if args[0].Type() != object.ArrayObj {
+		return newError("argument 1 to `%s` must be ARRAY, got %s", name, args[0].Type())
+	}
+	return nil
+}
+
+func isCallable(obj object.Object) bool {
+	t := obj.Type()
+	return t == object.FunctionObj || t == object.BuiltinObj
+}
+
+// lessThan orders a before b using the optional comparator in cmp, which
+// returns either a boolean (a < b) or an integer (negative when a < b).
+// Without a comparator the natural ordering of object.Compare is used.
+func lessThan(g object.Guard, cmp []object.Object, a, b object.Object) (bool, object.Object) {
+	if len(cmp) == 0 {
+		c, err := object.Compare(a, b)
+		if err != nil {
+			return false, newError("%s", err)
+		}
+		return c < 0, nil
+	}
+
+	result := applyFunction(g, cmp[0], []object.Object{a, b})
+	switch r := result.(type) {
+	case *object.Error:
+		return false, r
+	case *object.Boolean:
+		return r.Value, nil
+	case *object.Integer:
+		return r.Value < 0, nil
+	default:
+		return false, newError("comparator passed to `sort` must return BOOLEAN or INTEGER, got %s", result.Type())
+	}
+}
+
+// flatten appends the elements of arr to result, descending depth levels
+// into nested arrays. g is charged for each array it descends into before
+// its elements are appended, so that an array nested many times over is
+// caught before the result grows.
+func flatten(
+	g object.Guard,
+	result *[]object.Object,
+	arr *object.Array,
+	depth int64,
+	visiting map[*object.Array]bool,
+) object.Object {
+	if visiting[arr] {
+		return newError("cannot flatten an array that contains itself")
+	}
+	visiting[arr] = true
+	defer delete(visiting, arr)
+	if err := alloc(g, 8*len(arr.Elements)); err != nil {
+		return err
+	}
+
+	for _, e := range arr.Elements {
+		nested, ok := e.(*object.Array)
+		if !ok || depth == 0 {
+			*result = append(*result, e)
+			continue
+		}
+		if err := flatten(g, result, nested, depth-1, visiting); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package evaluator
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectionBuiltins(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`map([1, 2, 3], fn(x) { x * 2 })`, "[2, 4, 6]"},
+		{`map([], fn(x) { x })`, "[]"},
+		{`map(["a", "b"], strings.upper)`, "[A, B]"},
+		{`map([1], fn(x) { x + true })`, errors.New("type mismatch: INTEGER + BOOLEAN")},
+		{`map([1], fn(x, y) { x })`, errors.New("wrong number of arguments. got=1, want=2")},
+		{`map(1, fn(x) { x })`, errors.New("argument 1 to `map` must be ARRAY, got INTEGER")},
+		{`map([1], 1)`, errors.New("argument 2 to `map` must be FUNCTION, got INTEGER")},
+		{`filter([1, 2, 3, 4], fn(x) { x > 2 })`, "[3, 4]"},
+		{`reduce([1, 2, 3], fn(acc, x) { acc + x }, 10)`, 16},
+		{`reduce([1, 2, 3], fn(acc, x) { acc * x })`, 6},
+		{`reduce([], fn(acc, x) { acc + x })`, nil},
+		{`reduce([], fn(acc, x) { acc + x }, 0)`, 0},
+		{`let out = []; each([1, 2], fn(x) { append!(out, x * 10) }); out`, "[10, 20]"},
+		{`each([1], fn(x) { -true })`, errors.New("unknown operator: -BOOLEAN")},
+		{`find([1, 2, 3], fn(x) { x > 1 })`, 2},
+		{`find([1, 2, 3], fn(x) { x > 5 })`, nil},
+		{`any([1, 2, 3], fn(x) { x > 2 })`, true},
+		{`any([], fn(x) { true })`, false},
+		{`all([1, 2, 3], fn(x) { x > 0 })`, true},
+		{`all([1, 2, 3], fn(x) { x > 1 })`, false},
+		{`zip([1, 2, 3], ["a", "b"])`, "[[1, a], [2, b]]"},
+		{`zip([1], 2)`, errors.New("argument 2 to `zip` must be ARRAY, got INTEGER")},
+		{`range(4)`, "[0, 1, 2, 3]"},
+		{`range(2, 5)`, "[2, 3, 4]"},
+		{`range(5, 0, -2)`, "[5, 3, 1]"},
+		{`range(0)`, "[]"},
+		{`range(-2)`, "[]"},
+		{`range(3, 0)`, "[]"},
+		{`range(9223372036854775805, 9223372036854775807, 4611686018427387904)`, "[9223372036854775805]"},
+		{`range(-9223372036854775807 - 1, 0, -9223372036854775807 - 1)`, "[]"},
+		{`range(0, -9223372036854775807 - 1, -9223372036854775807 - 1)`, "[0]"},
+		{`range(1, -9223372036854775807, -9223372036854775807)`, "[1, -9223372036854775806]"},
+		{
+			`range(0, 9223372036854775807, 2)`,
+			errors.New("`range` would produce 4611686018427387904 elements, more than 134217728"),
+		},
+		{`range(1, 2, 0)`, errors.New("step passed to `range` must not be zero")},
+		{`range("a")`, errors.New("argument 1 to `range` must be INTEGER, got STRING")},
+		{`sort([3, 1, 2])`, "[1, 2, 3]"},
+		{`sort(["b", "c", "a"])`, "[a, b, c]"},
+		{`sort([[2], [1, 2], [1]])`, "[[1], [1, 2], [2]]"},
+		{`sort([3, 1, 2], fn(a, b) { a > b })`, "[3, 2, 1]"},
+		{`sort(["bb", "a", "ccc"], fn(a, b) { len(a) - len(b) })`, "[a, bb, ccc]"},
+		{`let a = [2, 1]; sort(a); a`, "[2, 1]"},
+		{`sort([1, "a"])`, errors.New("cannot compare STRING and INTEGER")},
+		{`sort([1, 2], fn(a, b) { "x" })`, errors.New("comparator passed to `sort` must return BOOLEAN or INTEGER, got STRING")},
+		{`reverse([1, 2, 3])`, "[3, 2, 1]"},
+		{`reverse("héllo")`, "olléh"},
+		{`flatten([1, [2, [3, [4]]]])`, "[1, 2, 3, 4]"},
+		{`flatten([1, [2, [3, [4]]]], 1)`, "[1, 2, [3, [4]]]"},
+		{`let a = [1]; append!(a, a); flatten(a)`, errors.New("cannot flatten an array that contains itself")},
+		{`uniq([1, 2, 1, [3], [3], 2])`, "[1, 2, [3]]"},
+		{`let f = fn() {}; uniq([f, f, {}, {}])`, "[fn() {\n\n}, {}]"},
+		{`uniq([1, 1.0, 2.0, 2])`, "[1, 2.0]"},
+		{`contains([1], 1.0)`, "true"},
+		{`contains([1, [2]], [2])`, "true"},
+		{`contains([1, 2], 3)`, "false"},
+		{`contains("abc", "a")`, errors.New("argument 1 to `contains` must be ARRAY, got STRING")},
+		{`indexOf([1, "a", true], true)`, "2"},
+		{`indexOf([1], 2)`, "-1"},
+		{`groupBy([1, 2, 3, 4], fn(x) { x - (x / 2) * 2 })`, "{1: [1, 3], 0: [2, 4]}"},
+		{`groupBy([1], fn(x) { fn() {} })`, errors.New("unusable as hash key: FUNCTION")},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(tc.input)
+		if expected, ok := tc.expected.(string); ok {
+			require.Equal(t, expected, evaluated.Inspect(), tc.input)
+			continue
+		}
+		testExpectedObject(t, evaluated, tc.expected)
+	}
+}
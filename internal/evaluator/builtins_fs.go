@@ -0,0 +1,201 @@
+package evaluator
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/vancanhuit/monkey/internal/object"
+)
+
+// sandbox confines file system access to a set of root directories.
+type sandbox struct {
+	roots []string
+}
+
+func newSandbox(roots []string) *sandbox {
+	s := &sandbox{}
+	for _, root := range roots {
+		abs, err := filepath.Abs(root)
+		if err != nil {
+			continue
+		}
+		if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+			abs = resolved
+		}
+		s.roots = append(s.roots, abs)
+	}
+	return s
+}
+
+// resolve maps a path used by a script to a host path inside one of the
+// roots. Symbolic links are followed before checking, so neither ".."
+// segments nor links can be used to reach files outside the sandbox.
+func (s *sandbox) resolve(name string) (string, *object.Error) {
+	if len(s.roots) == 0 {
+		return "", newError("access denied: no directories are accessible")
+	}
+	path := name
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(s.roots[0], path)
+	}
+
+	resolved, err := evalExistingSymlinks(filepath.Clean(path))
+	if err != nil {
+		return "", newError("cannot resolve %s: %s", name, describeFSError(err))
+	}
+	for _, root := range s.roots {
+		rel, err := filepath.Rel(root, resolved)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return resolved, nil
+		}
+	}
+	return "", newError("access denied: %s is outside the allowed directories", name)
+}
+
+// evalExistingSymlinks resolves symbolic links in the longest prefix of
+// path that exists, leaving the remaining components as they are.
+func evalExistingSymlinks(path string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err == nil {
+		return resolved, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+	parent := filepath.Dir(path)
+	if parent == path {
+		return path, nil
+	}
+	resolvedParent, err := evalExistingSymlinks(parent)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(resolvedParent, filepath.Base(path)), nil
+}
+
+// describeFSError drops the host path from err so that messages returned to
+// scripts only mention the paths they used.
+func describeFSError(err error) string {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return pathErr.Err.Error()
+	}
+	return err.Error()
+}
+
+// readFile returns the contents of the file at path, which the script
+// called name, charging g for its size before reading it. Only as many
+// bytes as the file had when opened are read.
+func readFile(g object.Guard, name, path string) object.Object {
+	file, err := os.Open(path)
+	if err != nil {
+		return newError("cannot read %s: %s", name, describeFSError(err))
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return newError("cannot read %s: %s", name, describeFSError(err))
+	}
+	if err := alloc(g, int(info.Size())); err != nil {
+		return err
+	}
+	data, err := io.ReadAll(io.LimitReader(file, info.Size()))
+	if err != nil {
+		return newError("cannot read %s: %s", name, describeFSError(err))
+	}
+	return &object.String{Value: string(data)}
+}
+
+func newFSModule(s *sandbox) *object.Module {
+	return &object.Module{
+		Name: "fs",
+		Members: map[string]object.Object{
+			"read": &object.Builtin{
+				Guarded: func(g object.Guard, args ...object.Object) object.Object {
+					if err := checkArgs("fs.read", args, object.StringObj); err != nil {
+						return err
+					}
+					name := args[0].(*object.String).Value
+					path, err := s.resolve(name)
+					if err != nil {
+						return err
+					}
+					return readFile(g, name, path)
+				},
+			},
+			"write": &object.Builtin{
+				Fn: func(args ...object.Object) object.Object {
+					if err := checkArgs("fs.write", args, object.StringObj, object.StringObj); err != nil {
+						return err
+					}
+					name := args[0].(*object.String).Value
+					path, err := s.resolve(name)
+					if err != nil {
+						return err
+					}
+					data := []byte(args[1].(*object.String).Value)
+					if writeErr := os.WriteFile(path, data, 0o644); writeErr != nil {
+						return newError("cannot write %s: %s", name, describeFSError(writeErr))
+					}
+					return Null
+				},
+			},
+			"list": &object.Builtin{
+				Fn: func(args ...object.Object) object.Object {
+					if err := checkArgs("fs.list", args, object.StringObj); err != nil {
+						return err
+					}
+					name := args[0].(*object.String).Value
+					path, err := s.resolve(name)
+					if err != nil {
+						return err
+					}
+					entries, readErr := os.ReadDir(path)
+					if readErr != nil {
+						return newError("cannot list %s: %s", name, describeFSError(readErr))
+					}
+					names := make([]string, len(entries))
+					for i, entry := range entries {
+						names[i] = entry.Name()
+					}
+					sort.Strings(names)
+					return stringsToArray(names)
+				},
+			},
+			"exists": &object.Builtin{
+				Fn: func(args ...object.Object) object.Object {
+					if err := checkArgs("fs.exists", args, object.StringObj); err != nil {
+						return err
+					}
+					path, err := s.resolve(args[0].(*object.String).Value)
+					if err != nil {
+						return err
+					}
+					_, statErr := os.Stat(path)
+					return nativeBoolToBooleanObject(statErr == nil)
+				},
+			},
+			"mkdir": &object.Builtin{
+				Fn: func(args ...object.Object) object.Object {
+					if err := checkArgs("fs.mkdir", args, object.StringObj); err != nil {
+						return err
+					}
+					name := args[0].(*object.String).Value
+					path, err := s.resolve(name)
+					if err != nil {
+						return err
+					}
+					if mkdirErr := os.MkdirAll(path, 0o755); mkdirErr != nil {
+						return newError("cannot create %s: %s", name, describeFSError(mkdirErr))
+					}
+					return Null
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,81 @@
+package evaluator
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFSModule(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "hello.txt"), []byte("hello"), 0o644))
+	require.NoError(t, os.Mkdir(filepath.Join(root, "sub"), 0o755))
+	cfg := Config{FS: &FSConfig{Roots: []string{root}}}
+
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`fs.read("hello.txt")`, "hello"},
+		{`fs.read("sub/../hello.txt")`, "hello"},
+		{`fs.write("out.txt", "data"); fs.read("out.txt")`, "data"},
+		{`fs.exists("hello.txt")`, true},
+		{`fs.exists("missing.txt")`, false},
+		{`fs.mkdir("a/b"); fs.exists("a/b")`, true},
+		{`fs.list("sub")`, []string{}},
+		{`fs.read("missing.txt")`, errors.New("cannot read missing.txt: no such file or directory")},
+		{`fs.read(1)`, errors.New("argument to `fs.read` must be STRING, got INTEGER")},
+		{`fs.write("x")`, errors.New("wrong number of arguments. got=1, want=2")},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			testExpectedObject(t, testEvalWithConfig(tc.input, cfg), tc.expected)
+		})
+	}
+
+	listed := testEvalWithConfig(`fs.list(".")`, cfg)
+	testExpectedObject(t, listed, []string{"a", "hello.txt", "out.txt", "sub"})
+}
+
+func TestFSModuleSandbox(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root")
+	outside := filepath.Join(dir, "secret.txt")
+	require.NoError(t, os.Mkdir(root, 0o755))
+	require.NoError(t, os.WriteFile(outside, []byte("secret"), 0o644))
+	require.NoError(t, os.Symlink(dir, filepath.Join(root, "link")))
+	cfg := Config{FS: &FSConfig{Roots: []string{root}}}
+
+	testCases := []struct {
+		input string
+		path  string
+	}{
+		{`fs.read("../secret.txt")`, "../secret.txt"},
+		{`fs.read("` + outside + `")`, outside},
+		{`fs.read("link/secret.txt")`, "link/secret.txt"},
+		{`fs.write("link/new.txt", "x")`, "link/new.txt"},
+		{`fs.exists("../secret.txt")`, "../secret.txt"},
+		{`fs.mkdir("../escape")`, "../escape"},
+		{`fs.list("..")`, ".."},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			expected := errors.New("access denied: " + tc.path + " is outside the allowed directories")
+			testExpectedObject(t, testEvalWithConfig(tc.input, cfg), expected)
+		})
+	}
+
+	_, err := os.Stat(filepath.Join(dir, "new.txt"))
+	require.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(dir, "escape"))
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestFSModuleDisabledByDefault(t *testing.T) {
+	testExpectedObject(t, testEvalWithConfig(`fs.read("x")`, Config{}), errors.New("identifier not found: fs"))
+}
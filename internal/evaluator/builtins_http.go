@@ -0,0 +1,216 @@
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vancanhuit/monkey/internal/object"
+)
+
+// defaultHTTPTimeout bounds requests sent without a time limit.
+const defaultHTTPTimeout = 30 * time.Second
+
+// newHTTPModule returns the http module sending requests through transport.
+// Responses are hashes with "status", "headers" and "body" keys; header
+// names are lower-cased and repeated headers are joined with ", ". Bodies
+// longer than maxBody bytes are an error. If l is not nil, requests are
+// cancelled at its deadline and bodies are charged to it as they are read.
+func newHTTPModule(transport http.RoundTripper, maxBody int64, l *limiter) *object.Module {
+	client := &httpClient{
+		client:  &http.Client{Transport: transport, Timeout: defaultHTTPTimeout},
+		maxBody: maxBody,
+		limiter: l,
+	}
+	return &object.Module{
+		Name: "http",
+		Members: map[string]object.Object{
+			"get": &object.Builtin{
+				Fn: func(args ...object.Object) object.Object {
+					if len(args) != 1 && len(args) != 2 {
+						return newError("wrong number of arguments. got=%d, want=1 or 2", len(args))
+					}
+					if err := checkArgs("http.get", args[:1], object.StringObj); err != nil {
+						return err
+					}
+					var headers object.Object
+					if len(args) == 2 {
+						headers = args[1]
+					}
+					return client.send("GET", args[0].(*object.String).Value, "", headers)
+				},
+			},
+			"post": &object.Builtin{
+				Fn: func(args ...object.Object) object.Object {
+					if len(args) != 2 && len(args) != 3 {
+						return newError("wrong number of arguments. got=%d, want=2 or 3", len(args))
+					}
+					if err := checkArgs("http.post", args[:2], object.StringObj, object.StringObj); err != nil {
+						return err
+					}
+					var headers object.Object
+					if len(args) == 3 {
+						headers = args[2]
+					}
+					url := args[0].(*object.String).Value
+					body := args[1].(*object.String).Value
+					return client.send("POST", url, body, headers)
+				},
+			},
+			"request": &object.Builtin{
+				Fn: func(args ...object.Object) object.Object {
+					if err := checkArgs("http.request", args, object.HashObj); err != nil {
+						return err
+					}
+					options := args[0].(*object.Hash)
+					method, err := stringOption(options, "method", "GET")
+					if err != nil {
+						return err
+					}
+					url, err := stringOption(options, "url", "")
+					if err != nil {
+						return err
+					}
+					if url == "" {
+						return newError("missing url in request to `http.request`")
+					}
+					body, err := stringOption(options, "body", "")
+					if err != nil {
+						return err
+					}
+					var headers object.Object
+					if pair, ok := options.Get(&object.String{Value: "headers"}); ok {
+						headers = pair.Value
+					}
+					return client.send(strings.ToUpper(method), url, body, headers)
+				},
+			},
+		},
+	}
+}
+
+// stringOption returns the string stored under key in options, or def if
+// there is none.
+func stringOption(options *object.Hash, key, def string) (string, *object.Error) {
+	pair, ok := options.Get(&object.String{Value: key})
+	if !ok {
+		return def, nil
+	}
+	s, ok := pair.Value.(*object.String)
+	if !ok {
+		return "", newError("option %s must be STRING, got %s", key, pair.Value.Type())
+	}
+	return s.Value, nil
+}
+
+// httpClient sends the requests of the http module.
+type httpClient struct {
+	client  *http.Client
+	maxBody int64
+	limiter *limiter
+}
+
+func (c *httpClient) send(method, url, body string, headers object.Object) object.Object {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	ctx := context.Background()
+	if c.limiter != nil {
+		if remaining, ok := c.limiter.remaining(); ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, remaining)
+			defer cancel()
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return newError("invalid request: %s", err)
+	}
+
+	if headers != nil {
+		hash, ok := headers.(*object.Hash)
+		if !ok {
+			return newError("headers must be HASH, got %s", headers.Type())
+		}
+		for _, pair := range hash.Pairs() {
+			name, ok := pair.Key.(*object.String)
+			if !ok {
+				return newError("header name must be STRING, got %s", pair.Key.Type())
+			}
+			value, ok := pair.Value.(*object.String)
+			if !ok {
+				return newError("header %s must be STRING, got %s", name.Value, pair.Value.Type())
+			}
+			req.Header.Add(name.Value, value.Value)
+		}
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return c.failure(ctx, err)
+	}
+	defer resp.Body.Close()
+
+	var respBody io.Reader = io.LimitReader(resp.Body, c.maxBody+1)
+	if c.limiter != nil {
+		respBody = &chargedReader{r: respBody, g: c.limiter}
+	}
+	data, err := io.ReadAll(respBody)
+	if err != nil {
+		return c.failure(ctx, err)
+	}
+	if int64(len(data)) > c.maxBody {
+		return newError("response body exceeds %d bytes", c.maxBody)
+	}
+
+	names := make([]string, 0, len(resp.Header))
+	for name := range resp.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	respHeaders := object.NewHash()
+	for _, name := range names {
+		respHeaders.Set(
+			&object.String{Value: strings.ToLower(name)},
+			&object.String{Value: strings.Join(resp.Header[name], ", ")},
+		)
+	}
+
+	result := object.NewHash()
+	result.Set(&object.String{Value: "status"}, &object.Integer{Value: int64(resp.StatusCode)})
+	result.Set(&object.String{Value: "headers"}, respHeaders)
+	result.Set(&object.String{Value: "body"}, &object.String{Value: string(data)})
+	return result
+}
+
+// failure reports err, which failed the request sent with ctx. Requests
+// cancelled at the deadline report the time limit as scripts see it
+// elsewhere.
+func (c *httpClient) failure(ctx context.Context, err error) *object.Error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return newError("request failed: time limit of %s exceeded", c.limiter.limits.Timeout)
+	}
+	return newError("request failed: %s", err)
+}
+
+// chargedReader charges g for the bytes read from r, so that reading stops
+// with an error once the memory limit is reached.
+type chargedReader struct {
+	r io.Reader
+	g object.Guard
+}
+
+func (c *chargedReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	if c.g != nil && n > 0 {
+		if allocErr := c.g.Alloc(n); allocErr != nil {
+			return n, allocErr
+		}
+	}
+	return n, err
+}
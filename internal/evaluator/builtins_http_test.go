@@ -0,0 +1,128 @@
+package evaluator
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// roundTripperFunc lets a function serve requests in place of the network.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestHTTPModule(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Method", r.Method)
+		w.Header().Add("X-Multi", "a")
+		w.Header().Add("X-Multi", "b")
+		switch r.URL.Path {
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/echo":
+			_, _ = w.Write(body)
+		case "/auth":
+			_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+		}
+	}))
+	defer server.Close()
+	cfg := Config{HTTP: http.DefaultTransport}
+	url := `"` + server.URL
+
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`http.get(` + url + `/")["status"]`, 200},
+		{`http.get(` + url + `/missing")["status"]`, 404},
+		{`http.get(` + url + `/")["headers"]["x-method"]`, "GET"},
+		{`http.get(` + url + `/")["headers"]["x-multi"]`, "a, b"},
+		{`http.get(` + url + `/auth", {"Authorization": "token"})["body"]`, "token"},
+		{`http.post(` + url + `/echo", "hello")["body"]`, "hello"},
+		{`http.post(` + url + `/echo", "hi")["headers"]["x-method"]`, "POST"},
+		{`http.request({"method": "put", "url": ` + url + `/echo", "body": "x"})["headers"]["x-method"]`, "PUT"},
+		{`http.request({"url": ` + url + `/echo"})["headers"]["x-method"]`, "GET"},
+		{`http.request({"method": "GET"})`, errors.New("missing url in request to `http.request`")},
+		{`http.request({"url": 1})`, errors.New("option url must be STRING, got INTEGER")},
+		{`http.get(` + url + `/", {"X-Count": 1})`, errors.New("header X-Count must be STRING, got INTEGER")},
+		{`http.get(` + url + `/", [])`, errors.New("headers must be HASH, got ARRAY")},
+		{`http.get(1)`, errors.New("argument to `http.get` must be STRING, got INTEGER")},
+		{`http.post("x")`, errors.New("wrong number of arguments. got=1, want=2 or 3")},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			testExpectedObject(t, testEvalWithConfig(tc.input, cfg), tc.expected)
+		})
+	}
+}
+
+func TestHTTPModuleTransport(t *testing.T) {
+	var requests []string
+	cfg := Config{HTTP: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		requests = append(requests, req.Method+" "+req.URL.String())
+		if req.URL.Host == "down.example" {
+			return nil, errors.New("connection refused")
+		}
+		return &http.Response{
+			StatusCode: http.StatusCreated,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"id": 7}`)),
+		}, nil
+	})}
+
+	result := testEvalWithConfig(`
+		let resp = http.post("https://api.example/items", "{}");
+		[resp["status"], resp["headers"]["content-type"], json.parse(resp["body"])["id"]]
+	`, cfg)
+	require.Equal(t, `[201, application/json, 7]`, result.Inspect())
+	require.Equal(t, []string{"POST https://api.example/items"}, requests)
+
+	result = testEvalWithConfig(`http.get("https://down.example/")`, cfg)
+	testExpectedObject(t, result, errors.New(`request failed: Get "https://down.example/": connection refused`))
+}
+
+func TestHTTPModuleMaxResponseBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("a", 16)))
+	}))
+	defer server.Close()
+	input := `len(http.get("` + server.URL + `")["body"])`
+
+	testExpectedObject(t, testEvalWithConfig(input, Config{HTTP: http.DefaultTransport}), 16)
+	cfg := Config{HTTP: http.DefaultTransport, MaxResponseBody: 16}
+	testExpectedObject(t, testEvalWithConfig(input, cfg), 16)
+	cfg.MaxResponseBody = 15
+	testExpectedObject(t, testEvalWithConfig(input, cfg), errors.New("response body exceeds 15 bytes"))
+}
+
+func TestHTTPModuleStopsAtDeadline(t *testing.T) {
+	released := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-released:
+		}
+	}))
+	defer server.Close()
+	defer close(released)
+
+	cfg := Config{HTTP: http.DefaultTransport, Limits: Limits{Timeout: 50 * time.Millisecond}}
+	start := time.Now()
+	result := testEvalWithConfig(`http.get("`+server.URL+`")`, cfg)
+	testExpectedObject(t, result, errors.New("request failed: time limit of 50ms exceeded"))
+	require.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestHTTPModuleDisabledByDefault(t *testing.T) {
+	testExpectedObject(t, testEvalWithConfig(`http.get("http://localhost/")`, Config{}), errors.New("identifier not found: http"))
+}
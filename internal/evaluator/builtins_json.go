@@ -0,0 +1,83 @@
+package evaluator
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/vancanhuit/monkey/internal/object"
+)
+
+// maxJSONIndent is the largest number of spaces json.stringify indents by,
+// and the number of characters a string indent is truncated to, as in
+// JavaScript's JSON.stringify.
+const maxJSONIndent = 10
+
+func init() {
+	modules["json"] = &object.Module{
+		Name: "json",
+		Members: map[string]object.Object{
+			"parse": &object.Builtin{
+				Fn: func(args ...object.Object) object.Object {
+					if err := checkArgs("json.parse", args, object.StringObj); err != nil {
+						return err
+					}
+					obj, err := object.FromJSON([]byte(args[0].(*object.String).Value))
+					if err != nil {
+						return newError("invalid JSON: %s", err)
+					}
+					return obj
+				},
+			},
+			"stringify": &object.Builtin{
+				Guarded: func(g object.Guard, args ...object.Object) object.Object {
+					if len(args) != 1 && len(args) != 2 {
+						return newError("wrong number of arguments. got=%d, want=1 or 2", len(args))
+					}
+					indent := ""
+					if len(args) == 2 {
+						switch i := args[1].(type) {
+						case *object.Integer:
+							if i.Value < 0 || i.Value > maxJSONIndent {
+								return newError(
+									"indent passed to `json.stringify` must be between 0 and %d, got %d",
+									maxJSONIndent, i.Value)
+							}
+							indent = strings.Repeat(" ", int(i.Value))
+						case *object.String:
+							indent = i.Value
+							if runes := []rune(indent); len(runes) > maxJSONIndent {
+								indent = string(runes[:maxJSONIndent])
+							}
+						default:
+							return newError(
+								"argument 2 to `json.stringify` must be INTEGER or STRING, got %s",
+								args[1].Type())
+						}
+					}
+					// The compact encoding is charged before it is indented,
+					// which multiplies its size by at most the indent and the
+					// nesting depth.
+					data, err := object.ToJSON(args[0], "")
+					if err != nil {
+						return newError("%s", err)
+					}
+					if err := alloc(g, len(data)); err != nil {
+						return err
+					}
+					if indent != "" {
+						var indented bytes.Buffer
+						if err := json.Indent(&indented, data, "", indent); err != nil {
+							return newError("%s", err)
+						}
+						if err := alloc(g, indented.Len()-len(data)); err != nil {
+							return err
+						}
+						data = indented.Bytes()
+					}
+					return &object.String{Value: string(data)}
+				},
+			},
+		},
+	}
+}
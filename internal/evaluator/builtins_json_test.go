@@ -0,0 +1,43 @@
+package evaluator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestJSONModule(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`json.parse("[1, 2.5, \"a\", true, null]")[1]`, 2.5},
+		{`json.parse("{\"user\": {\"name\": \"monkey\"}}").user.name`, "monkey"},
+		{`json.parse("{\"a\": 1}").missing`, nil},
+		{`json.parse("true") == true`, true},
+		{`if (json.parse("false")) { 1 } else { 2 }`, 2},
+		{`json.parse("{")`, errors.New("invalid JSON: unexpected end of JSON input")},
+		{`json.parse(1)`, errors.New("argument to `json.parse` must be STRING, got INTEGER")},
+		{`json.stringify({"b": [1, 2.5], "a": false})`, `{"b":[1,2.5],"a":false}`},
+		{`json.stringify(json.parse("[null, {}]"))`, `[null,{}]`},
+		{`json.stringify({"a": 1}, 2)`, "{\n  \"a\": 1\n}"},
+		{`json.stringify({"a": 1}, "\t")`, "{\n\t\"a\": 1\n}"},
+		{`json.stringify([1], "ab-cd-ef-gh-ij-kl")`, "[\nab-cd-ef-g1\n]"},
+		{`json.stringify([1], "ééééééééééé")`, "[\néééééééééé1\n]"},
+		{`json.stringify({}, 0)`, "{}"},
+		{`json.stringify({}, -1)`, errors.New("indent passed to `json.stringify` must be between 0 and 10, got -1")},
+		{
+			`json.stringify({}, 9223372036854775807)`,
+			errors.New("indent passed to `json.stringify` must be between 0 and 10, got 9223372036854775807"),
+		},
+		{`json.stringify("x\ny")`, `"x\ny"`},
+		{`json.stringify(fn(x) { x })`, errors.New("cannot encode FUNCTION as JSON")},
+		{`let a = [1]; append!(a, a); json.stringify(a)`, errors.New("cannot encode self-referencing ARRAY as JSON")},
+		{`json.stringify(1, true)`, errors.New("argument 2 to `json.stringify` must be INTEGER or STRING, got BOOLEAN")},
+		{`json.missing`, errors.New("module json has no member missing")},
+		{`let x = 1; x.y`, errors.New("member access not supported: INTEGER")},
+	}
+
+	for _, tc := range testCases {
+		testExpectedObject(t, testEval(tc.input), tc.expected)
+	}
+}
@@ -0,0 +1,239 @@
+package evaluator
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/vancanhuit/monkey/internal/object"
+)
+
+// The math module bound globally only has the functions that need no
+// state. NewEnvironment binds one that adds seed, random and randInt, drawing
+// from a generator of the environment's own.
+func init() {
+	modules["math"] = &object.Module{Name: "math", Members: mathMembers()}
+}
+
+func mathMembers() map[string]object.Object {
+	return map[string]object.Object{
+		"pi":    &object.Float{Value: math.Pi},
+		"e":     &object.Float{Value: math.E},
+		"inf":   &object.Float{Value: math.Inf(1)},
+		"abs":   &object.Builtin{Fn: mathAbs},
+		"min":   &object.Builtin{Fn: mathExtreme("math.min", -1)},
+		"max":   &object.Builtin{Fn: mathExtreme("math.max", 1)},
+		"pow":   &object.Builtin{Fn: mathPow},
+		"floor": &object.Builtin{Fn: mathRound("math.floor", math.Floor)},
+		"ceil":  &object.Builtin{Fn: mathRound("math.ceil", math.Ceil)},
+		"round": &object.Builtin{Fn: mathRound("math.round", math.Round)},
+		"sqrt":  &object.Builtin{Fn: mathFloat("math.sqrt", math.Sqrt)},
+		"exp":   &object.Builtin{Fn: mathFloat("math.exp", math.Exp)},
+		"log":   &object.Builtin{Fn: mathFloat("math.log", math.Log)},
+		"log10": &object.Builtin{Fn: mathFloat("math.log10", math.Log10)},
+		"sin":   &object.Builtin{Fn: mathFloat("math.sin", math.Sin)},
+		"cos":   &object.Builtin{Fn: mathFloat("math.cos", math.Cos)},
+		"tan":   &object.Builtin{Fn: mathFloat("math.tan", math.Tan)},
+		"asin":  &object.Builtin{Fn: mathFloat("math.asin", math.Asin)},
+		"acos":  &object.Builtin{Fn: mathFloat("math.acos", math.Acos)},
+		"atan":  &object.Builtin{Fn: mathFloat("math.atan", math.Atan)},
+		"atan2": &object.Builtin{Fn: mathAtan2},
+	}
+}
+
+// newMathModule returns the math module with the functions that draw from
+// random. Once a script calls math.seed, the sequence of math.random and
+// math.randInt results is reproducible.
+func newMathModule(random *rand.Rand) *object.Module {
+	members := mathMembers()
+	members["seed"] = &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("math.seed", args, object.IntegerObj); err != nil {
+				return err
+			}
+			random.Seed(args[0].(*object.Integer).Value)
+			return Null
+		},
+	}
+	members["random"] = &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("math.random", args); err != nil {
+				return err
+			}
+			return &object.Float{Value: random.Float64()}
+		},
+	}
+	members["randInt"] = &object.Builtin{Fn: mathRandInt(random)}
+	return &object.Module{Name: "math", Members: members}
+}
+
+// checkNumbers validates that every argument to the builtin called name is
+// an integer or a float.
+func checkNumbers(name string, args []object.Object, want int) *object.Error {
+	if len(args) != want {
+		return newError("wrong number of arguments. got=%d, want=%d", len(args), want)
+	}
+	for i, arg := range args {
+		if isNumber(arg) {
+			continue
+		}
+		if want == 1 {
+			return newError("argument to `%s` must be INTEGER or FLOAT, got %s", name, arg.Type())
+		}
+		return newError("argument %d to `%s` must be INTEGER or FLOAT, got %s", i+1, name, arg.Type())
+	}
+	return nil
+}
+
+func mathAbs(args ...object.Object) object.Object {
+	if err := checkNumbers("math.abs", args, 1); err != nil {
+		return err
+	}
+	if i, ok := args[0].(*object.Integer); ok {
+		if i.Value == math.MinInt64 {
+			return newError("`math.abs` result out of integer range: abs(%d)", i.Value)
+		}
+		if i.Value < 0 {
+			return &object.Integer{Value: -i.Value}
+		}
+		return i
+	}
+	return &object.Float{Value: math.Abs(toFloat(args[0]))}
+}
+
+// mathExtreme returns min (sign -1) or max (sign 1) over its numeric
+// arguments, or over the elements of a single array argument.
+func mathExtreme(name string, sign int) object.BuiltinFunction {
+	return func(args ...object.Object) object.Object {
+		if len(args) == 1 {
+			if arr, ok := args[0].(*object.Array); ok {
+				args = arr.Elements
+			}
+		}
+		if len(args) == 0 {
+			return newError("`%s` needs at least one number", name)
+		}
+		if err := checkNumbers(name, args, len(args)); err != nil {
+			return err
+		}
+		result := args[0]
+		for _, arg := range args[1:] {
+			c, err := object.Compare(arg, result)
+			if err != nil {
+				return newError("%s", err)
+			}
+			if c*sign > 0 {
+				result = arg
+			}
+		}
+		return result
+	}
+}
+
+func mathPow(args ...object.Object) object.Object {
+	if err := checkNumbers("math.pow", args, 2); err != nil {
+		return err
+	}
+	base, baseOk := args[0].(*object.Integer)
+	exp, expOk := args[1].(*object.Integer)
+	if baseOk && expOk && exp.Value >= 0 {
+		result, ok := powInt(base.Value, exp.Value)
+		if !ok {
+			return newError("`math.pow` result out of integer range: pow(%d, %d)", base.Value, exp.Value)
+		}
+		return &object.Integer{Value: result}
+	}
+	return &object.Float{Value: math.Pow(toFloat(args[0]), toFloat(args[1]))}
+}
+
+// powInt returns b to the power e, which must not be negative, by
+// repeated squaring. It reports false if the result overflows.
+func powInt(b, e int64) (int64, bool) {
+	result := int64(1)
+	var ok bool
+	for ; e > 0; e >>= 1 {
+		if e&1 == 1 {
+			if result, ok = mulInt(result, b); !ok {
+				return 0, false
+			}
+		}
+		// The square is only a factor of the result if bits of e remain.
+		if e > 1 {
+			if b, ok = mulInt(b, b); !ok {
+				return 0, false
+			}
+		}
+	}
+	return result, true
+}
+
+// mulInt returns a*b, reporting false if it overflows.
+func mulInt(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	c := a * b
+	if c/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, false
+	}
+	return c, true
+}
+
+// mathRound applies a rounding function and returns an integer.
+func mathRound(name string, fn func(float64) float64) object.BuiltinFunction {
+	return func(args ...object.Object) object.Object {
+		if err := checkNumbers(name, args, 1); err != nil {
+			return err
+		}
+		if i, ok := args[0].(*object.Integer); ok {
+			return i
+		}
+		rounded := fn(toFloat(args[0]))
+		if math.IsNaN(rounded) || rounded < math.MinInt64 || rounded >= math.MaxInt64 {
+			return newError("`%s` result out of integer range: %s", name, args[0].Inspect())
+		}
+		return &object.Integer{Value: int64(rounded)}
+	}
+}
+
+// mathFloat wraps a float64 function; integer arguments are widened.
+func mathFloat(name string, fn func(float64) float64) object.BuiltinFunction {
+	return func(args ...object.Object) object.Object {
+		if err := checkNumbers(name, args, 1); err != nil {
+			return err
+		}
+		return &object.Float{Value: fn(toFloat(args[0]))}
+	}
+}
+
+func mathAtan2(args ...object.Object) object.Object {
+	if err := checkNumbers("math.atan2", args, 2); err != nil {
+		return err
+	}
+	return &object.Float{Value: math.Atan2(toFloat(args[0]), toFloat(args[1]))}
+}
+
+// mathRandInt returns randInt drawing from random, which returns an
+// integer in [0, n) for randInt(n) and in [lo, hi) for randInt(lo, hi).
+func mathRandInt(random *rand.Rand) object.BuiltinFunction {
+	return func(args ...object.Object) object.Object {
+		var lo, hi int64
+		switch len(args) {
+		case 1:
+			if err := checkArgs("math.randInt", args, object.IntegerObj); err != nil {
+				return err
+			}
+			hi = args[0].(*object.Integer).Value
+		case 2:
+			if err := checkArgs("math.randInt", args, object.IntegerObj, object.IntegerObj); err != nil {
+				return err
+			}
+			lo, hi = args[0].(*object.Integer).Value, args[1].(*object.Integer).Value
+		default:
+			return newError("wrong number of arguments. got=%d, want=1 or 2", len(args))
+		}
+		if hi <= lo || hi-lo < 0 {
+			return newError("invalid range passed to `math.randInt`: [%d, %d)", lo, hi)
+		}
+		return &object.Integer{Value: lo + random.Int63n(hi-lo)}
+	}
+}
@@ -0,0 +1,115 @@
+package evaluator
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vancanhuit/monkey/internal/lexer"
+	"github.com/vancanhuit/monkey/internal/object"
+	"github.com/vancanhuit/monkey/internal/parser"
+)
+
+func TestMathModule(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`math.pi`, math.Pi},
+		{`math.e`, math.E},
+		{`math.abs(-3)`, 3},
+		{`math.abs(-2.5)`, 2.5},
+		{`math.abs(-9223372036854775807)`, 9223372036854775807},
+		{
+			`math.abs(-9223372036854775807 - 1)`,
+			errors.New("`math.abs` result out of integer range: abs(-9223372036854775808)"),
+		},
+		{`math.abs("a")`, errors.New("argument to `math.abs` must be INTEGER or FLOAT, got STRING")},
+		{`math.min(3, 1.5, 2)`, 1.5},
+		{`math.max(3, 1.5, 2)`, 3},
+		{`math.max([4, 9, 2])`, 9},
+		{`math.min([])`, errors.New("`math.min` needs at least one number")},
+		{`math.max(1, "a")`, errors.New("argument 2 to `math.max` must be INTEGER or FLOAT, got STRING")},
+		{`math.pow(2, 10)`, 1024},
+		{`math.pow(2, -1)`, 0.5},
+		{`math.pow(2.0, 3)`, 8.0},
+		{`math.pow(-2, 63)`, math.MinInt64},
+		{`math.pow(1, 9223372036854775807)`, 1},
+		{`math.pow(-1, 9223372036854775807)`, -1},
+		{`math.pow(2, 63)`, errors.New("`math.pow` result out of integer range: pow(2, 63)")},
+		{`math.pow(10, 19)`, errors.New("`math.pow` result out of integer range: pow(10, 19)")},
+		{`math.pow(3, 40)`, errors.New("`math.pow` result out of integer range: pow(3, 40)")},
+		{`math.sqrt(16)`, 4.0},
+		{`math.floor(2.7)`, 2},
+		{`math.floor(-2.5)`, -3},
+		{`math.ceil(2.1)`, 3},
+		{`math.round(2.5)`, 3},
+		{`math.round(7)`, 7},
+		{`math.floor(math.inf)`, errors.New("`math.floor` result out of integer range: +Inf")},
+		{`math.sin(0)`, 0.0},
+		{`math.cos(math.pi)`, -1.0},
+		{`math.atan2(1, 1)`, math.Pi / 4},
+		{`math.log(math.e)`, 1.0},
+		{`math.log10(1000)`, 3.0},
+		{`math.exp(0)`, 1.0},
+		{`math.random()`, errors.New("module math has no member random")},
+	}
+
+	for _, tc := range testCases {
+		testExpectedObject(t, testEval(tc.input), tc.expected)
+	}
+}
+
+func TestMathRandomErrors(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`math.randInt(0)`, errors.New("invalid range passed to `math.randInt`: [0, 0)")},
+		{`math.randInt(5, 5)`, errors.New("invalid range passed to `math.randInt`: [5, 5)")},
+		{`math.seed("x")`, errors.New("argument to `math.seed` must be INTEGER, got STRING")},
+		{`math.random(1)`, errors.New("wrong number of arguments. got=1, want=0")},
+	}
+
+	for _, tc := range testCases {
+		testExpectedObject(t, testEvalWithConfig(tc.input, Config{}), tc.expected)
+	}
+}
+
+func TestMathRandomIsReproducible(t *testing.T) {
+	input := `
+	math.seed(42);
+	let rolls = map(range(20), fn(i) { math.randInt(1, 7) });
+	let fractions = map(range(5), fn(i) { math.random() });
+	[rolls, fractions]`
+
+	first := testEvalWithConfig(input, Config{}).Inspect()
+	second := testEvalWithConfig(input, Config{}).Inspect()
+	require.Equal(t, first, second)
+
+	rolls, ok := testEvalWithConfig(`math.seed(7); map(range(100), fn(i) { math.randInt(1, 7) })`, Config{}).(*object.Array)
+	require.True(t, ok)
+	for _, roll := range rolls.Elements {
+		value := roll.(*object.Integer).Value
+		require.True(t, value >= 1 && value < 7)
+	}
+}
+
+func TestMathSeedIsPerEnvironment(t *testing.T) {
+	draw := func(env *object.Environment, input string) string {
+		program := parser.New(lexer.New(input)).ParseProgram()
+		return Eval(program, env).Inspect()
+	}
+
+	seeded := NewEnvironment(Config{Random: rand.NewSource(1)})
+	other := NewEnvironment(Config{Random: rand.NewSource(1)})
+	draw(seeded, `math.seed(42)`)
+	require.NotEqual(t, draw(seeded, `math.random()`), draw(other, `math.random()`))
+
+	reseeded := NewEnvironment(Config{})
+	draw(reseeded, `math.seed(42)`)
+	draw(seeded, `math.seed(42)`)
+	require.Equal(t, draw(seeded, `math.random()`), draw(reseeded, `math.random()`))
+}
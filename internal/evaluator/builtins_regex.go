@@ -0,0 +1,196 @@
+package evaluator
+
+import (
+	"regexp"
+	"sync"
+
+	"github.com/vancanhuit/monkey/internal/object"
+)
+
+// maxCachedPatterns bounds the pattern cache; when it is full the cache is
+// emptied rather than tracking usage, which keeps lookups cheap.
+const maxCachedPatterns = 256
+
+var patterns = struct {
+	sync.Mutex
+	compiled map[string]*regexp.Regexp
+}{compiled: make(map[string]*regexp.Regexp)}
+
+// compilePattern returns the compiled form of pattern, reusing earlier
+// compilations so that regex builtins called in a loop stay fast.
+func compilePattern(pattern string) (*regexp.Regexp, *object.Error) {
+	patterns.Lock()
+	defer patterns.Unlock()
+
+	if re, ok := patterns.compiled[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, newError("invalid regular expression: %s", err)
+	}
+	if len(patterns.compiled) >= maxCachedPatterns {
+		patterns.compiled = make(map[string]*regexp.Regexp)
+	}
+	patterns.compiled[pattern] = re
+	return re, nil
+}
+
+// regexArgs validates the (pattern, string) arguments shared by the regex
+// builtins plus the types of any further arguments, and compiles pattern.
+func regexArgs(
+	name string,
+	args []object.Object,
+	extra ...object.ObjectType,
+) (*regexp.Regexp, string, *object.Error) {
+	types := append([]object.ObjectType{object.StringObj, object.StringObj}, extra...)
+	if err := checkArgs(name, args, types...); err != nil {
+		return nil, "", err
+	}
+	re, err := compilePattern(args[0].(*object.String).Value)
+	if err != nil {
+		return nil, "", err
+	}
+	return re, args[1].(*object.String).Value, nil
+}
+
+func init() {
+	modules["regex"] = &object.Module{
+		Name: "regex",
+		Members: map[string]object.Object{
+			"match": &object.Builtin{
+				Fn: func(args ...object.Object) object.Object {
+					re, s, err := regexArgs("regex.match", args)
+					if err != nil {
+						return err
+					}
+					return nativeBoolToBooleanObject(re.MatchString(s))
+				},
+			},
+			"find": &object.Builtin{
+				Fn: func(args ...object.Object) object.Object {
+					re, s, err := regexArgs("regex.find", args)
+					if err != nil {
+						return err
+					}
+					match := re.FindStringSubmatchIndex(s)
+					if match == nil {
+						return Null
+					}
+					return submatches(s, match)
+				},
+			},
+			"findAll": &object.Builtin{
+				Guarded: func(g object.Guard, args ...object.Object) object.Object {
+					re, s, err := regexArgs("regex.findAll", args)
+					if err != nil {
+						return err
+					}
+					matches := re.FindAllStringSubmatchIndex(s, -1)
+					size := 8 * len(matches)
+					for _, match := range matches {
+						size += 8*len(match)/2 + match[1] - match[0]
+					}
+					if err := alloc(g, size); err != nil {
+						return err
+					}
+					result := make([]object.Object, len(matches))
+					for i, match := range matches {
+						result[i] = submatches(s, match)
+					}
+					return &object.Array{Elements: result}
+				},
+			},
+			"replace": &object.Builtin{
+				Guarded: func(g object.Guard, args ...object.Object) object.Object {
+					if len(args) == 3 && isCallable(args[2]) {
+						re, s, err := regexArgs("regex.replace", args, args[2].Type())
+						if err != nil {
+							return err
+						}
+						return replaceMatches(g, re, s, func(match []int) (string, object.Object) {
+							replacement := applyFunction(g, args[2], []object.Object{submatches(s, match)})
+							if isError(replacement) {
+								return "", replacement
+							}
+							str, ok := replacement.(*object.String)
+							if !ok {
+								return "", newError(
+									"callback passed to `regex.replace` must return STRING, got %s", replacement.Type())
+							}
+							return str.Value, nil
+						})
+					}
+					re, s, err := regexArgs("regex.replace", args, object.StringObj)
+					if err != nil {
+						return err
+					}
+					template := args[2].(*object.String).Value
+					return replaceMatches(g, re, s, func(match []int) (string, object.Object) {
+						return string(re.ExpandString(nil, template, s, match)), nil
+					})
+				},
+			},
+			"split": &object.Builtin{
+				Guarded: func(g object.Guard, args ...object.Object) object.Object {
+					re, s, err := regexArgs("regex.split", args)
+					if err != nil {
+						return err
+					}
+					parts := re.Split(s, -1)
+					if err := alloc(g, 8*len(parts)+len(s)); err != nil {
+						return err
+					}
+					return stringsToArray(parts)
+				},
+			},
+		},
+	}
+}
+
+// submatches converts the index pairs of a match into an array holding the
+// whole match followed by each capture group, with null for groups that
+// did not participate in the match.
+func submatches(s string, match []int) *object.Array {
+	groups := make([]object.Object, len(match)/2)
+	for i := range groups {
+		start, end := match[2*i], match[2*i+1]
+		if start < 0 {
+			groups[i] = Null
+			continue
+		}
+		groups[i] = &object.String{Value: s[start:end]}
+	}
+	return &object.Array{Elements: groups}
+}
+
+// replaceMatches replaces every match of re in s with the string
+// replacement returns for it, charging g for the result as it grows. The
+// template form of regex.replace expands the template for each match, as
+// regexp.ReplaceAllString does.
+func replaceMatches(
+	g object.Guard,
+	re *regexp.Regexp,
+	s string,
+	replacement func(match []int) (string, object.Object),
+) object.Object {
+	var out []byte
+	last := 0
+	for _, match := range re.FindAllStringSubmatchIndex(s, -1) {
+		str, err := replacement(match)
+		if err != nil {
+			return err
+		}
+		if err := alloc(g, match[0]-last+len(str)); err != nil {
+			return err
+		}
+		out = append(out, s[last:match[0]]...)
+		out = append(out, str...)
+		last = match[1]
+	}
+	if err := alloc(g, len(s)-last); err != nil {
+		return err
+	}
+	out = append(out, s[last:]...)
+	return &object.String{Value: string(out)}
+}
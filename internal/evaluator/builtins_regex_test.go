@@ -0,0 +1,53 @@
+package evaluator
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// inspected is the expected Inspect output of a result, for results
+// testExpectedObject cannot compare, such as nested arrays.
+type inspected string
+
+func TestRegexModule(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`regex.match("^[a-z]+@[a-z]+\\.com$", "monkey@zoo.com")`, true},
+		{`regex.match("^\\d+$", "12a")`, false},
+		{`regex.match("(", "x")`, errors.New("invalid regular expression: error parsing regexp: missing closing ): `(`")},
+		{`regex.match(1, "x")`, errors.New("argument 1 to `regex.match` must be STRING, got INTEGER")},
+		{`regex.find("(\\w+)@(\\w+)", "mail: monkey@zoo now")`, []string{"monkey@zoo", "monkey", "zoo"}},
+		{`regex.find("\\d", "abc")`, nil},
+		{`regex.find("a(x)?", "a")[1]`, nil},
+		{`regex.findAll("(\\d)(\\w)", "1a 2b 3c")`, inspected("[[1a, 1, a], [2b, 2, b], [3c, 3, c]]")},
+		{`regex.findAll("\\d", "none")`, inspected("[]")},
+		{`regex.replace("(\\w+)@(\\w+)", "monkey@zoo", "$2 at $1")`, "zoo at monkey"},
+		{`regex.replace("(?P<n>\\d+)", "a1b22", "<${n}>")`, "a<1>b<22>"},
+		{`regex.replace("\\d+", "a1b22", fn(m) { strings.format("%d", len(m[0])) })`, "a1b2"},
+		{`regex.replace("\\d", "a1", fn(m) { 1 })`, errors.New("callback passed to `regex.replace` must return STRING, got INTEGER")},
+		{`regex.replace("\\d", "a1", fn(m) { -true })`, errors.New("unknown operator: -BOOLEAN")},
+		{`regex.replace("\\d", "a1", 1)`, errors.New("argument 3 to `regex.replace` must be STRING, got INTEGER")},
+		{`regex.split("\\s*,\\s*", "a , b,c")`, []string{"a", "b", "c"}},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(tc.input)
+		if expected, ok := tc.expected.(inspected); ok {
+			require.Equal(t, string(expected), evaluated.Inspect(), tc.input)
+			continue
+		}
+		testExpectedObject(t, evaluated, tc.expected)
+	}
+}
+
+func TestRegexPatternCache(t *testing.T) {
+	first, err := compilePattern("a+b")
+	require.Nil(t, err)
+	second, err := compilePattern("a+b")
+	require.Nil(t, err)
+	require.Same(t, first, second)
+}
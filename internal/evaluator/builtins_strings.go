@@ -0,0 +1,292 @@
+package evaluator
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/vancanhuit/monkey/internal/object"
+)
+
+// maxStringLength caps the length in bytes of the strings builtins build
+// by repetition, whose size is otherwise only bounded by the host's memory.
+const maxStringLength = 1 << 30
+
+// The strings module treats strings as sequences of bytes, as len does:
+// indexOf returns a byte offset. chars splits a string into its runes.
+func init() {
+	modules["strings"] = &object.Module{
+		Name: "strings",
+		Members: map[string]object.Object{
+			"split": &object.Builtin{
+				Guarded: func(g object.Guard, args ...object.Object) object.Object {
+					if err := checkArgs("strings.split", args, object.StringObj, object.StringObj); err != nil {
+						return err
+					}
+					s, sep := args[0].(*object.String).Value, args[1].(*object.String).Value
+					if err := alloc(g, 8*(strings.Count(s, sep)+1)+len(s)); err != nil {
+						return err
+					}
+					return stringsToArray(strings.Split(s, sep))
+				},
+			},
+			"join": &object.Builtin{
+				Guarded: func(g object.Guard, args ...object.Object) object.Object {
+					if err := checkArgs("strings.join", args, object.ArrayObj, object.StringObj); err != nil {
+						return err
+					}
+					elements := args[0].(*object.Array).Elements
+					sep := args[1].(*object.String).Value
+					parts := make([]string, len(elements))
+					size := 0
+					for i, e := range elements {
+						str, ok := e.(*object.String)
+						if !ok {
+							return newError(
+								"element %d passed to `strings.join` must be STRING, got %s", i, e.Type())
+						}
+						parts[i] = str.Value
+						size += len(str.Value)
+					}
+					if len(parts) > 1 {
+						size += len(sep) * (len(parts) - 1)
+					}
+					if err := alloc(g, size); err != nil {
+						return err
+					}
+					return &object.String{Value: strings.Join(parts, sep)}
+				},
+			},
+			"trim": &object.Builtin{
+				Fn: func(args ...object.Object) object.Object {
+					if len(args) == 2 {
+						if err := checkArgs("strings.trim", args, object.StringObj, object.StringObj); err != nil {
+							return err
+						}
+						return &object.String{Value: strings.Trim(
+							args[0].(*object.String).Value, args[1].(*object.String).Value)}
+					}
+					if err := checkArgs("strings.trim", args, object.StringObj); err != nil {
+						return err
+					}
+					return &object.String{Value: strings.TrimSpace(args[0].(*object.String).Value)}
+				},
+			},
+			"upper": &object.Builtin{
+				Fn: func(args ...object.Object) object.Object {
+					if err := checkArgs("strings.upper", args, object.StringObj); err != nil {
+						return err
+					}
+					return &object.String{Value: strings.ToUpper(args[0].(*object.String).Value)}
+				},
+			},
+			"lower": &object.Builtin{
+				Fn: func(args ...object.Object) object.Object {
+					if err := checkArgs("strings.lower", args, object.StringObj); err != nil {
+						return err
+					}
+					return &object.String{Value: strings.ToLower(args[0].(*object.String).Value)}
+				},
+			},
+			"replace": &object.Builtin{
+				Guarded: func(g object.Guard, args ...object.Object) object.Object {
+					err := checkArgs("strings.replace", args,
+						object.StringObj, object.StringObj, object.StringObj)
+					if err != nil {
+						return err
+					}
+					s := args[0].(*object.String).Value
+					old, replacement := args[1].(*object.String).Value, args[2].(*object.String).Value
+					n, growth := int64(strings.Count(s, old)), int64(len(replacement)-len(old))
+					if growth > 0 && n > (maxStringLength-int64(len(s)))/growth {
+						return newError("result of `strings.replace` would exceed %d bytes", maxStringLength)
+					}
+					if err := alloc(g, len(s)+int(n*growth)); err != nil {
+						return err
+					}
+					return &object.String{Value: strings.ReplaceAll(s, old, replacement)}
+				},
+			},
+			"contains": &object.Builtin{
+				Fn: func(args ...object.Object) object.Object {
+					if err := checkArgs("strings.contains", args, object.StringObj, object.StringObj); err != nil {
+						return err
+					}
+					return nativeBoolToBooleanObject(strings.Contains(
+						args[0].(*object.String).Value, args[1].(*object.String).Value))
+				},
+			},
+			"startsWith": &object.Builtin{
+				Fn: func(args ...object.Object) object.Object {
+					if err := checkArgs("strings.startsWith", args, object.StringObj, object.StringObj); err != nil {
+						return err
+					}
+					return nativeBoolToBooleanObject(strings.HasPrefix(
+						args[0].(*object.String).Value, args[1].(*object.String).Value))
+				},
+			},
+			"endsWith": &object.Builtin{
+				Fn: func(args ...object.Object) object.Object {
+					if err := checkArgs("strings.endsWith", args, object.StringObj, object.StringObj); err != nil {
+						return err
+					}
+					return nativeBoolToBooleanObject(strings.HasSuffix(
+						args[0].(*object.String).Value, args[1].(*object.String).Value))
+				},
+			},
+			"indexOf": &object.Builtin{
+				Fn: func(args ...object.Object) object.Object {
+					if err := checkArgs("strings.indexOf", args, object.StringObj, object.StringObj); err != nil {
+						return err
+					}
+					return &object.Integer{Value: int64(strings.Index(
+						args[0].(*object.String).Value, args[1].(*object.String).Value))}
+				},
+			},
+			"repeat": &object.Builtin{
+				Guarded: func(g object.Guard, args ...object.Object) object.Object {
+					if err := checkArgs("strings.repeat", args, object.StringObj, object.IntegerObj); err != nil {
+						return err
+					}
+					s := args[0].(*object.String).Value
+					count := args[1].(*object.Integer).Value
+					if count < 0 {
+						return newError("negative count passed to `strings.repeat`: %d", count)
+					}
+					if count > 0 && int64(len(s)) > maxStringLength/count {
+						return newError("result of `strings.repeat` would exceed %d bytes", maxStringLength)
+					}
+					if err := alloc(g, len(s)*int(count)); err != nil {
+						return err
+					}
+					return &object.String{Value: strings.Repeat(s, int(count))}
+				},
+			},
+			"format": &object.Builtin{
+				Guarded: func(g object.Guard, args ...object.Object) object.Object {
+					if len(args) < 1 {
+						return newError("wrong number of arguments. got=0, want at least 1")
+					}
+					if args[0].Type() != object.StringObj {
+						return newError("argument to `strings.format` must be STRING, got %s", args[0].Type())
+					}
+					return format(g, args[0].(*object.String).Value, args[1:])
+				},
+			},
+			"chars": &object.Builtin{
+				Fn: func(args ...object.Object) object.Object {
+					if err := checkArgs("strings.chars", args, object.StringObj); err != nil {
+						return err
+					}
+					s := args[0].(*object.String).Value
+					chars := make([]string, 0, utf8.RuneCountInString(s))
+					for _, r := range s {
+						chars = append(chars, string(r))
+					}
+					return stringsToArray(chars)
+				},
+			},
+		},
+	}
+}
+
+func newError(format string, a ...interface{}) *object.Error {
+	return &object.Error{Message: fmt.Sprintf(format, a...)}
+}
+
+// checkArgs validates the number and types of the arguments passed to the
+// builtin called name, using the same messages as the builtins table.
+func checkArgs(name string, args []object.Object, types ...object.ObjectType) *object.Error {
+	if len(args) != len(types) {
+		return newError("wrong number of arguments. got=%d, want=%d", len(args), len(types))
+	}
+	for i, t := range types {
+		if args[i].Type() == t {
+			continue
+		}
+		if len(types) == 1 {
+			return newError("argument to `%s` must be %s, got %s", name, t, args[i].Type())
+		}
+		return newError("argument %d to `%s` must be %s, got %s", i+1, name, t, args[i].Type())
+	}
+	return nil
+}
+
+func stringsToArray(strs []string) *object.Array {
+	elements := make([]object.Object, len(strs))
+	for i, s := range strs {
+		elements[i] = &object.String{Value: s}
+	}
+	return &object.Array{Elements: elements}
+}
+
+// format implements printf-style formatting: %d takes an integer, %f a
+// number, %s a string, %v any value and %% is a literal percent sign. It
+// charges g for the template and for each string it substitutes before
+// writing them.
+func format(g object.Guard, template string, args []object.Object) object.Object {
+	if err := alloc(g, len(template)); err != nil {
+		return err
+	}
+	var out bytes.Buffer
+
+	next := 0
+	for i := 0; i < len(template); i++ {
+		ch := template[i]
+		if ch != '%' {
+			out.WriteByte(ch)
+			continue
+		}
+		if i+1 >= len(template) {
+			return newError("incomplete verb at end of format string")
+		}
+		i++
+		verb := template[i]
+		if verb == '%' {
+			out.WriteByte('%')
+			continue
+		}
+		if next >= len(args) {
+			return newError("missing argument for %%%c in format string", verb)
+		}
+		arg := args[next]
+		next++
+
+		switch verb {
+		case 'd':
+			integer, ok := arg.(*object.Integer)
+			if !ok {
+				return newError("%%d expects INTEGER, got %s", arg.Type())
+			}
+			fmt.Fprintf(&out, "%d", integer.Value)
+		case 'f':
+			if !isNumber(arg) {
+				return newError("%%f expects a number, got %s", arg.Type())
+			}
+			fmt.Fprintf(&out, "%f", toFloat(arg))
+		case 's':
+			str, ok := arg.(*object.String)
+			if !ok {
+				return newError("%%s expects STRING, got %s", arg.Type())
+			}
+			if err := alloc(g, len(str.Value)); err != nil {
+				return err
+			}
+			out.WriteString(str.Value)
+		case 'v':
+			inspected := arg.Inspect()
+			if err := alloc(g, len(inspected)); err != nil {
+				return err
+			}
+			out.WriteString(inspected)
+		default:
+			return newError("unknown verb %%%c in format string", verb)
+		}
+	}
+	if next < len(args) {
+		return newError("too many arguments for format string: got %d, used %d", len(args), next)
+	}
+
+	return &object.String{Value: out.String()}
+}
@@ -0,0 +1,57 @@
+package evaluator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestStringBuiltins(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`strings.split("a,b,c", ",")`, []string{"a", "b", "c"}},
+		{`strings.split("abc", "")`, []string{"a", "b", "c"}},
+		{`strings.split("a", 1)`, errors.New("argument 2 to `strings.split` must be STRING, got INTEGER")},
+		{`strings.split("a")`, errors.New("wrong number of arguments. got=1, want=2")},
+		{`strings.join(["a", "b"], "-")`, "a-b"},
+		{`strings.join([], "-")`, ""},
+		{`strings.join(["a", 1], "-")`, errors.New("element 1 passed to `strings.join` must be STRING, got INTEGER")},
+		{`strings.trim("  a b  ")`, "a b"},
+		{`strings.trim("xxaxx", "x")`, "a"},
+		{`strings.trim(1)`, errors.New("argument to `strings.trim` must be STRING, got INTEGER")},
+		{`strings.upper("abc")`, "ABC"},
+		{`strings.lower("ÀBC")`, "àbc"},
+		{`strings.replace("aaa", "a", "b")`, "bbb"},
+		{`strings.contains("monkey", "key")`, true},
+		{`strings.contains("monkey", "x")`, false},
+		{`strings.startsWith("monkey", "mon")`, true},
+		{`strings.endsWith("monkey", "mon")`, false},
+		{`strings.indexOf("héllo", "l")`, 3},
+		{`strings.indexOf("hello", "x")`, -1},
+		{`strings.contains([1], 1)`, errors.New("argument 1 to `strings.contains` must be STRING, got ARRAY")},
+		{`strings.repeat("ab", 3)`, "ababab"},
+		{`strings.repeat("ab", -1)`, errors.New("negative count passed to `strings.repeat`: -1")},
+		{`strings.repeat("", 9223372036854775807)`, ""},
+		{
+			`strings.repeat("ab", 9223372036854775807)`,
+			errors.New("result of `strings.repeat` would exceed 1073741824 bytes"),
+		},
+		{`strings.format("%s is %d", "x", 5)`, "x is 5"},
+		{`strings.format("%v and %v", [1, 2], {"a": true})`, "[1, 2] and {a: true}"},
+		{`strings.format("100%%")`, "100%"},
+		{`strings.format("%f", 1)`, "1.000000"},
+		{`strings.format("%d", "x")`, errors.New("%d expects INTEGER, got STRING")},
+		{`strings.format("%s %s", "x")`, errors.New("missing argument for %s in format string")},
+		{`strings.format("%s", "x", "y")`, errors.New("too many arguments for format string: got 2, used 1")},
+		{`strings.format("%q", "x")`, errors.New("unknown verb %q in format string")},
+		{`strings.format("%")`, errors.New("incomplete verb at end of format string")},
+		{`strings.format(1)`, errors.New("argument to `strings.format` must be STRING, got INTEGER")},
+		{`strings.chars("héllo")`, []string{"h", "é", "l", "l", "o"}},
+		{`strings.chars("")`, []string{}},
+	}
+
+	for _, tc := range testCases {
+		testExpectedObject(t, testEval(tc.input), tc.expected)
+	}
+}
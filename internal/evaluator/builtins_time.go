@@ -0,0 +1,219 @@
+package evaluator
+
+import (
+	"time"
+
+	"github.com/vancanhuit/monkey/internal/object"
+)
+
+// timeLayouts names common layouts accepted by time.format and time.parse
+// in place of a Go reference layout such as "2006-01-02 15:04".
+var timeLayouts = map[string]string{
+	"rfc3339":  time.RFC3339,
+	"rfc1123":  time.RFC1123,
+	"kitchen":  time.Kitchen,
+	"date":     "2006-01-02",
+	"time":     "15:04:05",
+	"datetime": "2006-01-02 15:04:05",
+}
+
+// newTimeModule returns the time module bound to clock. Instants are TIME
+// objects that carry a location; durations are integers in nanoseconds, with
+// time.second and friends provided as units.
+func newTimeModule(clock Clock) *object.Module {
+	return &object.Module{
+		Name: "time",
+		Members: map[string]object.Object{
+			"nanosecond":  &object.Integer{Value: int64(time.Nanosecond)},
+			"microsecond": &object.Integer{Value: int64(time.Microsecond)},
+			"millisecond": &object.Integer{Value: int64(time.Millisecond)},
+			"second":      &object.Integer{Value: int64(time.Second)},
+			"minute":      &object.Integer{Value: int64(time.Minute)},
+			"hour":        &object.Integer{Value: int64(time.Hour)},
+			"now": &object.Builtin{
+				Fn: func(args ...object.Object) object.Object {
+					if err := checkArgs("time.now", args); err != nil {
+						return err
+					}
+					return &object.Time{Value: clock.Now()}
+				},
+			},
+			"since": &object.Builtin{
+				Fn: func(args ...object.Object) object.Object {
+					if err := checkArgs("time.since", args, object.TimeObj); err != nil {
+						return err
+					}
+					start := args[0].(*object.Time).Value
+					return &object.Integer{Value: int64(clock.Now().Sub(start))}
+				},
+			},
+			"sleep": &object.Builtin{
+				Fn: func(args ...object.Object) object.Object {
+					if err := checkArgs("time.sleep", args, object.IntegerObj); err != nil {
+						return err
+					}
+					clock.Sleep(time.Duration(args[0].(*object.Integer).Value))
+					return Null
+				},
+			},
+			"add": &object.Builtin{
+				Fn: func(args ...object.Object) object.Object {
+					if err := checkArgs("time.add", args, object.TimeObj, object.IntegerObj); err != nil {
+						return err
+					}
+					t := args[0].(*object.Time).Value
+					d := time.Duration(args[1].(*object.Integer).Value)
+					return &object.Time{Value: t.Add(d)}
+				},
+			},
+			"addDate": &object.Builtin{
+				Fn: func(args ...object.Object) object.Object {
+					err := checkArgs("time.addDate", args,
+						object.TimeObj, object.IntegerObj, object.IntegerObj, object.IntegerObj)
+					if err != nil {
+						return err
+					}
+					t := args[0].(*object.Time).Value
+					return &object.Time{Value: t.AddDate(
+						int(args[1].(*object.Integer).Value),
+						int(args[2].(*object.Integer).Value),
+						int(args[3].(*object.Integer).Value))}
+				},
+			},
+			"sub": &object.Builtin{
+				Fn: func(args ...object.Object) object.Object {
+					if err := checkArgs("time.sub", args, object.TimeObj, object.TimeObj); err != nil {
+						return err
+					}
+					a, b := args[0].(*object.Time).Value, args[1].(*object.Time).Value
+					return &object.Integer{Value: int64(a.Sub(b))}
+				},
+			},
+			"unix": &object.Builtin{
+				Fn: func(args ...object.Object) object.Object {
+					if err := checkArgs("time.unix", args, object.TimeObj); err != nil {
+						return err
+					}
+					return &object.Integer{Value: args[0].(*object.Time).Value.Unix()}
+				},
+			},
+			"fromUnix": &object.Builtin{
+				Fn: func(args ...object.Object) object.Object {
+					if err := checkArgs("time.fromUnix", args, object.IntegerObj); err != nil {
+						return err
+					}
+					return &object.Time{Value: time.Unix(args[0].(*object.Integer).Value, 0).UTC()}
+				},
+			},
+			"inZone": &object.Builtin{
+				Fn: func(args ...object.Object) object.Object {
+					if err := checkArgs("time.inZone", args, object.TimeObj, object.StringObj); err != nil {
+						return err
+					}
+					loc, err := loadLocation(args[1].(*object.String).Value)
+					if err != nil {
+						return err
+					}
+					return &object.Time{Value: args[0].(*object.Time).Value.In(loc)}
+				},
+			},
+			"format": &object.Builtin{
+				Fn: func(args ...object.Object) object.Object {
+					if err := checkArgs("time.format", args, object.TimeObj, object.StringObj); err != nil {
+						return err
+					}
+					layout := timeLayout(args[1].(*object.String).Value)
+					return &object.String{Value: args[0].(*object.Time).Value.Format(layout)}
+				},
+			},
+			"parse": &object.Builtin{
+				Fn: func(args ...object.Object) object.Object {
+					loc := time.UTC
+					if len(args) == 3 {
+						err := checkArgs("time.parse", args,
+							object.StringObj, object.StringObj, object.StringObj)
+						if err != nil {
+							return err
+						}
+						var locErr *object.Error
+						if loc, locErr = loadLocation(args[2].(*object.String).Value); locErr != nil {
+							return locErr
+						}
+					} else if err := checkArgs("time.parse", args, object.StringObj, object.StringObj); err != nil {
+						return err
+					}
+					layout := timeLayout(args[0].(*object.String).Value)
+					t, err := time.ParseInLocation(layout, args[1].(*object.String).Value, loc)
+					if err != nil {
+						return newError("%s", err)
+					}
+					return &object.Time{Value: t}
+				},
+			},
+			"duration": &object.Builtin{
+				Fn: func(args ...object.Object) object.Object {
+					if err := checkArgs("time.duration", args, object.StringObj); err != nil {
+						return err
+					}
+					d, err := time.ParseDuration(args[0].(*object.String).Value)
+					if err != nil {
+						return newError("%s", err)
+					}
+					return &object.Integer{Value: int64(d)}
+				},
+			},
+			"formatDuration": &object.Builtin{
+				Fn: func(args ...object.Object) object.Object {
+					if err := checkArgs("time.formatDuration", args, object.IntegerObj); err != nil {
+						return err
+					}
+					d := time.Duration(args[0].(*object.Integer).Value)
+					return &object.String{Value: d.String()}
+				},
+			},
+			"parts": &object.Builtin{
+				Fn: func(args ...object.Object) object.Object {
+					if err := checkArgs("time.parts", args, object.TimeObj); err != nil {
+						return err
+					}
+					return timeParts(args[0].(*object.Time).Value)
+				},
+			},
+		},
+	}
+}
+
+func timeLayout(name string) string {
+	if layout, ok := timeLayouts[name]; ok {
+		return layout
+	}
+	return name
+}
+
+func loadLocation(name string) (*time.Location, *object.Error) {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, newError("unknown time zone %s", name)
+	}
+	return loc, nil
+}
+
+// timeParts breaks t into its calendar fields in t's own location.
+func timeParts(t time.Time) *object.Hash {
+	zone, offset := t.Zone()
+	parts := object.NewHash()
+	set := func(key string, value object.Object) {
+		parts.Set(&object.String{Value: key}, value)
+	}
+	set("year", &object.Integer{Value: int64(t.Year())})
+	set("month", &object.Integer{Value: int64(t.Month())})
+	set("day", &object.Integer{Value: int64(t.Day())})
+	set("hour", &object.Integer{Value: int64(t.Hour())})
+	set("minute", &object.Integer{Value: int64(t.Minute())})
+	set("second", &object.Integer{Value: int64(t.Second())})
+	set("nanosecond", &object.Integer{Value: int64(t.Nanosecond())})
+	set("weekday", &object.String{Value: t.Weekday().String()})
+	set("zone", &object.String{Value: zone})
+	set("offset", &object.Integer{Value: int64(offset)})
+	return parts
+}
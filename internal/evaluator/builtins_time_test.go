@@ -0,0 +1,82 @@
+package evaluator
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vancanhuit/monkey/internal/lexer"
+	"github.com/vancanhuit/monkey/internal/object"
+	"github.com/vancanhuit/monkey/internal/parser"
+)
+
+// fakeClock starts at a fixed instant and only advances when slept on.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time        { return c.now }
+func (c *fakeClock) Sleep(d time.Duration) { c.now = c.now.Add(d) }
+
+func testEvalWithConfig(input string, cfg Config) object.Object {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	return Eval(program, NewEnvironment(cfg))
+}
+
+func TestTimeModule(t *testing.T) {
+	start := time.Date(2024, time.March, 9, 12, 30, 0, 0, time.UTC)
+
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`time.format(time.now(), "rfc3339")`, "2024-03-09T12:30:00Z"},
+		{`time.format(time.now(), "2006/01/02 15h04")`, "2024/03/09 12h30"},
+		{`let t = time.now(); time.sleep(2 * time.second); time.since(t)`, int(2 * time.Second)},
+		{`let t = time.now(); time.sleep(time.minute); time.now() > t`, true},
+		{`time.unix(time.now())`, int(start.Unix())},
+		{`time.format(time.fromUnix(0), "datetime")`, "1970-01-01 00:00:00"},
+		{`time.format(time.add(time.now(), 90 * time.minute), "time")`, "14:00:00"},
+		{`time.sub(time.add(time.now(), time.hour), time.now()) == time.hour`, true},
+		{`time.format(time.inZone(time.now(), "Asia/Tokyo"), "rfc3339")`, "2024-03-09T21:30:00+09:00"},
+		{`time.inZone(time.now(), "Mars/Olympus")`, errors.New("unknown time zone Mars/Olympus")},
+		{`time.parse("date", "2024-02-28") < time.now()`, true},
+		{
+			`time.format(time.addDate(time.parse("date", "2024-02-28"), 0, 0, 1), "date")`,
+			"2024-02-29",
+		},
+		{
+			// The day before the US switch to daylight saving time is 24
+			// hours long in calendar terms but 23 hours on the clock.
+			`let t = time.parse("datetime", "2024-03-09 12:00:00", "America/New_York");
+			time.sub(time.addDate(t, 0, 0, 1), t) / time.hour`,
+			23,
+		},
+		{`time.parse("date", "nope")`, errors.New(`parsing time "nope" as "2006-01-02": cannot parse "nope" as "2006"`)},
+		{`time.duration("1h30m") == 90 * time.minute`, true},
+		{`time.duration("soon")`, errors.New(`time: invalid duration "soon"`)},
+		{`time.formatDuration(1500 * time.millisecond)`, "1.5s"},
+		{`time.parts(time.now()).weekday`, "Saturday"},
+		{`time.parts(time.inZone(time.now(), "Europe/Paris")).hour`, 13},
+		{`time.add(1, 2)`, errors.New("argument 1 to `time.add` must be TIME, got INTEGER")},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEvalWithConfig(tc.input, Config{Clock: &fakeClock{now: start}})
+		testExpectedObject(t, evaluated, tc.expected)
+	}
+}
+
+func TestTimeModuleDefaultsToSystemClock(t *testing.T) {
+	before := time.Now()
+	evaluated := testEvalWithConfig("time.now()", Config{})
+	now, ok := evaluated.(*object.Time)
+	require.True(t, ok)
+	require.False(t, now.Value.Before(before))
+
+	evaluated = testEval("time.now()")
+	testExpectedObject(t, evaluated, errors.New("identifier not found: time"))
+}
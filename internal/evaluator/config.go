@@ -0,0 +1,202 @@
+package evaluator
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/vancanhuit/monkey/internal/object"
+)
+
+// Config holds the settings a host embedding the interpreter can provide.
+// The zero value is usable and selects the defaults described on each field.
+type Config struct {
+	// Clock is used by the time module. It defaults to the system clock.
+	Clock Clock
+
+	// Random is the source of math.random and math.randInt. It defaults to
+	// a source seeded from the clock. Each environment draws from its own
+	// source, so a script calling math.seed does not affect any other.
+	Random rand.Source
+
+	// FS enables the fs module. It is nil by default, in which case scripts
+	// have no access to the file system.
+	FS *FSConfig
+
+	// HTTP enables the http module, which sends requests through it. Hosts
+	// typically pass http.DefaultTransport. It is nil by default, in which
+	// case scripts have no network access.
+	HTTP http.RoundTripper
+
+	// MaxResponseBody caps the size in bytes of the response bodies the
+	// http module reads; longer bodies are an error. It defaults to
+	// DefaultMaxResponseBody.
+	MaxResponseBody int64
+
+	// Profile selects which of the modules above scripts may use. The empty
+	// profile is treated as ProfileFull.
+	Profile Profile
+
+	// Limits bounds the resources used by scripts.
+	Limits Limits
+
+	// Audit, if set, is called before every call to a function of one of
+	// the modules above. Returning an error denies the call.
+	Audit func(AuditEvent) error
+}
+
+// DefaultMaxResponseBody is the default of Config.MaxResponseBody.
+const DefaultMaxResponseBody = 10 << 20
+
+// Profile names a set of capabilities granted to scripts.
+type Profile string
+
+const (
+	// ProfilePure grants no module that observes or affects the host.
+	ProfilePure Profile = "pure"
+	// ProfileIORead grants the time module and the read-only functions of
+	// the fs module.
+	ProfileIORead Profile = "io-read"
+	// ProfileFull grants every module enabled by the Config.
+	ProfileFull Profile = "full"
+)
+
+// ParseProfile returns the profile with the given name.
+func ParseProfile(name string) (Profile, error) {
+	switch p := Profile(name); p {
+	case ProfilePure, ProfileIORead, ProfileFull:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown profile %q", name)
+}
+
+// AuditEvent describes a call to a privileged module function.
+type AuditEvent struct {
+	Module   string
+	Function string
+	Args     []object.Object
+}
+
+// FSConfig restricts the fs module to files below Roots. Relative paths
+// used by scripts are resolved against the first root.
+type FSConfig struct {
+	Roots []string
+}
+
+// Clock abstracts the passage of time so that tests can control it.
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time        { return time.Now() }
+func (systemClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+// NewEnvironment returns a top-level environment with the modules that
+// depend on cfg bound in it, and a math module with its own random number
+// generator. Programs evaluated in an environment created by
+// object.NewEnvironment only see the modules that need no configuration.
+// An unknown profile grants the same modules as ProfilePure.
+func NewEnvironment(cfg Config) *object.Environment {
+	clock := cfg.Clock
+	if clock == nil {
+		clock = systemClock{}
+	}
+
+	env := object.NewEnvironment()
+	var l *limiter
+	if cfg.Limits != (Limits{}) {
+		l = &limiter{limits: cfg.Limits, clock: clock}
+		clock = limitedClock{Clock: clock, limiter: l}
+		env = object.NewGuardedEnvironment(l)
+	}
+
+	source := cfg.Random
+	if source == nil {
+		source = rand.NewSource(clock.Now().UnixNano())
+	}
+	env.Set("math", newMathModule(rand.New(source)))
+
+	var granted []*object.Module
+	switch cfg.Profile {
+	case ProfileFull, "":
+		granted = append(granted, newTimeModule(clock))
+		if cfg.FS != nil {
+			granted = append(granted, newFSModule(newSandbox(cfg.FS.Roots)))
+		}
+		if cfg.HTTP != nil {
+			maxBody := cfg.MaxResponseBody
+			if maxBody <= 0 {
+				maxBody = DefaultMaxResponseBody
+			}
+			granted = append(granted, newHTTPModule(cfg.HTTP, maxBody, l))
+		}
+	case ProfileIORead:
+		granted = append(granted, newTimeModule(clock))
+		if cfg.FS != nil {
+			fs := newFSModule(newSandbox(cfg.FS.Roots))
+			granted = append(granted, restrictModule(fs, "read", "list", "exists"))
+		}
+	}
+
+	for _, m := range granted {
+		if cfg.Audit != nil {
+			m = auditModule(m, cfg.Audit)
+		}
+		env.Set(m.Name, m)
+	}
+	return env
+}
+
+// NewMacroEnvironment returns an environment to define and expand macros
+// in. Macro bodies run under the limits of cfg, like the programs they
+// expand, but see none of the modules cfg grants, as with ProfilePure.
+func NewMacroEnvironment(cfg Config) *object.Environment {
+	if cfg.Limits == (Limits{}) {
+		return object.NewEnvironment()
+	}
+	clock := cfg.Clock
+	if clock == nil {
+		clock = systemClock{}
+	}
+	return object.NewGuardedEnvironment(&limiter{limits: cfg.Limits, clock: clock})
+}
+
+// restrictModule returns a copy of m with only the named members.
+func restrictModule(m *object.Module, names ...string) *object.Module {
+	members := make(map[string]object.Object, len(names))
+	for _, name := range names {
+		if member, ok := m.Members[name]; ok {
+			members[name] = member
+		}
+	}
+	return &object.Module{Name: m.Name, Members: members}
+}
+
+// auditModule returns a copy of m whose functions report each call to audit
+// and only run if it returns nil. The copies are guarded, and pass the guard
+// on to the functions they wrap, which are charged as they would be unwrapped.
+func auditModule(m *object.Module, audit func(AuditEvent) error) *object.Module {
+	members := make(map[string]object.Object, len(m.Members))
+	for name, member := range m.Members {
+		builtin, ok := member.(*object.Builtin)
+		if !ok {
+			members[name] = member
+			continue
+		}
+		name := name
+		members[name] = &object.Builtin{
+			Guarded: func(g object.Guard, args ...object.Object) object.Object {
+				event := AuditEvent{Module: m.Name, Function: name, Args: args}
+				if err := audit(event); err != nil {
+					return newError("call to %s.%s denied: %s", m.Name, name, err)
+				}
+				return applyFunction(g, builtin, args)
+			},
+		}
+	}
+	return &object.Module{Name: m.Name, Members: members}
+}
@@ -0,0 +1,96 @@
+package evaluator
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfiles(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "in.txt"), []byte("in"), 0o644))
+	fs := &FSConfig{Roots: []string{root}}
+
+	testCases := []struct {
+		profile  Profile
+		input    string
+		expected interface{}
+	}{
+		{ProfileFull, `fs.write("out.txt", "x"); fs.read("out.txt")`, "x"},
+		{"", `fs.read("in.txt")`, "in"},
+		{ProfileIORead, `fs.read("in.txt")`, "in"},
+		{ProfileIORead, `fs.exists("in.txt")`, true},
+		{ProfileIORead, `fs.write("out.txt", "x")`, errors.New("module fs has no member write")},
+		{ProfileIORead, `fs.mkdir("dir")`, errors.New("module fs has no member mkdir")},
+		{ProfileIORead, `time.second`, int(time.Second)},
+		{ProfilePure, `fs.read("in.txt")`, errors.New("identifier not found: fs")},
+		{ProfilePure, `time.now()`, errors.New("identifier not found: time")},
+		{ProfilePure, `json.stringify([1, 2])`, "[1,2]"},
+		{"unknown", `time.now()`, errors.New("identifier not found: time")},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.profile)+": "+tc.input, func(t *testing.T) {
+			cfg := Config{FS: fs, Profile: tc.profile}
+			testExpectedObject(t, testEvalWithConfig(tc.input, cfg), tc.expected)
+		})
+	}
+}
+
+func TestParseProfile(t *testing.T) {
+	for _, name := range []string{"pure", "io-read", "full"} {
+		p, err := ParseProfile(name)
+		require.NoError(t, err)
+		require.Equal(t, Profile(name), p)
+	}
+
+	_, err := ParseProfile("admin")
+	require.EqualError(t, err, `unknown profile "admin"`)
+}
+
+func TestAudit(t *testing.T) {
+	root := t.TempDir()
+	var events []AuditEvent
+	cfg := Config{
+		FS: &FSConfig{Roots: []string{root}},
+		Audit: func(e AuditEvent) error {
+			events = append(events, e)
+			if e.Function == "write" {
+				return errors.New("writes are not allowed")
+			}
+			return nil
+		},
+	}
+
+	result := testEvalWithConfig(`fs.exists("a.txt"); time.second; fs.write("a.txt", "x")`, cfg)
+	testExpectedObject(t, result, errors.New("call to fs.write denied: writes are not allowed"))
+
+	require.Len(t, events, 2)
+	require.Equal(t, "fs", events[0].Module)
+	require.Equal(t, "exists", events[0].Function)
+	require.Len(t, events[0].Args, 1)
+	require.Equal(t, `a.txt`, events[0].Args[0].Inspect())
+	require.Equal(t, "write", events[1].Function)
+
+	_, err := os.Stat(filepath.Join(root, "a.txt"))
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestAuditKeepsCharges(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "big.txt"), []byte(strings.Repeat("a", 4096)), 0o600))
+	cfg := Config{
+		FS:     &FSConfig{Roots: []string{root}},
+		Audit:  func(AuditEvent) error { return nil },
+		Limits: Limits{MaxMemory: 8192},
+	}
+
+	testExpectedObject(t, testEvalWithConfig(`len(fs.read("big.txt"))`, cfg), 4096)
+	testExpectedObject(t, testEvalWithConfig(`fs.read("big.txt") + fs.read("big.txt")`, cfg),
+		errors.New("memory limit of 8192 bytes exceeded"))
+}
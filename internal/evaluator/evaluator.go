@@ -8,12 +8,32 @@ import (
 )
 
 var (
-	True  = &object.Boolean{Value: true}
-	False = &object.Boolean{Value: false}
-	Null  = &object.Null{}
+	True  = object.TRUE
+	False = object.FALSE
+	Null  = object.NULL
 )
 
 func Eval(node ast.Node, env *object.Environment) object.Object {
+	guard := env.Guard()
+	if guard == nil {
+		return evalNode(node, env)
+	}
+
+	if err := guard.Step(); err != nil {
+		return newError("%s", err)
+	}
+	result := evalNode(node, env)
+	// The results of builtins are charged by applyFunction.
+	switch node.(type) {
+	case *ast.InfixExpression, *ast.ArrayLiteral, *ast.HashLiteral:
+		if err := guard.Alloc(objectSize(result)); err != nil {
+			return newError("%s", err)
+		}
+	}
+	return result
+}
+
+func evalNode(node ast.Node, env *object.Environment) object.Object {
 	switch n := node.(type) {
 	case *ast.Program:
 		return evalProgram(n, env)
@@ -21,6 +41,8 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return Eval(n.Expression, env)
 	case *ast.IntegerLiteral:
 		return &object.Integer{Value: n.Value}
+	case *ast.FloatLiteral:
+		return &object.Float{Value: n.Value}
 	case *ast.Boolean:
 		return nativeBoolToBooleanObject(n.Value)
 	case *ast.PrefixExpression:
@@ -54,18 +76,30 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isError(val) {
 			return val
 		}
+		if err := checkType("value of "+n.Name.Value, val, n.Type); err != nil {
+			return err
+		}
 		env.Set(n.Name.Value, val)
 	case *ast.Identifier:
 		return evalIdentifier(n, env)
 	case *ast.FunctionLiteral:
-		params := n.Parameters
-		body := n.Body
 		return &object.Function{
-			Parameters: params,
-			Body:       body,
-			Env:        env,
+			Parameters:     n.Parameters,
+			ParameterTypes: n.ParameterTypes,
+			ReturnType:     n.ReturnType,
+			Body:           n.Body,
+			Env:            env,
 		}
+	case *ast.MacroLiteral:
+		return newError("macros can only be defined by a top-level let statement")
 	case *ast.CallExpression:
+		if isCallTo(n, "quote") {
+			if len(n.Arguments) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(n.Arguments))
+			}
+			return quote(n.Arguments[0], env)
+		}
+
 		function := Eval(n.Function, env)
 		if isError(function) {
 			return function
@@ -76,7 +110,7 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 			return args[0]
 		}
 
-		return applyFunction(function, args)
+		return applyFunction(env.Guard(), function, args)
 	case *ast.StringLiteral:
 		return &object.String{
 			Value: n.Value,
@@ -101,6 +135,14 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return evalIndexExpression(left, index)
 	case *ast.HashLiteral:
 		return evalHashLiteral(n, env)
+	case *ast.AssignExpression:
+		return evalAssignExpression(n, env)
+	case *ast.MemberExpression:
+		left := Eval(n.Left, env)
+		if isError(left) {
+			return left
+		}
+		return evalMemberExpression(left, n.Property)
 	}
 	return nil
 }
@@ -112,8 +154,14 @@ func isError(obj object.Object) bool {
 	return false
 }
 
-func evalProgram(program *ast.Program, env *object.Environment) object.Object {
-	var result object.Object
+// evalProgram evaluates the statements of program in turn. A panic in a
+// builtin is reported as an error rather than crashing the host.
+func evalProgram(program *ast.Program, env *object.Environment) (result object.Object) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = newError("internal error: %v", r)
+		}
+	}()
 
 	for _, stmt := range program.Statements {
 		result = Eval(stmt, env)
@@ -177,14 +225,16 @@ func evalBangOperatorExpression(right object.Object) object.Object {
 }
 
 func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
-	if right.Type() != object.IntegerObj {
+	switch r := right.(type) {
+	case *object.Integer:
+		return &object.Integer{Value: -r.Value}
+	case *object.Float:
+		return &object.Float{Value: -r.Value}
+	default:
 		return &object.Error{
 			Message: fmt.Sprintf("unknown operator: -%s", right.Type()),
 		}
 	}
-
-	value := right.(*object.Integer).Value
-	return &object.Integer{Value: -value}
 }
 
 func evalInfixExpression(
@@ -194,16 +244,20 @@ func evalInfixExpression(
 	switch {
 	case left.Type() == object.IntegerObj && right.Type() == object.IntegerObj:
 		return evalIntegerInfixExpression(operator, left, right)
+	case isNumber(left) && isNumber(right):
+		return evalFloatInfixExpression(operator, left, right)
 	case left.Type() == object.StringObj && right.Type() == object.StringObj:
 		return evalStringInfixExpression(operator, left, right)
 	case operator == "==":
-		return nativeBoolToBooleanObject(left == right)
+		return nativeBoolToBooleanObject(object.Equal(left, right))
 	case operator == "!=":
-		return nativeBoolToBooleanObject(left != right)
+		return nativeBoolToBooleanObject(!object.Equal(left, right))
 	case left.Type() != right.Type():
 		return &object.Error{
 			Message: fmt.Sprintf("type mismatch: %s %s %s", left.Type(), operator, right.Type()),
 		}
+	case operator == "<" || operator == ">":
+		return evalComparison(operator, left, right)
 	default:
 		return &object.Error{
 			Message: fmt.Sprintf("unknown operator: %s %s %s", left.Type(), operator, right.Type()),
@@ -211,11 +265,22 @@ func evalInfixExpression(
 	}
 }
 
-func nativeBoolToBooleanObject(input bool) *object.Boolean {
-	if input {
-		return True
+func evalComparison(operator string, left, right object.Object) object.Object {
+	c, err := object.Compare(left, right)
+	if err != nil {
+		return &object.Error{
+			Message: fmt.Sprintf("unknown operator: %s %s %s", left.Type(), operator, right.Type()),
+		}
 	}
-	return False
+
+	if operator == "<" {
+		return nativeBoolToBooleanObject(c < 0)
+	}
+	return nativeBoolToBooleanObject(c > 0)
+}
+
+func nativeBoolToBooleanObject(input bool) *object.Boolean {
+	return object.NativeBoolToBoolean(input)
 }
 
 func evalIntegerInfixExpression(
@@ -233,6 +298,9 @@ func evalIntegerInfixExpression(
 	case "*":
 		return &object.Integer{Value: leftValue * rightValue}
 	case "/":
+		if rightValue == 0 {
+			return newError("division by zero")
+		}
 		return &object.Integer{Value: leftValue / rightValue}
 	case "<":
 		return nativeBoolToBooleanObject(leftValue < rightValue)
@@ -249,6 +317,53 @@ func evalIntegerInfixExpression(
 	}
 }
 
+func isNumber(obj object.Object) bool {
+	t := obj.Type()
+	return t == object.IntegerObj || t == object.FloatObj
+}
+
+func toFloat(obj object.Object) float64 {
+	if i, ok := obj.(*object.Integer); ok {
+		return float64(i.Value)
+	}
+	return obj.(*object.Float).Value
+}
+
+// evalFloatInfixExpression handles arithmetic where at least one operand is
+// a float; integer operands are widened to float.
+func evalFloatInfixExpression(
+	operator string,
+	left, right object.Object,
+) object.Object {
+	leftValue := toFloat(left)
+	rightValue := toFloat(right)
+
+	switch operator {
+	case "+":
+		return &object.Float{Value: leftValue + rightValue}
+	case "-":
+		return &object.Float{Value: leftValue - rightValue}
+	case "*":
+		return &object.Float{Value: leftValue * rightValue}
+	case "/":
+		return &object.Float{Value: leftValue / rightValue}
+	case "<":
+		return nativeBoolToBooleanObject(leftValue < rightValue)
+	case ">":
+		return nativeBoolToBooleanObject(leftValue > rightValue)
+	case "==":
+		// Equal compares an integer with a float without rounding it, as
+		// hash keys do.
+		return nativeBoolToBooleanObject(object.Equal(left, right))
+	case "!=":
+		return nativeBoolToBooleanObject(!object.Equal(left, right))
+	default:
+		return &object.Error{
+			Message: fmt.Sprintf("unknown operator: %s %s %s", left.Type(), operator, right.Type()),
+		}
+	}
+}
+
 func evalIfExpression(
 	expr *ast.IfExpression,
 	env *object.Environment,
@@ -288,8 +403,8 @@ func evalIdentifier(
 		return val
 	}
 
-	if builtin, ok := builtins[node.Value]; ok {
-		return builtin
+	if global, ok := Global(node.Value); ok {
+		return global
 	}
 
 	return &object.Error{
@@ -314,17 +429,47 @@ func evalExpressions(
 	return result
 }
 
+// applyFunction calls fn with args. g is the guard of the environment fn
+// is called from, or nil; functions written in Monkey are supervised by the
+// guard of the environment they were defined in.
 func applyFunction(
+	g object.Guard,
 	fn object.Object,
 	args []object.Object,
 ) object.Object {
 	switch f := fn.(type) {
 	case *object.Function:
-		extendedEnv := extendFunctionEnv(f, args)
-		evaluated := Eval(f.Body, extendedEnv)
-		return unwrapReturnValue(evaluated)
+		if len(args) != len(f.Parameters) {
+			return &object.Error{
+				Message: fmt.Sprintf(
+					"wrong number of arguments. got=%d, want=%d",
+					len(args), len(f.Parameters)),
+			}
+		}
+		extendedEnv, err := extendFunctionEnv(f, args)
+		if err != nil {
+			return err
+		}
+		evaluated := unwrapReturnValue(Eval(f.Body, extendedEnv))
+		if isError(evaluated) {
+			return evaluated
+		}
+		if err := checkReturnType(f, evaluated); err != nil {
+			return err
+		}
+		return evaluated
 	case *object.Builtin:
-		return f.Fn(args...)
+		if f.Guarded != nil {
+			return f.Guarded(g, args...)
+		}
+		result := f.Fn(args...)
+		if isError(result) {
+			return result
+		}
+		if err := alloc(g, objectSize(result)); err != nil {
+			return err
+		}
+		return result
 	}
 
 	return &object.Error{
@@ -333,17 +478,24 @@ func applyFunction(
 
 }
 
+// extendFunctionEnv binds the parameters of fn to args, which must match
+// their annotations.
 func extendFunctionEnv(
 	fn *object.Function,
 	args []object.Object,
-) *object.Environment {
+) (*object.Environment, *object.Error) {
 	env := object.NewEnclosedEnvironment(fn.Env)
 
 	for i, p := range fn.Parameters {
+		if i < len(fn.ParameterTypes) {
+			if err := checkType("parameter "+p.Value, args[i], fn.ParameterTypes[i]); err != nil {
+				return nil, err
+			}
+		}
 		env.Set(p.Value, args[i])
 	}
 
-	return env
+	return env, nil
 }
 
 func unwrapReturnValue(obj object.Object) object.Object {
@@ -358,16 +510,27 @@ func evalStringInfixExpression(
 	operator string,
 	left, right object.Object,
 ) object.Object {
-	if operator != "+" {
+	leftVal := left.(*object.String).Value
+	rightVal := right.(*object.String).Value
+
+	switch operator {
+	case "+":
+		return &object.String{Value: leftVal + rightVal}
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
 		return &object.Error{
 			Message: fmt.Sprintf(
 				"unknown operator: %s %s %s",
 				left.Type(), operator, right.Type()),
 		}
 	}
-	leftVal := left.(*object.String).Value
-	rightVal := right.(*object.String).Value
-	return &object.String{Value: leftVal + rightVal}
 }
 
 func evalIndexExpression(left, index object.Object) object.Object {
@@ -398,47 +561,128 @@ func evalHashLiteral(
 	node *ast.HashLiteral,
 	env *object.Environment,
 ) object.Object {
-	pairs := make(map[object.HashKey]object.HashPair)
+	hash := object.NewHash()
 
-	for keyNode, valueNode := range node.Pairs {
-		key := Eval(keyNode, env)
+	for _, pair := range node.Pairs {
+		key := Eval(pair.Key, env)
 		if isError(key) {
 			return key
 		}
 
-		hashKey, ok := key.(object.Hashable)
-		if !ok {
+		if _, ok := object.HashKeyOf(key); !ok {
 			return &object.Error{
 				Message: fmt.Sprintf("unusable as hash key: %s", key.Type()),
 			}
 		}
 
-		value := Eval(valueNode, env)
+		value := Eval(pair.Value, env)
 		if isError(value) {
 			return value
 		}
 
-		hashed := hashKey.HashKey()
-		pairs[hashed] = object.HashPair{Key: key, Value: value}
+		hash.Set(key, value)
 	}
 
-	return &object.Hash{Pairs: pairs}
+	return hash
 }
 
 func evalHashIndexExpression(left, index object.Object) object.Object {
 	hashObject := left.(*object.Hash)
 
-	key, ok := index.(object.Hashable)
-	if !ok {
+	if _, ok := object.HashKeyOf(index); !ok {
 		return &object.Error{
 			Message: fmt.Sprintf("unusable as hash key: %s", index.Type()),
 		}
 	}
 
-	pair, ok := hashObject.Pairs[key.HashKey()]
+	pair, ok := hashObject.Get(index)
 	if !ok {
 		return Null
 	}
 
 	return pair.Value
 }
+
+func evalAssignExpression(
+	node *ast.AssignExpression,
+	env *object.Environment,
+) object.Object {
+	left := Eval(node.Target.Left, env)
+	if isError(left) {
+		return left
+	}
+
+	index := Eval(node.Target.Index, env)
+	if isError(index) {
+		return index
+	}
+
+	value := Eval(node.Value, env)
+	if isError(value) {
+		return value
+	}
+
+	switch {
+	case left.Type() == object.ArrayObj && index.Type() == object.IntegerObj:
+		return evalArrayIndexAssignment(left, index, value)
+	case left.Type() == object.HashObj:
+		return evalHashIndexAssignment(left, index, value)
+	default:
+		return &object.Error{
+			Message: fmt.Sprintf(
+				"index assignment not supported: %s", left.Type()),
+		}
+	}
+}
+
+func evalArrayIndexAssignment(array, index, value object.Object) object.Object {
+	arrayObject := array.(*object.Array)
+	idx := index.(*object.Integer).Value
+	if idx < 0 || idx >= int64(len(arrayObject.Elements)) {
+		return &object.Error{
+			Message: fmt.Sprintf("index out of range: %d", idx),
+		}
+	}
+
+	arrayObject.Elements[idx] = value
+	return value
+}
+
+func evalHashIndexAssignment(hash, index, value object.Object) object.Object {
+	hashObject := hash.(*object.Hash)
+
+	if _, ok := object.HashKeyOf(index); !ok {
+		return &object.Error{
+			Message: fmt.Sprintf("unusable as hash key: %s", index.Type()),
+		}
+	}
+
+	hashObject.Set(index, value)
+	return value
+}
+
+// evalMemberExpression looks up a member of a module, or a string key of a
+// hash so that decoded JSON objects can be accessed as payload.name.
+func evalMemberExpression(left object.Object, property *ast.Identifier) object.Object {
+	switch l := left.(type) {
+	case *object.Module:
+		member, ok := l.Members[property.Value]
+		if !ok {
+			return &object.Error{
+				Message: fmt.Sprintf(
+					"module %s has no member %s", l.Name, property.Value),
+			}
+		}
+		return member
+	case *object.Hash:
+		pair, ok := l.Get(&object.String{Value: property.Value})
+		if !ok {
+			return Null
+		}
+		return pair.Value
+	default:
+		return &object.Error{
+			Message: fmt.Sprintf("member access not supported: %s", left.Type()),
+		}
+	}
+}
@@ -74,6 +74,27 @@ func TestEvalBooleanExpression(t *testing.T) {
 		{"(1 < 2) == false", false},
 		{"(1 > 2) == true", false},
 		{"(1 > 2) == false", true},
+		{`"a" < "b"`, true},
+		{`"b" < "a"`, false},
+		{`"abc" > "abd"`, false},
+		{`"a" == "a"`, true},
+		{`"a" != "a"`, false},
+		{"[1, 2] == [1, 2]", true},
+		{"[1, 2] == [1, 2, 3]", false},
+		{"[1, [2, 3]] != [1, [2, 4]]", true},
+		{"[1, 2] < [1, 3]", true},
+		{"[1, 2] < [1, 2, 0]", true},
+		{"[2] > [1, 5]", true},
+		{"[1, 2.0] == [1.0, 2]", true},
+		{`{"a": 1, "b": [2]} == {"b": [2], "a": 1}`, true},
+		{`{"a": 1} == {"a": 2}`, false},
+		{`{"a": 1} == {"b": 1}`, false},
+		{"false < true", true},
+		{"let f = fn() {}; f == f", true},
+		{"fn() {} == fn() {}", false},
+		{`1 == "1"`, false},
+		{`[1] == "1"`, false},
+		{"let a = [1]; append!(a, a); let b = [1]; append!(b, b); a == b", true},
 	}
 	for _, tc := range testCases {
 		evaluated := testEval(tc.input)
@@ -186,6 +207,10 @@ func TestErrorHandling(t *testing.T) {
 			"5 + true;",
 			"type mismatch: INTEGER + BOOLEAN",
 		},
+		{
+			"1 / 0",
+			"division by zero",
+		},
 		{
 			"5 + true; 5;",
 			"type mismatch: INTEGER + BOOLEAN",
@@ -229,6 +254,22 @@ func TestErrorHandling(t *testing.T) {
 			`{"name": "Monkey"}[fn(x) { x }];`,
 			"unusable as hash key: FUNCTION",
 		},
+		{
+			`{} < {}`,
+			"unknown operator: HASH < HASH",
+		},
+		{
+			`[1] < ["a"]`,
+			"unknown operator: ARRAY < ARRAY",
+		},
+		{
+			`1 < "a"`,
+			"type mismatch: INTEGER < STRING",
+		},
+		{
+			"fn(x) { x }()",
+			"wrong number of arguments. got=0, want=1",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -284,6 +325,44 @@ func TestFunctionApplication(t *testing.T) {
 	}
 }
 
+func TestTypeAnnotations(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let x: int = 5; x;", 5},
+		{"let x: float = 5; x;", 5},
+		{"let xs: [int] = [1, 2]; len(xs);", 2},
+		{`let h: {string: int} = {"a": 1}; h["a"];`, 1},
+		{"let xs: [any] = [1, true]; len(xs);", 2},
+		{"let id = fn(x: a) -> a { x }; id(5);", 5},
+		{"let f: fn(int) -> int = fn(x) { x }; f(5);", 5},
+		{"let apply = fn(f: fn(a) -> b, x: a) -> b { f(x) }; apply(len, [1]);", 1},
+		{"let add = fn(a: int, b: int) -> int { return a + b; }; add(2, 3);", 5},
+		{"let g = fn() -> null { }; g(); 1;", 1},
+		{`let x: string = 5;`, "value of x must be string, got INTEGER"},
+		{`let xs: [int] = [1, "a"];`, "value of xs must be [int], got ARRAY"},
+		{`let h: {string: int} = {1: 1};`, "value of h must be {string: int}, got HASH"},
+		{"let f: fn(int) -> int = fn(x, y) { x };", "value of f must be fn(int) -> int, got FUNCTION"},
+		{`let f = fn(x: int) { x }; f("a");`, "parameter x must be int, got STRING"},
+		{`let f = fn(x) -> string { x }; f(1);`, "return value must be string, got INTEGER"},
+		{`let f = fn(x) -> int { return "a"; }; f(1);`, "return value must be int, got STRING"},
+		{"let g = fn() -> int { }; g();", "return value must be int, got NULL"},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(tc.input)
+		switch expected := tc.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			require.True(t, ok, tc.input)
+			require.Equal(t, expected, errObj.Message)
+		}
+	}
+}
+
 func TestClosures(t *testing.T) {
 	input := `
 	let newAdder = fn(x) {
@@ -349,6 +428,20 @@ func TestBuiltinFunctions(t *testing.T) {
 		{`rest([])`, nil},
 		{`push([], 1)`, []int{1}},
 		{`push(1, 1)`, "argument to `push` must be ARRAY, got INTEGER"},
+		{`let a = [1]; push(a, 2); a`, []int{1}},
+		{`let a = [1]; append!(a, 2, 3); a`, []int{1, 2, 3}},
+		{`append!([])`, []int{}},
+		{`append!(1, 1)`, "argument to `append!` must be ARRAY, got INTEGER"},
+		{`append!()`, "wrong number of arguments. got=0, want at least 1"},
+		{`let a = [1, 2]; pop(a)`, 2},
+		{`let a = [1, 2]; pop(a); a`, []int{1}},
+		{`pop([])`, nil},
+		{`pop(1)`, "argument to `pop` must be ARRAY, got INTEGER"},
+		{`let h = {"a": 1}; delete(h, "a")`, 1},
+		{`let h = {"a": 1}; delete(h, "a"); h["a"]`, nil},
+		{`delete({}, "a")`, nil},
+		{`delete([], 1)`, "argument to `delete` must be HASH, got ARRAY"},
+		{`delete({}, fn() {})`, "unusable as hash key: FUNCTION"},
 	}
 
 	for _, tc := range testCases {
@@ -455,20 +548,117 @@ func TestHashLiterals(t *testing.T) {
 	evaluated := testEval(input)
 	result, ok := evaluated.(*object.Hash)
 	require.True(t, ok)
-	expected := map[object.HashKey]int64{
-		(&object.String{Value: "one"}).HashKey():   1,
-		(&object.String{Value: "two"}).HashKey():   2,
-		(&object.String{Value: "three"}).HashKey(): 3,
-		(&object.Integer{Value: 4}).HashKey():      4,
-		True.HashKey():                             5,
-		False.HashKey():                            6,
-	}
-
-	require.Equal(t, len(result.Pairs), len(expected))
-	for expectedKey, expectedValue := range expected {
-		pair, ok := result.Pairs[expectedKey]
+	expected := []struct {
+		key   object.Object
+		value int64
+	}{
+		{&object.String{Value: "one"}, 1},
+		{&object.String{Value: "two"}, 2},
+		{&object.String{Value: "three"}, 3},
+		{&object.Integer{Value: 4}, 4},
+		{True, 5},
+		{False, 6},
+	}
+
+	require.Equal(t, len(expected), result.Len())
+	for i, e := range expected {
+		pair, ok := result.Get(e.key)
 		require.True(t, ok)
-		testIntegerObject(t, pair.Value, expectedValue)
+		testIntegerObject(t, pair.Value, e.value)
+		require.Equal(t, pair, result.Pairs()[i])
+	}
+}
+
+func TestCompositeAndFloatHashKeys(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`{[1, "a"]: 5}[[1, "a"]]`, 5},
+		{`{[1, "a"]: 5}[["a", 1]]`, nil},
+		{`{[[1], [2]]: 5}[[[1], [2]]]`, 5},
+		{`{[]: 5}[[]]`, 5},
+		{`let k = [1]; let h = {}; h[k] = 5; append!(k, 2); h[[1]]`, 5},
+		{`let k = [1]; let h = {}; h[k] = 5; append!(k, 2); h[k]`, nil},
+		{`{1.5: 5}[1.5]`, 5},
+		{`{0.0: 5}[-0.0]`, 5},
+		{`{1: 5}[1.0]`, 5},
+		{`{1.0: 5}[1]`, 5},
+		{`let h = {1: 5}; h[1.0] = 6; len(keys(h)) * 10 + h[1]`, 16},
+		{`{[1, 2.0]: 5}[[1.0, 2]]`, 5},
+		{`{1.5: 5}[1]`, nil},
+		{`{0.0 / 0.0: 5}`, "unusable as hash key: FLOAT"},
+		{`{[0.0 / 0.0]: 5}`, "unusable as hash key: ARRAY"},
+		{`{[1.5, true]: 5}[[1.5, true]]`, 5},
+		{`{[fn() {}]: 5}`, "unusable as hash key: ARRAY"},
+		{`let a = []; append!(a, a); {a: 1}`, "unusable as hash key: ARRAY"},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(tc.input)
+		switch expected := tc.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case nil:
+			testNullObject(t, evaluated)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			require.True(t, ok)
+			require.Equal(t, expected, errObj.Message)
+		}
+	}
+}
+
+func TestFloatExpressions(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"1.5", 1.5},
+		{"-2.25", -2.25},
+		{"1.5 + 1.5", 3.0},
+		{"1 + 0.5", 1.5},
+		{"0.5 * 4", 2.0},
+		{"1 / 4.0", 0.25},
+		{"10 - 0.5", 9.5},
+		{"1.5 < 2", true},
+		{"2 > 1.5", true},
+		{"1.0 == 1", true},
+		{"0.1 != 0.1", false},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(tc.input)
+		switch expected := tc.expected.(type) {
+		case float64:
+			result, ok := evaluated.(*object.Float)
+			require.True(t, ok)
+			require.Equal(t, expected, result.Value)
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestHashOrdering(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{`{"b": 1, "a": 2, "c": 3}`, "{b: 1, a: 2, c: 3}"},
+		{`{3: 1, 1: 2, 2: 3}`, "{3: 1, 1: 2, 2: 3}"},
+		{`{"a": 1, "b": 2, "a": 3}`, "{a: 3, b: 2}"},
+		{`let h = {"a": 1, "b": 2}; h["a"] = 3; h`, "{a: 3, b: 2}"},
+		{`let h = {"a": 1, "b": 2}; delete(h, "a"); h["a"] = 3; h`, "{b: 2, a: 3}"},
+		{`keys({"z": 1, "y": 2, "x": 3})`, "[z, y, x]"},
+		{`values({"z": 1, "y": 2, "x": 3})`, "[1, 2, 3]"},
+		{`keys({})`, "[]"},
+		{`keys([])`, "ERROR: argument to `keys` must be HASH, got ARRAY"},
+		{`values(1)`, "ERROR: argument to `values` must be HASH, got INTEGER"},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, testEval(tc.input).Inspect())
 	}
 }
 
@@ -516,3 +706,99 @@ func TestHashIndexExpressions(t *testing.T) {
 		}
 	}
 }
+
+func TestMemberExpressions(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`{"a": 1}.a`, 1},
+		{`{"a": {"b": 2}}.a.b`, 2},
+		{`{"a": 1}.b`, nil},
+		{`{1: 1}.a`, nil},
+		{`let h = {}; h["x"] = 3; h.x`, 3},
+		{`[1].a`, "member access not supported: ARRAY"},
+		{`math.nothing`, "module math has no member nothing"},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(tc.input)
+		switch expected := tc.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case nil:
+			testNullObject(t, evaluated)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			require.True(t, ok)
+			require.Equal(t, expected, errObj.Message)
+		}
+	}
+}
+
+func TestIndexAssignment(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let a = [1, 2, 3]; a[0] = 10; a[0]", 10},
+		{"let a = [1, 2, 3]; a[2] = 10", 10},
+		{"let a = [1, 2, 3]; let b = a; b[1] = 5; a[1]", 5},
+		{"let a = [0, 0]; a[0] = a[1] = 7; a[0] + a[1]", 14},
+		{`let h = {}; h["a"] = 1; h["a"]`, 1},
+		{`let h = {"a": 1}; h["a"] = 2; h["a"]`, 2},
+		{`let h = {}; h[true] = 3; h[true]`, 3},
+		{"let a = [1]; a[1] = 2", "index out of range: 1"},
+		{"let a = [1]; a[-1] = 2", "index out of range: -1"},
+		{`let h = {}; h[fn() {}] = 1`, "unusable as hash key: FUNCTION"},
+		{`let s = "ab"; s[0] = "c"`, "index assignment not supported: STRING"},
+		{"let a = [1]; a[0] = b", "identifier not found: b"},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(tc.input)
+		switch expected := tc.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			require.True(t, ok)
+			require.Equal(t, expected, errObj.Message)
+		}
+	}
+}
+
+// testExpectedObject checks obj against an expected Go value: ints, floats,
+// bools and nil map to the corresponding objects, string slices to arrays of
+// strings, and an error to an error object with that message.
+func testExpectedObject(t *testing.T, obj object.Object, expected interface{}) {
+	t.Helper()
+
+	switch expected := expected.(type) {
+	case int:
+		testIntegerObject(t, obj, int64(expected))
+	case float64:
+		result, ok := obj.(*object.Float)
+		require.True(t, ok, "expected FLOAT, got %s", obj.Inspect())
+		require.InDelta(t, expected, result.Value, 1e-9)
+	case bool:
+		testBooleanObject(t, obj, expected)
+	case nil:
+		testNullObject(t, obj)
+	case string:
+		result, ok := obj.(*object.String)
+		require.True(t, ok, "expected STRING, got %s", obj.Inspect())
+		require.Equal(t, expected, result.Value)
+	case []string:
+		array, ok := obj.(*object.Array)
+		require.True(t, ok, "expected ARRAY, got %s", obj.Inspect())
+		require.Len(t, array.Elements, len(expected))
+		for i, e := range expected {
+			testExpectedObject(t, array.Elements[i], e)
+		}
+	case error:
+		errObj, ok := obj.(*object.Error)
+		require.True(t, ok, "expected ERROR, got %s", obj.Inspect())
+		require.Equal(t, expected.Error(), errObj.Message)
+	}
+}
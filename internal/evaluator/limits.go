@@ -0,0 +1,114 @@
+package evaluator
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/vancanhuit/monkey/internal/object"
+)
+
+// Limits bounds the resources used by programs evaluated in an environment
+// returned by NewEnvironment. A zero field means no limit. The limits apply
+// to the environment as a whole, across every program evaluated in it.
+type Limits struct {
+	// MaxSteps caps the number of AST nodes evaluated.
+	MaxSteps int
+	// Timeout caps the time elapsed on the configured clock since the first
+	// node was evaluated. time.sleep never sleeps past the deadline.
+	Timeout time.Duration
+	// MaxMemory caps the approximate number of bytes allocated for strings,
+	// arrays and hashes. Allocations are never credited back, so this bounds
+	// the total allocated rather than the memory in use at any one time.
+	MaxMemory int
+}
+
+// limiter is the object.Guard enforcing Limits.
+type limiter struct {
+	limits   Limits
+	clock    Clock
+	steps    int
+	memory   int
+	deadline time.Time
+}
+
+func (l *limiter) Step() error {
+	l.steps++
+	if l.limits.MaxSteps > 0 && l.steps > l.limits.MaxSteps {
+		return fmt.Errorf("step limit of %d exceeded", l.limits.MaxSteps)
+	}
+	return l.checkTime()
+}
+
+// Alloc also checks the deadline, since builtins allocating large values
+// may run for long without any node being evaluated.
+func (l *limiter) Alloc(size int) error {
+	l.memory += size
+	if l.limits.MaxMemory > 0 && l.memory > l.limits.MaxMemory {
+		return fmt.Errorf("memory limit of %d bytes exceeded", l.limits.MaxMemory)
+	}
+	return l.checkTime()
+}
+
+// checkTime starts the clock on the first call and reports whether the
+// deadline has passed since.
+func (l *limiter) checkTime() error {
+	if l.limits.Timeout > 0 {
+		now := l.clock.Now()
+		if l.deadline.IsZero() {
+			l.deadline = now.Add(l.limits.Timeout)
+		} else if !now.Before(l.deadline) {
+			return fmt.Errorf("time limit of %s exceeded", l.limits.Timeout)
+		}
+	}
+	return nil
+}
+
+// remaining returns the time left before the deadline, and false if the
+// limiter has no deadline or has not started the clock yet.
+func (l *limiter) remaining() (time.Duration, bool) {
+	if l.deadline.IsZero() {
+		return 0, false
+	}
+	return l.deadline.Sub(l.clock.Now()), true
+}
+
+// limitedClock shortens sleeps that would run past the limiter's deadline.
+type limitedClock struct {
+	Clock
+	limiter *limiter
+}
+
+func (c limitedClock) Sleep(d time.Duration) {
+	if remaining, ok := c.limiter.remaining(); ok && remaining < d {
+		d = remaining
+	}
+	if d > 0 {
+		c.Clock.Sleep(d)
+	}
+}
+
+// alloc charges g, if there is one, for size bytes. Builtins call it
+// before allocating a value whose size depends on their arguments.
+func alloc(g object.Guard, size int) *object.Error {
+	if g == nil {
+		return nil
+	}
+	if err := g.Alloc(size); err != nil {
+		return newError("%s", err)
+	}
+	return nil
+}
+
+// objectSize estimates the bytes allocated for obj, not counting the values
+// it refers to, which are accounted for when they are created.
+func objectSize(obj object.Object) int {
+	switch obj := obj.(type) {
+	case *object.String:
+		return len(obj.Value)
+	case *object.Array:
+		return 8 * len(obj.Elements)
+	case *object.Hash:
+		return 64 * obj.Len()
+	}
+	return 0
+}
@@ -0,0 +1,171 @@
+package evaluator
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vancanhuit/monkey/internal/lexer"
+	"github.com/vancanhuit/monkey/internal/object"
+	"github.com/vancanhuit/monkey/internal/parser"
+)
+
+func TestLimits(t *testing.T) {
+	loop := `let loop = fn(n) { if (n > 0) { loop(n - 1) } }; `
+
+	testCases := []struct {
+		limits   Limits
+		input    string
+		expected interface{}
+	}{
+		{Limits{MaxSteps: 1000}, loop + `loop(10); 1`, 1},
+		{Limits{MaxSteps: 1000}, loop + `loop(1000)`, errors.New("step limit of 1000 exceeded")},
+		{Limits{MaxSteps: 1000}, `map(range(1000), fn(x) { x * 2 })`, errors.New("step limit of 1000 exceeded")},
+		{Limits{MaxMemory: 1024}, `len(strings.repeat("a", 100) + "b")`, 101},
+		{Limits{MaxMemory: 1024}, `strings.repeat("a", 2000)`, errors.New("memory limit of 1024 bytes exceeded")},
+		{
+			Limits{MaxMemory: 1024},
+			`let grow = fn(s, n) { if (n > 0) { grow(s + s, n - 1) } else { s } }; grow("ab", 20)`,
+			errors.New("memory limit of 1024 bytes exceeded"),
+		},
+		{Limits{MaxMemory: 1024}, `range(0, 20000000)`, errors.New("memory limit of 1024 bytes exceeded")},
+		{Limits{MaxMemory: 1024}, `len(range(100))`, 100},
+		{Limits{MaxMemory: 1024}, `let a = range(100); sort(a)`, errors.New("memory limit of 1024 bytes exceeded")},
+		{Limits{MaxMemory: 1024}, `let a = range(50); flatten([a, a, a])`, errors.New("memory limit of 1024 bytes exceeded")},
+		{
+			Limits{MaxMemory: 1024},
+			`let a = range(3); map([a, a], fn(x) { x })`,
+			inspected("[[0, 1, 2], [0, 1, 2]]"),
+		},
+		{
+			Limits{MaxMemory: 1024},
+			`strings.split(strings.repeat("a", 500), "")`,
+			errors.New("memory limit of 1024 bytes exceeded"),
+		},
+		{
+			Limits{MaxMemory: 1024},
+			`regex.findAll("a", strings.repeat("a", 100))`,
+			errors.New("memory limit of 1024 bytes exceeded"),
+		},
+		{
+			Limits{MaxMemory: 1024},
+			`regex.replace("a", strings.repeat("a", 100), "$0$0$0$0$0$0$0$0$0$0")`,
+			errors.New("memory limit of 1024 bytes exceeded"),
+		},
+		{
+			Limits{MaxMemory: 1024},
+			`regex.split("", strings.repeat("a", 500))`,
+			errors.New("memory limit of 1024 bytes exceeded"),
+		},
+		{Limits{MaxMemory: 1024}, `regex.replace("a", "banana", "$0$0")`, "baanaanaa"},
+		{
+			Limits{MaxMemory: 1 << 20},
+			`strings.replace(strings.repeat("a", 1000), "a", strings.repeat("b", 100000))`,
+			errors.New("memory limit of 1048576 bytes exceeded"),
+		},
+		{Limits{MaxMemory: 1024}, `strings.replace("banana", "a", "oo")`, "boonoonoo"},
+		{
+			Limits{MaxMemory: 1024},
+			`let a = strings.repeat("a", 400); strings.join([a, a], "")`,
+			errors.New("memory limit of 1024 bytes exceeded"),
+		},
+		{
+			Limits{MaxMemory: 1024},
+			`let a = strings.repeat("a", 400); strings.format("%s%v", a, a)`,
+			errors.New("memory limit of 1024 bytes exceeded"),
+		},
+		{
+			Limits{MaxMemory: 1 << 17},
+			`let a = []; each(range(3000), fn(i) { append!(a, i) }); len(a)`,
+			3000,
+		},
+		{Limits{MaxMemory: 1024}, `len(json.stringify(range(50)))`, 141},
+		{
+			Limits{MaxMemory: 1024},
+			`json.stringify(range(50), "          ")`,
+			errors.New("memory limit of 1024 bytes exceeded"),
+		},
+		{Limits{MaxMemory: 1024}, `let a = [strings.repeat("a", 600)]; len(pop(a))`, 600},
+		{Limits{MaxMemory: 1024}, `let a = [strings.repeat("a", 600)]; len(first(a))`, 600},
+		{Limits{MaxMemory: 1024}, `let h = {1: strings.repeat("a", 600)}; len(delete(h, 1))`, 600},
+		{
+			Limits{MaxMemory: 1024},
+			`let h = {1: 1, 2: 2, 3: 3, 4: 4, 5: 5, 6: 6, 7: 7, 8: 8, 9: 9, 10: 10, 11: 11, 12: 12, 13: 13, 14: 14};` +
+				` values(h); keys(h)`,
+			errors.New("memory limit of 1024 bytes exceeded"),
+		},
+		{Limits{Timeout: time.Minute}, `time.sleep(time.second); 1`, 1},
+		{Limits{Timeout: time.Minute}, `time.sleep(time.hour); 1`, errors.New("time limit of 1m0s exceeded")},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			clock := &fakeClock{now: time.Unix(0, 0)}
+			cfg := Config{Clock: clock, Limits: tc.limits}
+			evaluated := testEvalWithConfig(tc.input, cfg)
+			if expected, ok := tc.expected.(inspected); ok {
+				require.Equal(t, string(expected), evaluated.Inspect())
+				return
+			}
+			testExpectedObject(t, evaluated, tc.expected)
+		})
+	}
+}
+
+func TestFSReadIsCharged(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "big.txt"), []byte(strings.Repeat("a", 4096)), 0o600))
+
+	cfg := Config{FS: &FSConfig{Roots: []string{root}}, Limits: Limits{MaxMemory: 1024}}
+	testExpectedObject(t, testEvalWithConfig(`fs.read("big.txt")`, cfg),
+		errors.New("memory limit of 1024 bytes exceeded"))
+
+	cfg.Limits.MaxMemory = 8192
+	testExpectedObject(t, testEvalWithConfig(`len(fs.read("big.txt"))`, cfg), 4096)
+}
+
+func TestAllocChecksDeadline(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	l := &limiter{limits: Limits{Timeout: time.Minute}, clock: clock}
+
+	require.NoError(t, l.Alloc(8))
+	clock.Sleep(time.Hour)
+	require.EqualError(t, l.Alloc(8), "time limit of 1m0s exceeded")
+}
+
+func TestPanicsBecomeErrors(t *testing.T) {
+	env := NewEnvironment(Config{})
+	env.Set("boom", &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			panic("boom")
+		},
+	})
+
+	program := parser.New(lexer.New(`let x = 1; boom(); x`)).ParseProgram()
+	testExpectedObject(t, Eval(program, env), errors.New("internal error: boom"))
+}
+
+func TestHTTPBodyIsCharged(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("a", 4096)))
+	}))
+	defer server.Close()
+
+	cfg := Config{HTTP: http.DefaultTransport, Limits: Limits{MaxMemory: 1024}}
+	evaluated := testEvalWithConfig(`http.get("`+server.URL+`")`, cfg)
+	testExpectedObject(t, evaluated, errors.New("request failed: memory limit of 1024 bytes exceeded"))
+}
+
+func TestTimeoutShortensSleep(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	cfg := Config{Clock: clock, Limits: Limits{Timeout: time.Minute}}
+
+	testEvalWithConfig(`time.sleep(time.hour)`, cfg)
+	require.Equal(t, time.Unix(60, 0), clock.now)
+}
@@ -0,0 +1,110 @@
+package evaluator
+
+import (
+	"fmt"
+
+	"github.com/vancanhuit/monkey/internal/ast"
+	"github.com/vancanhuit/monkey/internal/object"
+)
+
+// maxExpansionDepth bounds how many times the code produced by a macro may
+// itself be expanded, so that a macro expanding to a call to itself fails
+// instead of running forever.
+const maxExpansionDepth = 100
+
+// DefineMacros removes the top-level statements of the form
+// "let name = macro(...) { ... }" from program and binds each macro in env.
+func DefineMacros(program *ast.Program, env *object.Environment) {
+	statements := program.Statements[:0]
+	for _, stmt := range program.Statements {
+		let, ok := stmt.(*ast.LetStatement)
+		if !ok {
+			statements = append(statements, stmt)
+			continue
+		}
+		literal, ok := let.Value.(*ast.MacroLiteral)
+		if !ok {
+			statements = append(statements, stmt)
+			continue
+		}
+		env.Set(let.Name.Value, &object.Macro{
+			Parameters: literal.Parameters,
+			Body:       literal.Body,
+			Env:        env,
+		})
+	}
+	program.Statements = statements
+}
+
+// ExpandMacros replaces every call to a macro bound in env with the code
+// the macro returns for the call's unevaluated arguments. The returned
+// code is expanded in turn.
+func ExpandMacros(program ast.Node, env *object.Environment) (ast.Node, error) {
+	return expandMacros(program, env, 0)
+}
+
+func expandMacros(node ast.Node, env *object.Environment, depth int) (ast.Node, error) {
+	var err error
+	expanded := ast.Modify(node, func(node ast.Node) ast.Node {
+		if err != nil {
+			return node
+		}
+		call, ok := node.(*ast.CallExpression)
+		if !ok {
+			return node
+		}
+		macro, name, ok := macroCall(call, env)
+		if !ok {
+			return node
+		}
+		if depth == maxExpansionDepth {
+			err = fmt.Errorf("macro %s: expansion is nested more than %d levels deep", name, maxExpansionDepth)
+			return node
+		}
+
+		code, macroErr := applyMacro(macro, call.Arguments)
+		if macroErr != nil {
+			err = fmt.Errorf("macro %s: %s", name, macroErr.Message)
+			return node
+		}
+		code, err = expandMacros(code, env, depth+1)
+		return code
+	})
+	return expanded, err
+}
+
+func macroCall(call *ast.CallExpression, env *object.Environment) (*object.Macro, string, bool) {
+	ident, ok := call.Function.(*ast.Identifier)
+	if !ok {
+		return nil, "", false
+	}
+	obj, ok := env.Get(ident.Value)
+	if !ok {
+		return nil, "", false
+	}
+	macro, ok := obj.(*object.Macro)
+	return macro, ident.Value, ok
+}
+
+func applyMacro(macro *object.Macro, args []ast.Expression) (ast.Node, *object.Error) {
+	if len(args) != len(macro.Parameters) {
+		return nil, newError("wrong number of arguments. got=%d, want=%d", len(args), len(macro.Parameters))
+	}
+
+	env := object.NewEnclosedEnvironment(macro.Env)
+	for i, p := range macro.Parameters {
+		env.Set(p.Value, &object.Quote{Node: args[i]})
+	}
+
+	result := unwrapReturnValue(Eval(macro.Body, env))
+	switch result := result.(type) {
+	case *object.Quote:
+		return result.Node, nil
+	case *object.Error:
+		return nil, result
+	case nil:
+		return nil, newError("macros must return quoted code, got nothing")
+	default:
+		return nil, newError("macros must return quoted code, got %s", result.Type())
+	}
+}
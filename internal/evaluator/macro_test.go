@@ -0,0 +1,193 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vancanhuit/monkey/internal/ast"
+	"github.com/vancanhuit/monkey/internal/lexer"
+	"github.com/vancanhuit/monkey/internal/object"
+	"github.com/vancanhuit/monkey/internal/parser"
+)
+
+func testParseProgram(t *testing.T, input string) *ast.Program {
+	t.Helper()
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	require.Empty(t, p.Errors())
+	return program
+}
+
+func TestDefineMacros(t *testing.T) {
+	input := `
+	let number = 1;
+	let function = fn(x, y) { x + y };
+	let mymacro = macro(x, y) { x + y; };
+	`
+
+	env := object.NewEnvironment()
+	program := testParseProgram(t, input)
+
+	DefineMacros(program, env)
+
+	require.Len(t, program.Statements, 2)
+	_, ok := env.Get("number")
+	require.False(t, ok)
+	_, ok = env.Get("function")
+	require.False(t, ok)
+
+	obj, ok := env.Get("mymacro")
+	require.True(t, ok)
+	macro, ok := obj.(*object.Macro)
+	require.True(t, ok)
+	require.Len(t, macro.Parameters, 2)
+	require.Equal(t, "x", macro.Parameters[0].String())
+	require.Equal(t, "y", macro.Parameters[1].String())
+	require.Equal(t, "(x + y)", macro.Body.String())
+}
+
+func TestExpandMacros(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`let infixExpression = macro() { quote(1 + 2); };
+			infixExpression();`,
+			`(1 + 2)`,
+		},
+		{
+			`let reverse = macro(a, b) { quote(unquote(b) - unquote(a)); };
+			reverse(2 + 2, 10 - 5);`,
+			`(10 - 5) - (2 + 2)`,
+		},
+		{
+			`let unless = macro(condition, consequence, alternative) {
+				quote(if (!(unquote(condition))) {
+					unquote(consequence);
+				} else {
+					unquote(alternative);
+				});
+			};
+			unless(10 > 5, puts("not greater"), puts("greater"));`,
+			`if (!(10 > 5)) { puts("not greater") } else { puts("greater") }`,
+		},
+		{
+			`let twice = macro(x) { quote([unquote(x), unquote(x)]) };
+			let quad = macro(x) { quote(twice(twice(unquote(x)))) };
+			quad(a)`,
+			`[[a, a], [a, a]]`,
+		},
+		{
+			`let id = macro(x) { x };
+			let f = fn() { id(id(1)) + 1 };`,
+			`let f = fn() { 1 + 1 };`,
+		},
+		{
+			`let build = macro(n) { let v = 0; quote(unquote(v) + unquote(n)) };
+			build(n)`,
+			`0 + n`,
+		},
+	}
+
+	for _, tc := range testCases {
+		expected := testParseProgram(t, tc.expected)
+		program := testParseProgram(t, tc.input)
+
+		env := object.NewEnvironment()
+		DefineMacros(program, env)
+		expanded, err := ExpandMacros(program, env)
+		require.NoError(t, err)
+		require.Equal(t, expected.String(), expanded.String(), tc.input)
+	}
+}
+
+func TestExpandMacrosErrors(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`let m = macro(x) { x }; m()`,
+			"macro m: wrong number of arguments. got=0, want=1",
+		},
+		{
+			`let m = macro() { 1 }; m()`,
+			"macro m: macros must return quoted code, got INTEGER",
+		},
+		{
+			`let m = macro() { let x = 1; }; m()`,
+			"macro m: macros must return quoted code, got nothing",
+		},
+		{
+			`let m = macro() { quote(unquote(y)) }; m()`,
+			"macro m: identifier not found: y",
+		},
+		{
+			`let m = macro(x) { return quote(unquote(x) + 1); }; f(m(undefined(1)), m(2, 3))`,
+			"macro m: wrong number of arguments. got=2, want=1",
+		},
+		{
+			`let loop = macro() { quote(loop()) }; loop()`,
+			"macro loop: expansion is nested more than 100 levels deep",
+		},
+	}
+
+	for _, tc := range testCases {
+		program := testParseProgram(t, tc.input)
+		env := object.NewEnvironment()
+		DefineMacros(program, env)
+		_, err := ExpandMacros(program, env)
+		require.EqualError(t, err, tc.expected, tc.input)
+	}
+}
+
+func TestMacroProgram(t *testing.T) {
+	input := `
+	let assert = macro(condition, message) {
+		quote(if (!(unquote(condition))) { "assertion failed: " + unquote(message) } else { true })
+	};
+	let unless = macro(cond, body) { quote(if (!(unquote(cond))) { unquote(body) }) };
+	let x = 5;
+	[assert(x > 10, "x is big"), assert(x == 5, "x is five"), unless(x > 10, x * 2), unless(x < 10, x)]
+	`
+
+	program := testParseProgram(t, input)
+	macros := object.NewEnvironment()
+	DefineMacros(program, macros)
+	expanded, err := ExpandMacros(program, macros)
+	require.NoError(t, err)
+
+	result := Eval(expanded, object.NewEnvironment())
+	array, ok := result.(*object.Array)
+	require.True(t, ok, result.Inspect())
+	require.Equal(t, "[assertion failed: x is big, true, 10, null]", array.Inspect())
+}
+
+func TestMacroEnvironment(t *testing.T) {
+	root := t.TempDir()
+	cfg := Config{FS: &FSConfig{Roots: []string{root}}, Limits: Limits{MaxSteps: 1000}}
+
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`let spin = macro() { let loop = fn() { loop() }; loop() }; spin()`,
+			"macro spin: step limit of 1000 exceeded",
+		},
+		{
+			`let m = macro() { fs.exists("x"); quote(1) }; m()`,
+			"macro m: identifier not found: fs",
+		},
+	}
+
+	for _, tc := range testCases {
+		program := testParseProgram(t, tc.input)
+		env := NewMacroEnvironment(cfg)
+		DefineMacros(program, env)
+		_, err := ExpandMacros(program, env)
+		require.EqualError(t, err, tc.expected, tc.input)
+	}
+}
@@ -0,0 +1,90 @@
+package evaluator
+
+import (
+	"fmt"
+
+	"github.com/vancanhuit/monkey/internal/ast"
+	"github.com/vancanhuit/monkey/internal/object"
+	"github.com/vancanhuit/monkey/internal/token"
+)
+
+// quote returns node unevaluated, except for the calls to unquote inside
+// it, which are replaced by the code for the values of their arguments.
+// The node is copied first so that quoting the same code twice, as a
+// function does on every call, sees the original unquote calls each time.
+func quote(node ast.Node, env *object.Environment) object.Object {
+	node, err := evalUnquoteCalls(ast.Copy(node), env)
+	if err != nil {
+		return err
+	}
+	return &object.Quote{Node: node}
+}
+
+func evalUnquoteCalls(quoted ast.Node, env *object.Environment) (ast.Node, *object.Error) {
+	var err *object.Error
+	node := ast.Modify(quoted, func(node ast.Node) ast.Node {
+		call, ok := node.(*ast.CallExpression)
+		if err != nil || !ok || !isCallTo(call, "unquote") {
+			return node
+		}
+		if len(call.Arguments) != 1 {
+			err = newError("wrong number of arguments. got=%d, want=1", len(call.Arguments))
+			return node
+		}
+
+		value := Eval(call.Arguments[0], env)
+		if isError(value) {
+			err = value.(*object.Error)
+			return node
+		}
+		converted, convertErr := convertObjectToASTNode(value)
+		if convertErr != nil {
+			err = convertErr
+			return node
+		}
+		return converted
+	})
+	return node, err
+}
+
+func isCallTo(call *ast.CallExpression, name string) bool {
+	ident, ok := call.Function.(*ast.Identifier)
+	return ok && ident.Value == name
+}
+
+// convertObjectToASTNode returns code that evaluates to obj. Quoted code
+// is spliced in as it is.
+func convertObjectToASTNode(obj object.Object) (ast.Node, *object.Error) {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		t := token.Token{Type: token.Integer, Literal: fmt.Sprintf("%d", obj.Value)}
+		return &ast.IntegerLiteral{Token: t, Value: obj.Value}, nil
+	case *object.Float:
+		t := token.Token{Type: token.Float, Literal: obj.Inspect()}
+		return &ast.FloatLiteral{Token: t, Value: obj.Value}, nil
+	case *object.String:
+		t := token.Token{Type: token.String, Literal: obj.Value}
+		return &ast.StringLiteral{Token: t, Value: obj.Value}, nil
+	case *object.Boolean:
+		t := token.Token{Type: token.False, Literal: "false"}
+		if obj.Value {
+			t = token.Token{Type: token.True, Literal: "true"}
+		}
+		return &ast.Boolean{Token: t, Value: obj.Value}, nil
+	case *object.Array:
+		elements := make([]ast.Expression, len(obj.Elements))
+		for i, e := range obj.Elements {
+			node, err := convertObjectToASTNode(e)
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = node.(ast.Expression)
+		}
+		t := token.Token{Type: token.LeftBracket, Literal: "["}
+		return &ast.ArrayLiteral{Token: t, Elements: elements}, nil
+	case *object.Quote:
+		return obj.Node, nil
+	default:
+		return nil, newError("cannot unquote %s", obj.Type())
+	}
+}
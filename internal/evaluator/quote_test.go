@@ -0,0 +1,82 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vancanhuit/monkey/internal/object"
+)
+
+func TestQuote(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{`quote(5)`, `5`},
+		{`quote(5 + 8)`, `(5 + 8)`},
+		{`quote(foobar)`, `foobar`},
+		{`quote(foobar + barfoo)`, `(foobar + barfoo)`},
+		{`quote(fn(x) { x })`, `fn(x)x`},
+	}
+
+	for _, tc := range testCases {
+		quote, ok := testEval(tc.input).(*object.Quote)
+		require.True(t, ok, tc.input)
+		require.Equal(t, tc.expected, quote.Node.String())
+	}
+}
+
+func TestQuoteUnquote(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{`quote(unquote(4))`, `4`},
+		{`quote(unquote(4 + 4))`, `8`},
+		{`quote(8 + unquote(4 + 4))`, `(8 + 8)`},
+		{`quote(unquote(4 + 4) + 8)`, `(8 + 8)`},
+		{`let foobar = 8; quote(foobar)`, `foobar`},
+		{`let foobar = 8; quote(unquote(foobar))`, `8`},
+		{`quote(unquote(true))`, `true`},
+		{`quote(unquote(true == false))`, `false`},
+		{`quote(unquote(1.5 * 2.0))`, `3.0`},
+		{`quote(unquote("a" + "b"))`, `ab`},
+		{`quote(unquote([1, 2 * 3, "x"]))`, `[1, 6, x]`},
+		{`quote(unquote(quote(4 + 4)))`, `(4 + 4)`},
+		{
+			`let quotedInfixExpression = quote(4 + 4);
+			quote(unquote(4 + 4) + unquote(quotedInfixExpression))`,
+			`(8 + (4 + 4))`,
+		},
+		{
+			`let f = fn(x) { quote(unquote(x) * 2) }; f(1); f(2)`,
+			`(2 * 2)`,
+		},
+	}
+
+	for _, tc := range testCases {
+		quote, ok := testEval(tc.input).(*object.Quote)
+		require.True(t, ok, tc.input)
+		require.Equal(t, tc.expected, quote.Node.String(), tc.input)
+	}
+}
+
+func TestQuoteErrors(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{`quote()`, "wrong number of arguments. got=0, want=1"},
+		{`quote(1, 2)`, "wrong number of arguments. got=2, want=1"},
+		{`quote(unquote())`, "wrong number of arguments. got=0, want=1"},
+		{`quote(unquote(x))`, "identifier not found: x"},
+		{`quote(unquote(puts()))`, "cannot unquote NULL"},
+		{`quote(unquote([fn(x) { x }]))`, "cannot unquote FUNCTION"},
+		{`unquote(1)`, "identifier not found: unquote"},
+		{`let m = [macro(x) { x }];`, "macros can only be defined by a top-level let statement"},
+	}
+
+	for _, tc := range testCases {
+		testExpectedObject(t, testEval(tc.input), newError("%s", tc.expected))
+	}
+}
@@ -0,0 +1,460 @@
+package evaluator
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vancanhuit/monkey/internal/ast"
+	"github.com/vancanhuit/monkey/internal/lexer"
+	"github.com/vancanhuit/monkey/internal/object"
+	"github.com/vancanhuit/monkey/internal/parser"
+	"github.com/vancanhuit/monkey/internal/printer"
+	"github.com/vancanhuit/monkey/internal/token"
+)
+
+const snapshotVersion = 1
+
+// snapshot is the JSON form of an environment and every value reachable
+// from it. Arrays, hashes and functions are stored once in Objects and
+// referred to by index, so that sharing and cycles survive a round trip.
+// Environments are ordered so that each one comes after its outer one,
+// starting with the saved environment itself. Macros, if present, is the
+// index of the environment holding the saved macros.
+type snapshot struct {
+	Version      int              `json:"version"`
+	Environments []snapshotEnv    `json:"environments"`
+	Objects      []snapshotObject `json:"objects"`
+	Macros       *int             `json:"macros,omitempty"`
+}
+
+type snapshotEnv struct {
+	Outer    *int                     `json:"outer"`
+	Bindings map[string]snapshotValue `json:"bindings"`
+}
+
+type snapshotObject struct {
+	Kind     string             `json:"kind"`
+	Elements []snapshotValue    `json:"elements,omitempty"`
+	Pairs    [][2]snapshotValue `json:"pairs,omitempty"`
+	Source   string             `json:"source,omitempty"`
+	Env      int                `json:"env,omitempty"`
+}
+
+// snapshotValue holds exactly one of its fields. Floats are stored as
+// strings so that infinities and NaN can be represented.
+type snapshotValue struct {
+	Integer *int64  `json:"integer,omitempty"`
+	Float   *string `json:"float,omitempty"`
+	String  *string `json:"string,omitempty"`
+	Boolean *bool   `json:"boolean,omitempty"`
+	Null    bool    `json:"null,omitempty"`
+	Time    *string `json:"time,omitempty"`
+	Builtin string  `json:"builtin,omitempty"`
+	Module  string  `json:"module,omitempty"`
+	Ref     *int    `json:"ref,omitempty"`
+}
+
+// SaveEnvironment serializes the bindings of env, along with the values
+// they refer to, as JSON. Functions are saved as source code together with
+// the environments they closed over, so they remain callable once restored
+// by RestoreEnvironment. Builtins and modules are saved by name. If macros
+// is not nil, the macros defined in it are saved as source code too.
+func SaveEnvironment(env, macros *object.Environment) ([]byte, error) {
+	w := &snapshotWriter{
+		envs:     make(map[*object.Environment]int),
+		objects:  make(map[object.Object]int),
+		builtins: make(map[*object.Builtin]string),
+		snap:     snapshot{Version: snapshotVersion},
+	}
+	for name, b := range builtins {
+		w.builtins[b] = name
+	}
+	for _, m := range modules {
+		w.addModule(m)
+	}
+	for _, name := range env.Names() {
+		if value, _ := env.Get(name); value != nil {
+			if m, ok := value.(*object.Module); ok {
+				w.addModule(m)
+			}
+		}
+	}
+
+	if _, err := w.env(env); err != nil {
+		return nil, err
+	}
+	if macros != nil {
+		i, err := w.env(macros)
+		if err != nil {
+			return nil, err
+		}
+		w.snap.Macros = &i
+	}
+	return json.MarshalIndent(w.snap, "", "  ")
+}
+
+type snapshotWriter struct {
+	envs     map[*object.Environment]int
+	objects  map[object.Object]int
+	builtins map[*object.Builtin]string
+	snap     snapshot
+}
+
+func (w *snapshotWriter) addModule(m *object.Module) {
+	for name, member := range m.Members {
+		if b, ok := member.(*object.Builtin); ok {
+			w.builtins[b] = m.Name + "." + name
+		}
+	}
+}
+
+func (w *snapshotWriter) env(env *object.Environment) (int, error) {
+	if i, ok := w.envs[env]; ok {
+		return i, nil
+	}
+
+	var outer *int
+	if env.Outer() != nil {
+		i, err := w.env(env.Outer())
+		if err != nil {
+			return 0, err
+		}
+		outer = &i
+	}
+
+	i := len(w.snap.Environments)
+	w.envs[env] = i
+	w.snap.Environments = append(w.snap.Environments, snapshotEnv{Outer: outer})
+
+	bindings := make(map[string]snapshotValue)
+	for _, name := range env.Names() {
+		value, _ := env.Get(name)
+		v, err := w.value(value)
+		if err != nil {
+			return 0, fmt.Errorf("cannot save %s: %w", name, err)
+		}
+		bindings[name] = v
+	}
+	w.snap.Environments[i].Bindings = bindings
+	return i, nil
+}
+
+func (w *snapshotWriter) value(obj object.Object) (snapshotValue, error) {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return snapshotValue{Integer: &obj.Value}, nil
+	case *object.Float:
+		s := strconv.FormatFloat(obj.Value, 'g', -1, 64)
+		return snapshotValue{Float: &s}, nil
+	case *object.String:
+		return snapshotValue{String: &obj.Value}, nil
+	case *object.Boolean:
+		return snapshotValue{Boolean: &obj.Value}, nil
+	case *object.Null:
+		return snapshotValue{Null: true}, nil
+	case *object.Time:
+		s := obj.Value.Format(time.RFC3339Nano)
+		return snapshotValue{Time: &s}, nil
+	case *object.Module:
+		return snapshotValue{Module: obj.Name}, nil
+	case *object.Builtin:
+		name, ok := w.builtins[obj]
+		if !ok {
+			return snapshotValue{}, fmt.Errorf("unknown builtin")
+		}
+		return snapshotValue{Builtin: name}, nil
+	case *object.Array, *object.Hash, *object.Function, *object.Macro:
+		i, err := w.object(obj)
+		if err != nil {
+			return snapshotValue{}, err
+		}
+		return snapshotValue{Ref: &i}, nil
+	}
+	return snapshotValue{}, fmt.Errorf("%s values cannot be saved", obj.Type())
+}
+
+func (w *snapshotWriter) object(obj object.Object) (int, error) {
+	if i, ok := w.objects[obj]; ok {
+		return i, nil
+	}
+
+	i := len(w.snap.Objects)
+	w.objects[obj] = i
+	w.snap.Objects = append(w.snap.Objects, snapshotObject{})
+
+	var saved snapshotObject
+	switch obj := obj.(type) {
+	case *object.Array:
+		saved.Kind = "array"
+		for _, elem := range obj.Elements {
+			v, err := w.value(elem)
+			if err != nil {
+				return 0, err
+			}
+			saved.Elements = append(saved.Elements, v)
+		}
+	case *object.Hash:
+		saved.Kind = "hash"
+		for _, pair := range obj.Pairs() {
+			key, err := w.value(pair.Key)
+			if err != nil {
+				return 0, err
+			}
+			value, err := w.value(pair.Value)
+			if err != nil {
+				return 0, err
+			}
+			saved.Pairs = append(saved.Pairs, [2]snapshotValue{key, value})
+		}
+	case *object.Function:
+		saved.Kind = "function"
+		saved.Source = printer.Print(&ast.FunctionLiteral{
+			Token:          token.Token{Type: token.Function, Literal: "fn"},
+			Parameters:     obj.Parameters,
+			ParameterTypes: obj.ParameterTypes,
+			ReturnType:     obj.ReturnType,
+			Body:           obj.Body,
+		})
+		env, err := w.env(obj.Env)
+		if err != nil {
+			return 0, err
+		}
+		saved.Env = env
+	case *object.Macro:
+		saved.Kind = "macro"
+		saved.Source = printer.Print(&ast.MacroLiteral{
+			Token:      token.Token{Type: token.Macro, Literal: "macro"},
+			Parameters: obj.Parameters,
+			Body:       obj.Body,
+		})
+		env, err := w.env(obj.Env)
+		if err != nil {
+			return 0, err
+		}
+		saved.Env = env
+	}
+	w.snap.Objects[i] = saved
+	return i, nil
+}
+
+// RestoreEnvironment binds in env the values saved by SaveEnvironment, and
+// in macros, if it is not nil, the saved macros. Builtins and modules are
+// looked up by name in env and among the globals, so env should be set up
+// like the environment that was saved.
+func RestoreEnvironment(data []byte, env, macros *object.Environment) error {
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("invalid snapshot: %w", err)
+	}
+	if snap.Version != snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d", snap.Version)
+	}
+	if len(snap.Environments) == 0 {
+		return fmt.Errorf("invalid snapshot: no environment")
+	}
+
+	r := &snapshotReader{root: env, objects: make([]object.Object, len(snap.Objects))}
+	for i, saved := range snap.Environments {
+		switch {
+		case i == 0:
+			r.envs = append(r.envs, env)
+		case macros != nil && snap.Macros != nil && i == *snap.Macros && saved.Outer == nil:
+			r.envs = append(r.envs, macros)
+		case saved.Outer == nil:
+			r.envs = append(r.envs, object.NewEnvironment())
+		case *saved.Outer >= 0 && *saved.Outer < i:
+			r.envs = append(r.envs, object.NewEnclosedEnvironment(r.envs[*saved.Outer]))
+		default:
+			return fmt.Errorf("invalid snapshot: environment %d has outer %d", i, *saved.Outer)
+		}
+	}
+
+	// Objects are created empty first, so that references between them
+	// can be resolved whatever their order, then filled in.
+	for i, saved := range snap.Objects {
+		switch saved.Kind {
+		case "array":
+			r.objects[i] = &object.Array{}
+		case "hash":
+			r.objects[i] = object.NewHash()
+		case "function":
+			fn, err := parseFunction(saved.Source)
+			if err != nil {
+				return err
+			}
+			if saved.Env < 0 || saved.Env >= len(r.envs) {
+				return fmt.Errorf("invalid snapshot: function refers to environment %d", saved.Env)
+			}
+			r.objects[i] = &object.Function{
+				Parameters:     fn.Parameters,
+				ParameterTypes: fn.ParameterTypes,
+				ReturnType:     fn.ReturnType,
+				Body:           fn.Body,
+				Env:            r.envs[saved.Env],
+			}
+		case "macro":
+			macro, err := parseMacro(saved.Source)
+			if err != nil {
+				return err
+			}
+			if saved.Env < 0 || saved.Env >= len(r.envs) {
+				return fmt.Errorf("invalid snapshot: macro refers to environment %d", saved.Env)
+			}
+			r.objects[i] = &object.Macro{
+				Parameters: macro.Parameters,
+				Body:       macro.Body,
+				Env:        r.envs[saved.Env],
+			}
+		default:
+			return fmt.Errorf("invalid snapshot: unknown object kind %q", saved.Kind)
+		}
+	}
+	// Arrays are filled in before any hash, since an array used as a hash
+	// key is hashed by its elements when the pair is set.
+	for i, saved := range snap.Objects {
+		if arr, ok := r.objects[i].(*object.Array); ok {
+			for _, elem := range saved.Elements {
+				value, err := r.value(elem)
+				if err != nil {
+					return err
+				}
+				arr.Elements = append(arr.Elements, value)
+			}
+		}
+	}
+	for i, saved := range snap.Objects {
+		if hash, ok := r.objects[i].(*object.Hash); ok {
+			for _, pair := range saved.Pairs {
+				key, err := r.value(pair[0])
+				if err != nil {
+					return err
+				}
+				value, err := r.value(pair[1])
+				if err != nil {
+					return err
+				}
+				if !hash.Set(key, value) {
+					return fmt.Errorf("invalid snapshot: unusable as hash key: %s", key.Type())
+				}
+			}
+		}
+	}
+
+	// Bindings are resolved before any is set, since modules saved by name
+	// are looked up in env.
+	resolved := make([]map[string]object.Object, len(snap.Environments))
+	for i, saved := range snap.Environments {
+		resolved[i] = make(map[string]object.Object, len(saved.Bindings))
+		for name, v := range saved.Bindings {
+			value, err := r.value(v)
+			if err != nil {
+				return fmt.Errorf("cannot restore %s: %w", name, err)
+			}
+			resolved[i][name] = value
+		}
+	}
+	for i, bindings := range resolved {
+		for name, value := range bindings {
+			r.envs[i].Set(name, value)
+		}
+	}
+	return nil
+}
+
+type snapshotReader struct {
+	root    *object.Environment
+	envs    []*object.Environment
+	objects []object.Object
+}
+
+func (r *snapshotReader) value(v snapshotValue) (object.Object, error) {
+	switch {
+	case v.Integer != nil:
+		return &object.Integer{Value: *v.Integer}, nil
+	case v.Float != nil:
+		f, err := strconv.ParseFloat(*v.Float, 64)
+		if err != nil && !math.IsInf(f, 0) {
+			return nil, fmt.Errorf("invalid float %q", *v.Float)
+		}
+		return &object.Float{Value: f}, nil
+	case v.String != nil:
+		return &object.String{Value: *v.String}, nil
+	case v.Boolean != nil:
+		return nativeBoolToBooleanObject(*v.Boolean), nil
+	case v.Null:
+		return Null, nil
+	case v.Time != nil:
+		t, err := time.Parse(time.RFC3339Nano, *v.Time)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time %q", *v.Time)
+		}
+		return &object.Time{Value: t}, nil
+	case v.Module != "":
+		return r.module(v.Module)
+	case v.Builtin != "":
+		dot := strings.LastIndexByte(v.Builtin, '.')
+		if dot < 0 {
+			if b, ok := builtins[v.Builtin]; ok {
+				return b, nil
+			}
+			return nil, fmt.Errorf("unknown builtin %s", v.Builtin)
+		}
+		m, err := r.module(v.Builtin[:dot])
+		if err != nil {
+			return nil, err
+		}
+		member, ok := m.Members[v.Builtin[dot+1:]]
+		if !ok {
+			return nil, fmt.Errorf("unknown builtin %s", v.Builtin)
+		}
+		return member, nil
+	case v.Ref != nil:
+		if *v.Ref < 0 || *v.Ref >= len(r.objects) {
+			return nil, fmt.Errorf("invalid reference %d", *v.Ref)
+		}
+		return r.objects[*v.Ref], nil
+	}
+	return nil, fmt.Errorf("empty value")
+}
+
+func (r *snapshotReader) module(name string) (*object.Module, error) {
+	if value, ok := r.root.Get(name); ok {
+		if m, ok := value.(*object.Module); ok && m.Name == name {
+			return m, nil
+		}
+	}
+	if m, ok := modules[name]; ok {
+		return m, nil
+	}
+	return nil, fmt.Errorf("module %s is not available", name)
+}
+
+func parseFunction(source string) (*ast.FunctionLiteral, error) {
+	if fn, ok := parseLiteral(source).(*ast.FunctionLiteral); ok {
+		return fn, nil
+	}
+	return nil, fmt.Errorf("invalid snapshot: cannot parse function %q", source)
+}
+
+func parseMacro(source string) (*ast.MacroLiteral, error) {
+	if macro, ok := parseLiteral(source).(*ast.MacroLiteral); ok {
+		return macro, nil
+	}
+	return nil, fmt.Errorf("invalid snapshot: cannot parse macro %q", source)
+}
+
+// parseLiteral returns the expression source consists of, or nil.
+func parseLiteral(source string) ast.Expression {
+	p := parser.New(lexer.New(source))
+	program := p.ParseProgram()
+	if len(p.Errors()) == 0 && len(program.Statements) == 1 {
+		if stmt, ok := program.Statements[0].(*ast.ExpressionStatement); ok {
+			return stmt.Expression
+		}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package evaluator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vancanhuit/monkey/internal/lexer"
+	"github.com/vancanhuit/monkey/internal/object"
+	"github.com/vancanhuit/monkey/internal/parser"
+)
+
+func evalIn(t *testing.T, env *object.Environment, input string) object.Object {
+	t.Helper()
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	require.Empty(t, p.Errors())
+	return Eval(program, env)
+}
+
+func TestSaveAndRestoreEnvironment(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, time.March, 9, 12, 30, 0, 0, time.UTC)}
+	cfg := Config{Clock: clock}
+
+	saved := NewEnvironment(cfg)
+	evalIn(t, saved, `
+		let n = 42;
+		let f = 2.5;
+		let nan = 0.0 / 0.0;
+		let s = "a \"quoted\"\n string";
+		let b = true;
+		let nothing = puts();
+		let started = time.now();
+		let shared = [1, 2];
+		let pair = [shared, shared];
+		let cyclic = [0];
+		append!(cyclic, cyclic);
+		let h = {"k": [1, {"x": 2}], 3: "three", true: shared};
+		let composite = {[1, [2, 3]]: "nested", [1, 2]: 3, [true, "a"]: "mixed"};
+		let fib = fn(n) { if (n < 2) { return n; }; fib(n - 1) + fib(n - 2) };
+		let makeAdder = fn(x) { fn(y) { x + y } };
+		let add5 = makeAdder(5);
+		let counter = fn() { let count = [0]; fn() { count[0] = count[0] + 1 } }();
+		counter(); counter();
+		let size = len;
+		let parse = json.parse;
+		let now = time.now;
+		let t = time;
+	`)
+
+	data, err := SaveEnvironment(saved, nil)
+	require.NoError(t, err)
+
+	clock.Sleep(time.Hour)
+	restored := NewEnvironment(cfg)
+	require.NoError(t, RestoreEnvironment(data, restored, nil))
+
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`n`, 42},
+		{`f`, 2.5},
+		{`nan == nan`, false},
+		{`s`, "a \"quoted\"\n string"},
+		{`b`, true},
+		{`nothing`, nil},
+		{`time.format(started, "rfc3339")`, "2024-03-09T12:30:00Z"},
+		{`pair[0][0] = 10; shared[0]`, 10},
+		{`cyclic[1][1][1][0]`, 0},
+		{`h["k"][1]["x"] + len(h[true])`, 4},
+		{`keys(h)[1]`, 3},
+		{`composite[[1, [2, 3]]]`, "nested"},
+		{`composite[[1, 2]]`, 3},
+		{`composite[[true, "a"]]`, "mixed"},
+		{`keys(composite)[0][1][1]`, 3},
+		{`fib(15)`, 610},
+		{`add5(10)`, 15},
+		{`makeAdder(1)(1)`, 2},
+		{`counter()`, 3},
+		{`size("four")`, 4},
+		{`parse("[1]")[0]`, 1},
+		{`time.since(now())`, 0},
+		{`t.unix(t.now()) - t.unix(started)`, 3600},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			testExpectedObject(t, evalIn(t, restored, tc.input), tc.expected)
+		})
+	}
+}
+
+func TestSaveAndRestoreMacros(t *testing.T) {
+	env, macros := NewEnvironment(Config{}), object.NewEnvironment()
+	program := parser.New(lexer.New(`
+		let unless = macro(c, x) { quote(if (!(unquote(c))) { unquote(x) }) };
+		let n = 7;
+	`)).ParseProgram()
+	DefineMacros(program, macros)
+	Eval(program, env)
+
+	data, err := SaveEnvironment(env, macros)
+	require.NoError(t, err)
+
+	restored, restoredMacros := NewEnvironment(Config{}), object.NewEnvironment()
+	require.NoError(t, RestoreEnvironment(data, restored, restoredMacros))
+	_, ok := restored.Get("unless")
+	require.False(t, ok)
+
+	expanded, err := ExpandMacros(parser.New(lexer.New(`unless(false, n)`)).ParseProgram(), restoredMacros)
+	require.NoError(t, err)
+	testExpectedObject(t, Eval(expanded, restored), 7)
+
+	// Without an environment for them, saved macros are ignored.
+	require.NoError(t, RestoreEnvironment(data, NewEnvironment(Config{}), nil))
+}
+
+func TestSaveEnvironmentErrors(t *testing.T) {
+	env := object.NewEnvironment()
+	env.Set("err", &object.Error{Message: "boom"})
+	_, err := SaveEnvironment(env, nil)
+	require.EqualError(t, err, "cannot save err: ERROR values cannot be saved")
+
+	env = object.NewEnvironment()
+	env.Set("anonymous", &object.Builtin{Fn: func(...object.Object) object.Object { return Null }})
+	_, err = SaveEnvironment(env, nil)
+	require.EqualError(t, err, "cannot save anonymous: unknown builtin")
+}
+
+func TestRestoreEnvironmentErrors(t *testing.T) {
+	testCases := []struct {
+		data     string
+		expected string
+	}{
+		{`{`, "invalid snapshot: unexpected end of JSON input"},
+		{`{"version": 2}`, "unsupported snapshot version 2"},
+		{`{"version": 1, "environments": []}`, "invalid snapshot: no environment"},
+		{
+			`{"version": 1, "environments": [{"bindings": {"x": {"ref": 3}}}]}`,
+			"cannot restore x: invalid reference 3",
+		},
+		{
+			`{"version": 1, "environments": [{"bindings": {"x": {"builtin": "fs.read"}}}]}`,
+			"cannot restore x: module fs is not available",
+		},
+		{
+			`{"version": 1, "environments": [{"bindings": {}}], "objects": [{"kind": "function", "source": "1 +"}]}`,
+			`invalid snapshot: cannot parse function "1 +"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.data, func(t *testing.T) {
+			err := RestoreEnvironment([]byte(tc.data), object.NewEnvironment(), nil)
+			require.EqualError(t, err, tc.expected)
+		})
+	}
+}
@@ -1,24 +1,47 @@
 package lexer
 
-import "github.com/vancanhuit/monkey/internal/token"
+import (
+	"strings"
+
+	"github.com/vancanhuit/monkey/internal/token"
+)
 
 type Lexer struct {
 	input        string
 	position     int
 	readPosition int
 	ch           byte
+	line         int
+	column       int
 }
 
+// New returns a lexer for input. A leading "#!" line is skipped so that
+// scripts can be made executable.
 func New(input string) *Lexer {
-	l := &Lexer{input: input}
+	l := &Lexer{input: input, line: 1}
+	if strings.HasPrefix(input, "#!") {
+		if i := strings.IndexByte(input, '\n'); i >= 0 {
+			l.readPosition = i
+		} else {
+			l.readPosition = len(input)
+		}
+	}
 	l.readChar()
 	return l
 }
 
+// NextToken returns the next token in the input. Comments are returned as
+// COMMENT tokens holding the text from "//" to the end of the line.
 func (l *Lexer) NextToken() token.Token {
-	var tok token.Token
-
 	l.skipWhitespace()
+	line, column := l.line, l.column
+	tok := l.readToken()
+	tok.Line, tok.Column = line, column
+	return tok
+}
+
+func (l *Lexer) readToken() token.Token {
+	var tok token.Token
 
 	switch l.ch {
 	case '=':
@@ -42,10 +65,19 @@ func (l *Lexer) NextToken() token.Token {
 		tok = newToken(token.RightBrace, l.ch)
 	case ',':
 		tok = newToken(token.Comma, l.ch)
+	case '.':
+		tok = newToken(token.Dot, l.ch)
 	case '+':
 		tok = newToken(token.Plus, l.ch)
 	case '-':
-		tok = newToken(token.Minus, l.ch)
+		if l.peekChar() == '>' {
+			ch := l.ch
+			l.readChar()
+			tok.Literal = string(ch) + string(l.ch)
+			tok.Type = token.Arrow
+		} else {
+			tok = newToken(token.Minus, l.ch)
+		}
 	case '!':
 		if l.peekChar() == '=' {
 			ch := l.ch
@@ -58,6 +90,11 @@ func (l *Lexer) NextToken() token.Token {
 	case '*':
 		tok = newToken(token.Asterisk, l.ch)
 	case '/':
+		if l.peekChar() == '/' {
+			tok.Type = token.Comment
+			tok.Literal = l.readComment()
+			return tok
+		}
 		tok = newToken(token.Slash, l.ch)
 	case '<':
 		tok = newToken(token.LessThan, l.ch)
@@ -81,8 +118,7 @@ func (l *Lexer) NextToken() token.Token {
 			tok.Type = token.LookupIdentifier(tok.Literal)
 			return tok
 		} else if isDigit(l.ch) {
-			tok.Type = token.Integer
-			tok.Literal = l.readNumber()
+			tok.Literal, tok.Type = l.readNumber()
 			return tok
 		} else {
 			tok = newToken(token.Illegal, l.ch)
@@ -93,6 +129,11 @@ func (l *Lexer) NextToken() token.Token {
 }
 
 func (l *Lexer) readChar() {
+	if l.readPosition > 0 && l.position < len(l.input) && l.input[l.position] == '\n' {
+		l.line++
+		l.column = 0
+	}
+	l.column++
 	if l.readPosition >= len(l.input) {
 		l.ch = 0
 	} else {
@@ -132,28 +173,67 @@ func (l *Lexer) skipWhitespace() {
 
 func (l *Lexer) readIdentifier() string {
 	position := l.position
-	for isLetter(l.ch) {
+	for isLetter(l.ch) || isDigit(l.ch) {
+		l.readChar()
+	}
+	// A trailing '!' directly followed by '(' is part of the name, so that
+	// mutating builtins such as append! can be called.
+	if l.ch == '!' && l.peekChar() == '(' {
 		l.readChar()
 	}
 	return l.input[position:l.position]
 }
 
-func (l *Lexer) readNumber() string {
+func (l *Lexer) readComment() string {
+	position := l.position
+	for l.ch != '\n' && l.ch != 0 {
+		l.readChar()
+	}
+	return strings.TrimRight(l.input[position:l.position], "\r")
+}
+
+func (l *Lexer) readNumber() (string, token.TokenType) {
 	position := l.position
 	for isDigit(l.ch) {
 		l.readChar()
 	}
-	return l.input[position:l.position]
+	if l.ch != '.' || !isDigit(l.peekChar()) {
+		return l.input[position:l.position], token.Integer
+	}
+
+	l.readChar()
+	for isDigit(l.ch) {
+		l.readChar()
+	}
+	return l.input[position:l.position], token.Float
 }
 
+// readString returns the contents of a string literal with the escape
+// sequences \", \\, \n, \r and \t replaced by the characters they denote.
 func (l *Lexer) readString() string {
-	position := l.position + 1
+	var out strings.Builder
 	for {
 		l.readChar()
 		if l.ch == '"' || l.ch == 0 {
 			break
 		}
+		if l.ch == '\\' {
+			switch l.peekChar() {
+			case '"', '\\':
+				l.readChar()
+			case 'n':
+				l.readChar()
+				l.ch = '\n'
+			case 'r':
+				l.readChar()
+				l.ch = '\r'
+			case 't':
+				l.readChar()
+				l.ch = '\t'
+			}
+		}
+		out.WriteByte(l.ch)
 	}
 
-	return l.input[position:l.position]
+	return out.String()
 }
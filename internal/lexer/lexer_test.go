@@ -31,6 +31,13 @@ if (5 < 10) {
 "foo bar"
 [1, 2];
 {"foo": "bar"}
+append!(a);
+a!=b
+3.14 1.
+json.parse
+log10
+fn(x: int) -> [int]
+a - -b
 `
 
 	testCases := []struct {
@@ -123,6 +130,35 @@ if (5 < 10) {
 		{token.Colon, ":"},
 		{token.String, "bar"},
 		{token.RightBrace, "}"},
+		{token.Identifier, "append!"},
+		{token.LeftParen, "("},
+		{token.Identifier, "a"},
+		{token.RightParen, ")"},
+		{token.Semicolon, ";"},
+		{token.Identifier, "a"},
+		{token.NotEqual, "!="},
+		{token.Identifier, "b"},
+		{token.Float, "3.14"},
+		{token.Integer, "1"},
+		{token.Dot, "."},
+		{token.Identifier, "json"},
+		{token.Dot, "."},
+		{token.Identifier, "parse"},
+		{token.Identifier, "log10"},
+		{token.Function, "fn"},
+		{token.LeftParen, "("},
+		{token.Identifier, "x"},
+		{token.Colon, ":"},
+		{token.Identifier, "int"},
+		{token.RightParen, ")"},
+		{token.Arrow, "->"},
+		{token.LeftBracket, "["},
+		{token.Identifier, "int"},
+		{token.RightBracket, "]"},
+		{token.Identifier, "a"},
+		{token.Minus, "-"},
+		{token.Minus, "-"},
+		{token.Identifier, "b"},
 		{token.EOF, ""},
 	}
 	l := New(input)
@@ -135,3 +171,95 @@ if (5 < 10) {
 		require.Equal(t, tok.Literal, tc.expectedLiteral)
 	}
 }
+
+func TestStringEscapes(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{`"a\"b"`, `a"b`},
+		{`"a\\b"`, `a\b`},
+		{`"a\nb\tc\r"`, "a\nb\tc\r"},
+		{`"a\qb"`, `a\qb`},
+	}
+
+	for _, tc := range testCases {
+		tok := New(tc.input).NextToken()
+		require.Equal(t, token.TokenType(token.String), tok.Type)
+		require.Equal(t, tc.expected, tok.Literal)
+	}
+}
+
+func TestShebang(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected []string
+	}{
+		{"#!/usr/bin/env monkey run\nlet x = 1;", []string{"let", "x", "=", "1", ";", ""}},
+		{"#!/usr/bin/env monkey run", []string{""}},
+		{"let x = 1;", []string{"let", "x", "=", "1", ";", ""}},
+	}
+
+	for _, tc := range testCases {
+		l := New(tc.input)
+		for _, expected := range tc.expected {
+			require.Equal(t, expected, l.NextToken().Literal)
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := "let x = 1; // one\n// alone\r\nx / 2 //"
+	expected := []struct {
+		tokenType token.TokenType
+		literal   string
+	}{
+		{token.Let, "let"},
+		{token.Identifier, "x"},
+		{token.Assign, "="},
+		{token.Integer, "1"},
+		{token.Semicolon, ";"},
+		{token.Comment, "// one"},
+		{token.Comment, "// alone"},
+		{token.Identifier, "x"},
+		{token.Slash, "/"},
+		{token.Integer, "2"},
+		{token.Comment, "//"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for _, e := range expected {
+		tok := l.NextToken()
+		require.Equal(t, e.tokenType, tok.Type)
+		require.Equal(t, e.literal, tok.Literal)
+	}
+}
+
+func TestPositions(t *testing.T) {
+	input := "#!/bin/monkey\nlet s = \"a\\nb\";\n\n  f(s)\n"
+	expected := []struct {
+		literal string
+		line    int
+		column  int
+	}{
+		{"let", 2, 1},
+		{"s", 2, 5},
+		{"=", 2, 7},
+		{"a\nb", 2, 9},
+		{";", 2, 15},
+		{"f", 4, 3},
+		{"(", 4, 4},
+		{"s", 4, 5},
+		{")", 4, 6},
+		{"", 5, 1},
+	}
+
+	l := New(input)
+	for _, e := range expected {
+		tok := l.NextToken()
+		require.Equal(t, e.literal, tok.Literal)
+		require.Equal(t, e.line, tok.Line, e.literal)
+		require.Equal(t, e.column, tok.Column, e.literal)
+	}
+}
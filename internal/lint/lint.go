@@ -0,0 +1,391 @@
+// Package lint reports code in Monkey programs that runs but is probably
+// a mistake, such as a binding that is never used or a statement that
+// can never run.
+//
+// Each finding comes from a rule with an ID, and rules can be turned off
+// one by one. Type errors are left to the types package.
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vancanhuit/monkey/internal/ast"
+	"github.com/vancanhuit/monkey/internal/evaluator"
+	"github.com/vancanhuit/monkey/internal/object"
+	"github.com/vancanhuit/monkey/internal/token"
+	"github.com/vancanhuit/monkey/internal/types"
+)
+
+// The IDs of the rules.
+const (
+	Unused             = "unused"
+	ShadowedBuiltin    = "shadowed-builtin"
+	Undefined          = "undefined"
+	Unreachable        = "unreachable"
+	Arity              = "arity"
+	ConstantComparison = "constant-comparison"
+)
+
+// Rules describes each rule by its ID.
+var Rules = map[string]string{
+	Unused:             "let bindings and parameters that are never used",
+	ShadowedBuiltin:    "let bindings and parameters named after a builtin",
+	Undefined:          "identifiers not defined in any enclosing scope",
+	Unreachable:        "statements after a return statement",
+	Arity:              "calls with the wrong number of arguments to a known function",
+	ConstantComparison: "comparisons whose result does not depend on their operands",
+}
+
+// RuleIDs returns the IDs of the rules in sorted order.
+func RuleIDs() []string {
+	ids := make([]string, 0, len(Rules))
+	for id := range Rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Finding is a problem reported by a rule at a position in the source.
+type Finding struct {
+	Line    int
+	Column  int
+	Rule    string
+	Message string
+}
+
+func (f *Finding) String() string {
+	return fmt.Sprintf("%d:%d: %s (%s)", f.Line, f.Column, f.Message, f.Rule)
+}
+
+// Lint returns the findings of the rules not in disabled for program,
+// sorted by position. The names env binds, besides the builtins, are those
+// the host defines for the program; their types give the number of
+// arguments functions among them take. Macros must have been expanded
+// beforehand.
+//
+// Bindings whose names start with an underscore are not reported as
+// unused.
+func Lint(program *ast.Program, env *types.Env, disabled map[string]bool) []*Finding {
+	l := &linter{env: env, builtins: types.NewEnv(), disabled: disabled}
+	l.body(l.enter(nil), program.Statements)
+	sort.SliceStable(l.findings, func(i, j int) bool {
+		if l.findings[i].Line != l.findings[j].Line {
+			return l.findings[i].Line < l.findings[j].Line
+		}
+		return l.findings[i].Column < l.findings[j].Column
+	})
+	return l.findings
+}
+
+type linter struct {
+	env      *types.Env
+	builtins *types.Env
+	disabled map[string]bool
+	findings []*Finding
+}
+
+// scope holds the names bound by a program or a function body. The lets
+// in if blocks share the scope of their function, as they do at runtime.
+// functions holds the function literals in the statements of the scope,
+// whose bodies are checked once all of those statements have been.
+type scope struct {
+	outer     *scope
+	names     map[string][]*binding
+	bindings  []*binding
+	functions []*ast.FunctionLiteral
+}
+
+// binding is a let statement or a parameter.
+type binding struct {
+	name  *ast.Identifier
+	kind  string
+	value ast.Expression
+	used  bool
+}
+
+func (l *linter) report(tok token.Token, rule, format string, a ...interface{}) {
+	if l.disabled[rule] {
+		return
+	}
+	l.findings = append(l.findings, &Finding{
+		Line:    tok.Line,
+		Column:  tok.Column,
+		Rule:    rule,
+		Message: fmt.Sprintf(format, a...),
+	})
+}
+
+func (l *linter) enter(outer *scope) *scope {
+	return &scope{outer: outer, names: map[string][]*binding{}}
+}
+
+// leave reports the bindings of s that were never used.
+func (l *linter) leave(s *scope) {
+	for _, b := range s.bindings {
+		if !b.used && !strings.HasPrefix(b.name.Value, "_") {
+			l.report(b.name.Token, Unused, "%s %s is never used", b.kind, b.name.Value)
+		}
+	}
+}
+
+func (l *linter) bind(s *scope, name *ast.Identifier, kind string, value ast.Expression) {
+	if _, ok := l.builtins.Lookup(name.Value); ok {
+		l.report(name.Token, ShadowedBuiltin, "%s %s shadows the builtin %s", kind, name.Value, name.Value)
+	}
+	b := &binding{name: name, kind: kind, value: value}
+	s.names[name.Value] = append(s.names[name.Value], b)
+	s.bindings = append(s.bindings, b)
+}
+
+// body checks the statements of s in order, so that each sees only the
+// names bound before it, then the bodies of the functions among them: a
+// function may refer to a name bound after it, since it only runs once the
+// name is bound.
+func (l *linter) body(s *scope, stmts []ast.Statement) {
+	l.statements(stmts, s)
+	for _, fn := range s.functions {
+		l.function(fn, s)
+	}
+	l.leave(s)
+}
+
+// use marks the binding of name in effect in s as used, and reports
+// whether there is one. In s itself that is the latest binding so far; in
+// an outer scope, whose statements have all been checked, any binding of
+// name may be in effect when the function runs, so all are marked.
+func (l *linter) use(s *scope, name string) bool {
+	if bindings, ok := s.names[name]; ok {
+		bindings[len(bindings)-1].used = true
+		return true
+	}
+	for s = s.outer; s != nil; s = s.outer {
+		if bindings, ok := s.names[name]; ok {
+			for _, b := range bindings {
+				b.used = true
+			}
+			return true
+		}
+	}
+	return false
+}
+
+func (l *linter) statements(stmts []ast.Statement, s *scope) {
+	returned := false
+	for _, stmt := range stmts {
+		if returned {
+			l.report(ast.Start(stmt), Unreachable, "unreachable code after return")
+			returned = false
+		}
+		switch stmt := stmt.(type) {
+		case *ast.LetStatement:
+			l.expression(stmt.Value, s)
+			if stmt.Name != nil {
+				l.bind(s, stmt.Name, "let", stmt.Value)
+			}
+		case *ast.ReturnStatement:
+			l.expression(stmt.Value, s)
+			returned = true
+		case *ast.ExpressionStatement:
+			l.expression(stmt.Expression, s)
+		}
+	}
+}
+
+func (l *linter) expression(expr ast.Expression, s *scope) {
+	switch n := expr.(type) {
+	case *ast.Identifier:
+		if !l.use(s, n.Value) {
+			if _, ok := l.env.Lookup(n.Value); !ok {
+				l.report(n.Token, Undefined, "identifier not found: %s", n.Value)
+			}
+		}
+	case *ast.PrefixExpression:
+		l.expression(n.Right, s)
+	case *ast.InfixExpression:
+		l.expression(n.Left, s)
+		l.expression(n.Right, s)
+		l.comparison(n)
+	case *ast.IfExpression:
+		l.expression(n.Condition, s)
+		l.statements(n.Consequence.Statements, s)
+		if n.Alternative != nil {
+			l.statements(n.Alternative.Statements, s)
+		}
+	case *ast.FunctionLiteral:
+		s.functions = append(s.functions, n)
+	case *ast.CallExpression:
+		l.call(n, s)
+	case *ast.ArrayLiteral:
+		for _, e := range n.Elements {
+			l.expression(e, s)
+		}
+	case *ast.HashLiteral:
+		for _, pair := range n.Pairs {
+			l.expression(pair.Key, s)
+			l.expression(pair.Value, s)
+		}
+	case *ast.IndexExpression:
+		l.expression(n.Left, s)
+		l.expression(n.Index, s)
+	case *ast.MemberExpression:
+		l.expression(n.Left, s)
+	case *ast.AssignExpression:
+		l.expression(n.Target, s)
+		l.expression(n.Value, s)
+	}
+}
+
+func (l *linter) function(n *ast.FunctionLiteral, outer *scope) {
+	s := l.enter(outer)
+	for _, p := range n.Parameters {
+		l.bind(s, p, "parameter", nil)
+	}
+	l.body(s, n.Body.Statements)
+}
+
+func (l *linter) call(n *ast.CallExpression, s *scope) {
+	if id, ok := n.Function.(*ast.Identifier); ok && id.Value == "quote" {
+		l.quote(n, s)
+		return
+	}
+	l.expression(n.Function, s)
+	for _, arg := range n.Arguments {
+		l.expression(arg, s)
+	}
+
+	switch callee := l.callee(n.Function, s).(type) {
+	case *ast.FunctionLiteral:
+		if len(n.Arguments) != len(callee.Parameters) {
+			l.report(ast.Start(n), Arity, "wrong number of arguments. got=%d, want=%d",
+				len(n.Arguments), len(callee.Parameters))
+		}
+	case *types.Func:
+		if !callee.Accepts(len(n.Arguments)) {
+			l.report(ast.Start(n), Arity, "wrong number of arguments. got=%d, want=%s",
+				len(n.Arguments), callee.Arity())
+		}
+	}
+}
+
+// quote checks a call to quote, whose argument is code rather than a
+// value: only the arguments to unquote in it are evaluated.
+func (l *linter) quote(n *ast.CallExpression, s *scope) {
+	if len(n.Arguments) != 1 {
+		l.report(ast.Start(n), Arity, "wrong number of arguments. got=%d, want=1", len(n.Arguments))
+	}
+	for _, arg := range n.Arguments {
+		ast.Inspect(arg, func(node ast.Node) bool {
+			call, ok := node.(*ast.CallExpression)
+			if !ok {
+				return true
+			}
+			if id, ok := call.Function.(*ast.Identifier); !ok || id.Value != "unquote" {
+				return true
+			}
+			for _, arg := range call.Arguments {
+				l.expression(arg, s)
+			}
+			return false
+		})
+	}
+}
+
+// bound reports whether name is bound by the program rather than being a
+// builtin, without marking it used.
+func (l *linter) bound(s *scope, name string) bool {
+	for ; s != nil; s = s.outer {
+		if _, ok := s.names[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// callee returns what fn calls when it is known: the function literal the
+// binding of a name in effect is bound to, or the type of a builtin
+// function or of one the host defines. It returns nil otherwise. As in
+// use, the binding in effect in an outer scope is only known if there is
+// a single one.
+func (l *linter) callee(fn ast.Expression, s *scope) interface{} {
+	switch fn := fn.(type) {
+	case *ast.FunctionLiteral:
+		return fn
+	case *ast.Identifier:
+		for inner := s; s != nil; s = s.outer {
+			if bindings, ok := s.names[fn.Value]; ok {
+				if s == inner || len(bindings) == 1 {
+					if literal, ok := bindings[len(bindings)-1].value.(*ast.FunctionLiteral); ok {
+						return literal
+					}
+				}
+				return nil
+			}
+		}
+		if scheme, ok := l.env.Lookup(fn.Value); ok {
+			if f, ok := scheme.Type.(*types.Func); ok {
+				return f
+			}
+		}
+	case *ast.MemberExpression:
+		module, ok := fn.Left.(*ast.Identifier)
+		if !ok || l.bound(s, module.Value) {
+			return nil
+		}
+		if scheme, ok := l.env.Lookup(module.Value); ok {
+			if m, ok := scheme.Type.(*types.Module); ok {
+				if member, ok := m.Members[fn.Property.Value]; ok {
+					if f, ok := member.Type.(*types.Func); ok {
+						return f
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// comparison reports n if it compares two constants, or a name with
+// itself.
+func (l *linter) comparison(n *ast.InfixExpression) {
+	switch n.Operator {
+	case "==", "!=", "<", ">":
+	default:
+		return
+	}
+
+	if left, ok := n.Left.(*ast.Identifier); ok {
+		if right, ok := n.Right.(*ast.Identifier); ok && left.Value == right.Value {
+			// NaN is not equal to itself, but neither less nor greater.
+			switch n.Operator {
+			case "==", "!=":
+				l.report(n.Token, ConstantComparison, "comparison of %s with itself is always %t unless %s is NaN",
+					left.Value, n.Operator == "==", left.Value)
+			default:
+				l.report(n.Token, ConstantComparison, "comparison of %s with itself is always false", left.Value)
+			}
+		}
+		return
+	}
+	if !isConstant(n.Left) || !isConstant(n.Right) {
+		return
+	}
+	// Comparisons the runtime rejects are type errors, not findings.
+	if result, ok := evaluator.Eval(n, object.NewEnvironment()).(*object.Boolean); ok {
+		l.report(n.Token, ConstantComparison, "comparison is always %t", result.Value)
+	}
+}
+
+// isConstant reports whether expr is a literal number, string or boolean,
+// possibly negated.
+func isConstant(expr ast.Expression) bool {
+	switch expr := expr.(type) {
+	case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.StringLiteral, *ast.Boolean:
+		return true
+	case *ast.PrefixExpression:
+		return isConstant(expr.Right)
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package lint_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vancanhuit/monkey/internal/lexer"
+	"github.com/vancanhuit/monkey/internal/lint"
+	"github.com/vancanhuit/monkey/internal/parser"
+	"github.com/vancanhuit/monkey/internal/types"
+)
+
+func lintSource(t *testing.T, input string, disabled map[string]bool) []string {
+	t.Helper()
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	require.Empty(t, p.Errors())
+
+	env := types.NewEnv()
+	env.Define("args", &types.Array{Elem: types.String})
+	var findings []string
+	for _, f := range lint.Lint(program, env, disabled) {
+		findings = append(findings, f.String())
+	}
+	return findings
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"let x = 1;", []string{"1:5: let x is never used (unused)"}},
+		{"let f = fn(a, b) { a }; f(1, 2);", []string{"1:15: parameter b is never used (unused)"}},
+		{"let _x = 1; let f = fn(_a) { 1 }; f(2);", nil},
+		{"let f = fn() { if (true) { let y = 1; } }; f();", []string{"1:32: let y is never used (unused)"}},
+		{"let len = 1; puts(len);", []string{"1:5: let len shadows the builtin len (shadowed-builtin)"}},
+		{
+			"let f = fn(json) { json }; f(1);",
+			[]string{"1:12: parameter json shadows the builtin json (shadowed-builtin)"},
+		},
+		{"puts(x);", []string{"1:6: identifier not found: x (undefined)"}},
+		{"let f = fn() { g() }; let g = fn() { 1 }; f();", nil},
+		{"puts(x); let x = 1;", []string{
+			"1:6: identifier not found: x (undefined)",
+			"1:14: let x is never used (unused)",
+		}},
+		{"let f = fn() { puts(y); let y = 1; }; f();", []string{
+			"1:21: identifier not found: y (undefined)",
+			"1:29: let y is never used (unused)",
+		}},
+		{"let x = x + 1; puts(x);", []string{"1:9: identifier not found: x (undefined)"}},
+		{"let x = 1; let x = 2; puts(x);", []string{"1:5: let x is never used (unused)"}},
+		{"let x = 1; let x = x + 1; puts(x);", nil},
+		{"let x = 1; let f = fn() { x }; let x = 2; f();", nil},
+		{"if (true) { let x = 1; } puts(x);", nil},
+		{"let f = fn(x) { x }; let f = fn(x, y) { x + y }; f(1, 2);", []string{"1:5: let f is never used (unused)"}},
+		{
+			"let f = fn(x) { x }; f(1); let f = fn(x, y) { x + y }; f(1);",
+			[]string{"1:56: wrong number of arguments. got=1, want=2 (arity)"},
+		},
+		{"puts(args); quote(foo + 1);", nil},
+		{"let x = 1; quote(unquote(x) + y);", nil},
+		{"quote(unquote(x));", []string{"1:15: identifier not found: x (undefined)"}},
+		{"quote(1, 2);", []string{"1:1: wrong number of arguments. got=2, want=1 (arity)"}},
+		{
+			"let f = fn(x) { return x; puts(x); puts(1); }; f(1);",
+			[]string{"1:27: unreachable code after return (unreachable)"},
+		},
+		{"return 1; 2;", []string{"1:11: unreachable code after return (unreachable)"}},
+		{"let f = fn(x) { x }; f(1, 2);", []string{"1:22: wrong number of arguments. got=2, want=1 (arity)"}},
+		{"fn(x, y) { x + y }(1);", []string{"1:1: wrong number of arguments. got=1, want=2 (arity)"}},
+		{"len(1, 2);", []string{"1:1: wrong number of arguments. got=2, want=1 (arity)"}},
+		{"range();", []string{"1:1: wrong number of arguments. got=0, want=1 to 3 (arity)"}},
+		{`json.parse("1", 2);`, []string{"1:1: wrong number of arguments. got=2, want=1 (arity)"}},
+		{"let len = fn(a, b) { a + b }; len(1, 2);", []string{
+			"1:5: let len shadows the builtin len (shadowed-builtin)",
+		}},
+		{"let f = if (true) { fn(x) { x } }; f(1, 2);", nil},
+		{"1 < 2;", []string{"1:3: comparison is always true (constant-comparison)"}},
+		{`"a" == "b";`, []string{"1:5: comparison is always false (constant-comparison)"}},
+		{"-1 != 1.0;", []string{"1:4: comparison is always true (constant-comparison)"}},
+		{
+			"let x = 1; x == x;",
+			[]string{"1:14: comparison of x with itself is always true unless x is NaN (constant-comparison)"},
+		},
+		{
+			"let x = math.sqrt(-1.0); puts(x != x);",
+			[]string{"1:33: comparison of x with itself is always false unless x is NaN (constant-comparison)"},
+		},
+		{"let x = 1; x < x;", []string{"1:14: comparison of x with itself is always false (constant-comparison)"}},
+		{`1 < "a"; let x = 1; x == 1;`, nil},
+		{
+			"let f = fn(a) {\n  return 1;\n  b;\n};",
+			[]string{
+				"1:5: let f is never used (unused)",
+				"1:12: parameter a is never used (unused)",
+				"3:3: unreachable code after return (unreachable)",
+				"3:3: identifier not found: b (undefined)",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			require.Equal(t, tc.expected, lintSource(t, tc.input, nil))
+		})
+	}
+}
+
+func TestDisabledRules(t *testing.T) {
+	input := "let len = 1; let f = fn(x) { return 1; x }; f(); 1 == 1; y;"
+	require.Len(t, lintSource(t, input, nil), 6)
+
+	disabled := map[string]bool{}
+	for _, id := range lint.RuleIDs() {
+		disabled[id] = true
+	}
+	require.Empty(t, lintSource(t, input, disabled))
+
+	findings := lintSource(t, input, map[string]bool{lint.Unused: true, lint.Undefined: true})
+	require.Equal(t, []string{
+		"1:5: let len shadows the builtin len (shadowed-builtin)",
+		"1:40: unreachable code after return (unreachable)",
+		"1:45: wrong number of arguments. got=0, want=1 (arity)",
+		"1:52: comparison is always true (constant-comparison)",
+	}, findings)
+}
@@ -0,0 +1,244 @@
+package object
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+type objectPair struct {
+	a, b Object
+}
+
+// Equal reports whether a and b are structurally equal. Integers and floats
+// compare by exact numeric value, as their hash keys do, arrays element by
+// element and hashes by their key/value pairs regardless of insertion
+// order. Functions and other objects are only equal to themselves.
+// Self-referencing arrays and hashes are handled by treating a pair that is
+// already being compared as equal.
+func Equal(a, b Object) bool {
+	return equal(a, b, map[objectPair]bool{})
+}
+
+func equal(a, b Object, seen map[objectPair]bool) bool {
+	if a == b {
+		if f, ok := a.(*Float); ok {
+			return !math.IsNaN(f.Value)
+		}
+		return true
+	}
+
+	switch a := a.(type) {
+	case *Integer, *Float:
+		if !isNumber(b) {
+			return false
+		}
+		ai, aInt := a.(*Integer)
+		bi, bInt := b.(*Integer)
+		switch {
+		case aInt && bInt:
+			return ai.Value == bi.Value
+		case aInt:
+			return integerEqualsFloat(ai.Value, b.(*Float).Value)
+		case bInt:
+			return integerEqualsFloat(bi.Value, a.(*Float).Value)
+		}
+		return toFloat(a) == toFloat(b)
+	case *Boolean:
+		b, ok := b.(*Boolean)
+		return ok && a.Value == b.Value
+	case *Null:
+		_, ok := b.(*Null)
+		return ok
+	case *String:
+		b, ok := b.(*String)
+		return ok && a.Value == b.Value
+	case *Time:
+		b, ok := b.(*Time)
+		return ok && a.Value.Equal(b.Value)
+	case *Array:
+		b, ok := b.(*Array)
+		if !ok || len(a.Elements) != len(b.Elements) {
+			return false
+		}
+		key := objectPair{a, b}
+		if seen[key] {
+			return true
+		}
+		seen[key] = true
+		defer delete(seen, key)
+
+		for i := range a.Elements {
+			if !equal(a.Elements[i], b.Elements[i], seen) {
+				return false
+			}
+		}
+		return true
+	case *Hash:
+		b, ok := b.(*Hash)
+		if !ok || a.Len() != b.Len() {
+			return false
+		}
+		key := objectPair{a, b}
+		if seen[key] {
+			return true
+		}
+		seen[key] = true
+		defer delete(seen, key)
+
+		for _, pair := range a.order {
+			other, ok := b.Get(pair.Key)
+			if !ok || !equal(pair.Value, other.Value, seen) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// Compare orders a relative to b, returning a negative number, zero or a
+// positive number. Numbers are ordered numerically, strings bytewise, times
+// chronologically, false before true, and arrays lexicographically by their
+// elements. An error is returned for objects without an ordering, such as
+// hashes, functions or values of unrelated types.
+func Compare(a, b Object) (int, error) {
+	return compare(a, b, map[objectPair]bool{})
+}
+
+func compare(a, b Object, seen map[objectPair]bool) (int, error) {
+	switch a := a.(type) {
+	case *Integer, *Float:
+		if !isNumber(b) {
+			break
+		}
+		x, y := toFloat(a), toFloat(b)
+		if math.IsNaN(x) || math.IsNaN(y) {
+			return 0, fmt.Errorf("cannot compare NaN")
+		}
+		ai, aInt := a.(*Integer)
+		bi, bInt := b.(*Integer)
+		switch {
+		case aInt && bInt:
+			return compareOrdered(ai.Value, bi.Value), nil
+		case aInt:
+			return compareIntegerFloat(ai.Value, y), nil
+		case bInt:
+			return -compareIntegerFloat(bi.Value, x), nil
+		}
+		return compareOrdered(x, y), nil
+	case *String:
+		if b, ok := b.(*String); ok {
+			return strings.Compare(a.Value, b.Value), nil
+		}
+	case *Time:
+		if b, ok := b.(*Time); ok {
+			switch {
+			case a.Value.Before(b.Value):
+				return -1, nil
+			case a.Value.After(b.Value):
+				return 1, nil
+			default:
+				return 0, nil
+			}
+		}
+	case *Boolean:
+		if b, ok := b.(*Boolean); ok {
+			return compareOrdered(boolToInt(a.Value), boolToInt(b.Value)), nil
+		}
+	case *Null:
+		if _, ok := b.(*Null); ok {
+			return 0, nil
+		}
+	case *Array:
+		b, ok := b.(*Array)
+		if !ok {
+			break
+		}
+		key := objectPair{a, b}
+		if seen[key] {
+			return 0, nil
+		}
+		seen[key] = true
+		defer delete(seen, key)
+
+		for i := 0; i < len(a.Elements) && i < len(b.Elements); i++ {
+			c, err := compare(a.Elements[i], b.Elements[i], seen)
+			if err != nil || c != 0 {
+				return c, err
+			}
+		}
+		return compareOrdered(len(a.Elements), len(b.Elements)), nil
+	}
+
+	return 0, fmt.Errorf("cannot compare %s and %s", a.Type(), b.Type())
+}
+
+func compareOrdered[T int | int64 | float64](x, y T) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func isNumber(obj Object) bool {
+	switch obj.(type) {
+	case *Integer, *Float:
+		return true
+	default:
+		return false
+	}
+}
+
+// integerEqualsFloat reports whether i and f are the same number. Unlike
+// comparing float64(i) with f, it does not round i, so that equal numbers
+// always have the same hash key.
+func integerEqualsFloat(i int64, f float64) bool {
+	fi, ok := floatToInteger(f)
+	return ok && fi == i
+}
+
+// compareIntegerFloat orders i relative to f, which is not NaN. Like
+// integerEqualsFloat, it does not round i, so that it agrees with Equal.
+func compareIntegerFloat(i int64, f float64) int {
+	// The bounds are -2^63 and 2^63, which float64 represents exactly.
+	switch {
+	case f >= 1<<63:
+		return -1
+	case f < -(1 << 63):
+		return 1
+	}
+	t := math.Trunc(f)
+	if c := compareOrdered(i, int64(t)); c != 0 {
+		return c
+	}
+	return compareOrdered(t, f)
+}
+
+// floatToInteger returns the integer equal to f, if there is one.
+func floatToInteger(f float64) (int64, bool) {
+	// The bounds are -2^63 and 2^63, which float64 represents exactly.
+	if f != math.Trunc(f) || f < -(1<<63) || f >= 1<<63 {
+		return 0, false
+	}
+	return int64(f), true
+}
+
+func toFloat(obj Object) float64 {
+	if i, ok := obj.(*Integer); ok {
+		return float64(i.Value)
+	}
+	return obj.(*Float).Value
+}
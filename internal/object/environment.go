@@ -1,8 +1,21 @@
 package object
 
+import "sort"
+
+// Guard is consulted by the evaluator while a program runs so that hosts
+// can bound the resources used by untrusted code. A non-nil error stops
+// evaluation and is reported to the program as an error object.
+type Guard interface {
+	// Step is called before each node is evaluated.
+	Step() error
+	// Alloc is called with the approximate size in bytes of a new value.
+	Alloc(size int) error
+}
+
 type Environment struct {
 	outer *Environment
 	store map[string]Object
+	guard Guard
 }
 
 func NewEnvironment() *Environment {
@@ -13,9 +26,28 @@ func NewEnvironment() *Environment {
 func NewEnclosedEnvironment(outer *Environment) *Environment {
 	env := NewEnvironment()
 	env.outer = outer
+	env.guard = outer.guard
+	return env
+}
+
+// NewGuardedEnvironment returns a top-level environment whose evaluation,
+// including that of every enclosed environment, is supervised by g.
+func NewGuardedEnvironment(g Guard) *Environment {
+	env := NewEnvironment()
+	env.guard = g
 	return env
 }
 
+// Outer returns the environment enclosing e, or nil if e is top-level.
+func (e *Environment) Outer() *Environment {
+	return e.outer
+}
+
+// Guard returns the guard supervising e, or nil if there is none.
+func (e *Environment) Guard() Guard {
+	return e.guard
+}
+
 func (e *Environment) Get(name string) (Object, bool) {
 	obj, ok := e.store[name]
 	if !ok && e.outer != nil {
@@ -28,3 +60,14 @@ func (e *Environment) Set(name string, val Object) Object {
 	e.store[name] = val
 	return val
 }
+
+// Names returns the sorted names bound directly in e, excluding those of
+// enclosing environments.
+func (e *Environment) Names() []string {
+	names := make([]string, 0, len(e.store))
+	for name := range e.store {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
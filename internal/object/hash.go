@@ -0,0 +1,210 @@
+package object
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+)
+
+type Hashable interface {
+	HashKey() HashKey
+}
+
+// HashKey selects the bucket a key is stored in. Different keys may share a
+// HashKey, so lookups always compare the keys themselves as well.
+type HashKey struct {
+	Type  ObjectType
+	Value uint64
+}
+
+func (b *Boolean) HashKey() HashKey {
+	var value uint64
+
+	if b.Value {
+		value = 1
+	} else {
+		value = 0
+	}
+
+	return HashKey{Type: b.Type(), Value: value}
+}
+
+func (i *Integer) HashKey() HashKey {
+	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
+}
+
+// HashKey returns the key of the integer equal to f if there is one, since
+// the two are equal as keys. -0.0 is such a float, equal to 0.
+func (f *Float) HashKey() HashKey {
+	if i, ok := floatToInteger(f.Value); ok {
+		return (&Integer{Value: i}).HashKey()
+	}
+	return HashKey{Type: f.Type(), Value: math.Float64bits(f.Value)}
+}
+
+func (s *String) HashKey() HashKey {
+	h := fnv.New64a()
+	h.Write([]byte(s.Value))
+
+	return HashKey{Type: s.Type(), Value: h.Sum64()}
+}
+
+// HashKeyOf returns the hash key of obj. Besides Hashable objects, arrays
+// whose elements are all usable as keys are accepted as composite keys. The
+// second result is false if obj cannot be used as a hash key.
+func HashKeyOf(obj Object) (HashKey, bool) {
+	return hashKeyOf(obj, map[*Array]bool{})
+}
+
+func hashKeyOf(obj Object, visiting map[*Array]bool) (HashKey, bool) {
+	switch o := obj.(type) {
+	case *Float:
+		// NaN is not equal to itself, so a value stored under it could
+		// never be looked up.
+		if math.IsNaN(o.Value) {
+			return HashKey{}, false
+		}
+		return o.HashKey(), true
+	case Hashable:
+		return o.HashKey(), true
+	case *Array:
+		if visiting[o] {
+			return HashKey{}, false
+		}
+		visiting[o] = true
+		defer delete(visiting, o)
+
+		h := fnv.New64a()
+		buf := make([]byte, 8)
+		for _, e := range o.Elements {
+			key, ok := hashKeyOf(e, visiting)
+			if !ok {
+				return HashKey{}, false
+			}
+			h.Write([]byte(key.Type))
+			binary.LittleEndian.PutUint64(buf, key.Value)
+			h.Write(buf)
+		}
+		return HashKey{Type: o.Type(), Value: h.Sum64()}, true
+	default:
+		return HashKey{}, false
+	}
+}
+
+// freezeKey copies composite keys so that mutating the array a key was
+// built from does not change the key stored in the hash.
+func freezeKey(key Object) Object {
+	arr, ok := key.(*Array)
+	if !ok {
+		return key
+	}
+
+	elements := make([]Object, len(arr.Elements))
+	for i, e := range arr.Elements {
+		elements[i] = freezeKey(e)
+	}
+	return &Array{Elements: elements}
+}
+
+type HashPair struct {
+	Key   Object
+	Value Object
+}
+
+// Hash is an insertion-ordered map. Pairs are grouped in buckets by their
+// HashKey and told apart by comparing the keys, while order records the
+// sequence in which keys were first inserted.
+type Hash struct {
+	buckets map[HashKey][]*HashPair
+	order   []*HashPair
+}
+
+func NewHash() *Hash {
+	return &Hash{buckets: make(map[HashKey][]*HashPair)}
+}
+
+func (h *Hash) Type() ObjectType {
+	return HashObj
+}
+
+func (h *Hash) Inspect() string {
+	return inspect(h, map[Object]bool{})
+}
+
+func (h *Hash) Len() int {
+	return len(h.order)
+}
+
+func (h *Hash) lookup(key Object) (HashKey, int, bool) {
+	hashKey, ok := HashKeyOf(key)
+	if !ok {
+		return hashKey, -1, false
+	}
+	for i, pair := range h.buckets[hashKey] {
+		if Equal(pair.Key, key) {
+			return hashKey, i, true
+		}
+	}
+	return hashKey, -1, true
+}
+
+// Get returns the pair stored under key. The result is false if the key is
+// absent or cannot be used as a hash key.
+func (h *Hash) Get(key Object) (HashPair, bool) {
+	hashKey, i, ok := h.lookup(key)
+	if !ok || i < 0 {
+		return HashPair{}, false
+	}
+	return *h.buckets[hashKey][i], true
+}
+
+// Set stores value under key and reports whether key is usable as a hash
+// key. Overwriting an existing key keeps its original position.
+func (h *Hash) Set(key, value Object) bool {
+	hashKey, i, ok := h.lookup(key)
+	if !ok {
+		return false
+	}
+	if i >= 0 {
+		h.buckets[hashKey][i].Value = value
+		return true
+	}
+
+	pair := &HashPair{Key: freezeKey(key), Value: value}
+	h.buckets[hashKey] = append(h.buckets[hashKey], pair)
+	h.order = append(h.order, pair)
+	return true
+}
+
+// Delete removes key and returns the pair it was bound to. It is linear in
+// the size of the hash since the pair has to be removed from the ordering.
+func (h *Hash) Delete(key Object) (HashPair, bool) {
+	hashKey, i, ok := h.lookup(key)
+	if !ok || i < 0 {
+		return HashPair{}, false
+	}
+
+	bucket := h.buckets[hashKey]
+	pair := bucket[i]
+	if len(bucket) == 1 {
+		delete(h.buckets, hashKey)
+	} else {
+		h.buckets[hashKey] = append(bucket[:i:i], bucket[i+1:]...)
+	}
+	for j, p := range h.order {
+		if p == pair {
+			h.order = append(h.order[:j], h.order[j+1:]...)
+			break
+		}
+	}
+	return *pair, true
+}
+
+// Pairs returns the key/value pairs in insertion order.
+func (h *Hash) Pairs() []HashPair {
+	pairs := make([]HashPair, len(h.order))
+	for i, pair := range h.order {
+		pairs[i] = *pair
+	}
+	return pairs
+}
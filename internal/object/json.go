@@ -0,0 +1,186 @@
+package object
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ToJSON encodes obj as JSON. Hash keys are written in insertion order;
+// integer, float and boolean keys are converted to strings and times are
+// written as RFC 3339 strings. A non-empty indent pretty-prints the result.
+// Functions, builtins and self-referencing values cannot be encoded.
+func ToJSON(obj Object, indent string) ([]byte, error) {
+	var out bytes.Buffer
+	if err := encodeJSON(&out, obj, map[Object]bool{}); err != nil {
+		return nil, err
+	}
+	if indent == "" {
+		return out.Bytes(), nil
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, out.Bytes(), "", indent); err != nil {
+		return nil, err
+	}
+	return indented.Bytes(), nil
+}
+
+func encodeJSON(out *bytes.Buffer, obj Object, seen map[Object]bool) error {
+	switch o := obj.(type) {
+	case *Null:
+		out.WriteString("null")
+	case *Boolean:
+		out.WriteString(strconv.FormatBool(o.Value))
+	case *Integer:
+		out.WriteString(strconv.FormatInt(o.Value, 10))
+	case *Float:
+		if math.IsNaN(o.Value) || math.IsInf(o.Value, 0) {
+			return fmt.Errorf("cannot encode %s as JSON", o.Inspect())
+		}
+		out.WriteString(o.Inspect())
+	case *String:
+		encodeJSONString(out, o.Value)
+	case *Time:
+		encodeJSONString(out, o.Inspect())
+	case *Array:
+		if seen[o] {
+			return fmt.Errorf("cannot encode self-referencing ARRAY as JSON")
+		}
+		seen[o] = true
+		defer delete(seen, o)
+
+		out.WriteByte('[')
+		for i, e := range o.Elements {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			if err := encodeJSON(out, e, seen); err != nil {
+				return err
+			}
+		}
+		out.WriteByte(']')
+	case *Hash:
+		if seen[o] {
+			return fmt.Errorf("cannot encode self-referencing HASH as JSON")
+		}
+		seen[o] = true
+		defer delete(seen, o)
+
+		out.WriteByte('{')
+		for i, pair := range o.order {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			switch key := pair.Key.(type) {
+			case *String:
+				encodeJSONString(out, key.Value)
+			case *Integer, *Float, *Boolean:
+				encodeJSONString(out, key.Inspect())
+			default:
+				return fmt.Errorf("cannot encode %s hash key as JSON", key.Type())
+			}
+			out.WriteByte(':')
+			if err := encodeJSON(out, pair.Value, seen); err != nil {
+				return err
+			}
+		}
+		out.WriteByte('}')
+	default:
+		return fmt.Errorf("cannot encode %s as JSON", obj.Type())
+	}
+	return nil
+}
+
+func encodeJSONString(out *bytes.Buffer, s string) {
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	// Encoding a string cannot fail; Encode appends a newline we drop.
+	_ = enc.Encode(s)
+	out.Truncate(out.Len() - 1)
+}
+
+// FromJSON decodes a single JSON value. Objects become hashes that keep the
+// order of their keys, numbers without a fraction or exponent become
+// integers and all other numbers floats.
+func FromJSON(data []byte) (Object, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	obj, err := decodeJSON(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("invalid character after top-level value")
+	}
+	return obj, nil
+}
+
+func decodeJSON(dec *json.Decoder) (Object, error) {
+	tok, err := dec.Token()
+	if err == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := tok.(type) {
+	case nil:
+		return NULL, nil
+	case bool:
+		return NativeBoolToBoolean(t), nil
+	case string:
+		return &String{Value: t}, nil
+	case json.Number:
+		if !strings.ContainsAny(t.String(), ".eE") {
+			if i, err := t.Int64(); err == nil {
+				return &Integer{Value: i}, nil
+			}
+		}
+		f, err := t.Float64()
+		if err != nil {
+			return nil, err
+		}
+		return &Float{Value: f}, nil
+	case json.Delim:
+		if t == '[' {
+			elements := []Object{}
+			for dec.More() {
+				e, err := decodeJSON(dec)
+				if err != nil {
+					return nil, err
+				}
+				elements = append(elements, e)
+			}
+			if _, err := dec.Token(); err != nil {
+				return nil, err
+			}
+			return &Array{Elements: elements}, nil
+		}
+
+		hash := NewHash()
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			hash.Set(&String{Value: keyTok.(string)}, value)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return hash, nil
+	}
+
+	return nil, fmt.Errorf("unexpected JSON token %v", tok)
+}
@@ -0,0 +1,78 @@
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	testCases := []string{
+		`null`,
+		`true`,
+		`42`,
+		`-1.5`,
+		`"café <\"quoted\">"`,
+		`[]`,
+		`{}`,
+		`{"z":1,"a":[1,2.5,{"b":null}],"m":"x"}`,
+	}
+
+	for _, input := range testCases {
+		obj, err := FromJSON([]byte(input))
+		require.NoError(t, err)
+		data, err := ToJSON(obj, "")
+		require.NoError(t, err)
+
+		again, err := FromJSON(data)
+		require.NoError(t, err)
+		require.True(t, Equal(obj, again), input)
+	}
+}
+
+func TestFromJSON(t *testing.T) {
+	obj, err := FromJSON([]byte(`{"b": 1, "a": [true, 2.0, 3e2], "c": null}`))
+	require.NoError(t, err)
+	require.Equal(t, "{b: 1, a: [true, 2.0, 300.0], c: null}", obj.Inspect())
+
+	hash := obj.(*Hash)
+	pair, ok := hash.Get(&String{Value: "c"})
+	require.True(t, ok)
+	require.Same(t, NULL, pair.Value)
+
+	_, err = FromJSON([]byte(`{"a": }`))
+	require.Error(t, err)
+	_, err = FromJSON([]byte(`[1, 2`))
+	require.Error(t, err)
+	_, err = FromJSON([]byte(`1 2`))
+	require.Error(t, err)
+	_, err = FromJSON([]byte(``))
+	require.Error(t, err)
+}
+
+func TestToJSON(t *testing.T) {
+	hash := NewHash()
+	hash.Set(&String{Value: "name"}, &String{Value: "<monkey>"})
+	hash.Set(&Integer{Value: 1}, &Array{Elements: []Object{TRUE, &Float{Value: 2}}})
+
+	data, err := ToJSON(hash, "")
+	require.NoError(t, err)
+	require.Equal(t, `{"name":"<monkey>","1":[true,2.0]}`, string(data))
+
+	data, err = ToJSON(hash, "  ")
+	require.NoError(t, err)
+	require.Equal(t, "{\n  \"name\": \"<monkey>\",\n  \"1\": [\n    true,\n    2.0\n  ]\n}", string(data))
+
+	_, err = ToJSON(&Function{}, "")
+	require.EqualError(t, err, "cannot encode FUNCTION as JSON")
+
+	cyclic := &Array{}
+	cyclic.Elements = []Object{cyclic}
+	_, err = ToJSON(cyclic, "")
+	require.EqualError(t, err, "cannot encode self-referencing ARRAY as JSON")
+
+	arrayKey := NewHash()
+	arrayKey.Set(&Array{}, NULL)
+	_, err = ToJSON(arrayKey, "")
+	require.EqualError(t, err, "cannot encode ARRAY hash key as JSON")
+}
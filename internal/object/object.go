@@ -3,8 +3,9 @@ package object
 import (
 	"bytes"
 	"fmt"
-	"hash/fnv"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/vancanhuit/monkey/internal/ast"
 )
@@ -12,10 +13,15 @@ import (
 type (
 	ObjectType      string
 	BuiltinFunction func(args ...Object) Object
+	// GuardedFunction is a builtin that is passed the guard of the
+	// environment it is called from, or nil if there is none, so that it
+	// can charge for what it allocates before allocating it.
+	GuardedFunction func(g Guard, args ...Object) Object
 )
 
 const (
 	IntegerObj     = "INTEGER"
+	FloatObj       = "FLOAT"
 	BooleanObj     = "BOOLEAN"
 	NullObj        = "NULL"
 	ReturnValueObj = "RETURN_VALUE"
@@ -25,8 +31,27 @@ const (
 	BuiltinObj     = "BUILTIN"
 	ArrayObj       = "ARRAY"
 	HashObj        = "HASH"
+	ModuleObj      = "MODULE"
+	TimeObj        = "TIME"
+	QuoteObj       = "QUOTE"
+	MacroObj       = "MACRO"
 )
 
+// TRUE, FALSE and NULL are the only boolean and null values; the evaluator
+// relies on comparing against them by identity.
+var (
+	TRUE  = &Boolean{Value: true}
+	FALSE = &Boolean{Value: false}
+	NULL  = &Null{}
+)
+
+func NativeBoolToBoolean(input bool) *Boolean {
+	if input {
+		return TRUE
+	}
+	return FALSE
+}
+
 type Object interface {
 	Type() ObjectType
 	Inspect() string
@@ -43,6 +68,21 @@ func (o *Integer) Type() ObjectType {
 	return IntegerObj
 }
 
+type Float struct {
+	Value float64
+}
+
+func (o *Float) Inspect() string {
+	s := strconv.FormatFloat(o.Value, 'f', -1, 64)
+	if strings.ContainsAny(s, ".IN") {
+		return s
+	}
+	return s + ".0"
+}
+func (o *Float) Type() ObjectType {
+	return FloatObj
+}
+
 type Boolean struct {
 	Value bool
 }
@@ -87,8 +127,12 @@ func (e *Error) Inspect() string {
 
 type Function struct {
 	Parameters []*ast.Identifier
-	Body       *ast.BlockStatement
-	Env        *Environment
+	// ParameterTypes and ReturnType are the type annotations of the
+	// function literal, which are checked on every call.
+	ParameterTypes []ast.TypeExpression
+	ReturnType     ast.TypeExpression
+	Body           *ast.BlockStatement
+	Env            *Environment
 }
 
 func (o *Function) Type() ObjectType {
@@ -97,13 +141,21 @@ func (o *Function) Type() ObjectType {
 func (o *Function) Inspect() string {
 	var out bytes.Buffer
 	params := []string{}
-	for _, p := range o.Parameters {
+	for i, p := range o.Parameters {
+		if i < len(o.ParameterTypes) && o.ParameterTypes[i] != nil {
+			params = append(params, p.String()+": "+o.ParameterTypes[i].String())
+			continue
+		}
 		params = append(params, p.String())
 	}
 	out.WriteString("fn")
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
-	out.WriteString(") {\n")
+	out.WriteString(")")
+	if o.ReturnType != nil {
+		out.WriteString(" -> " + o.ReturnType.String())
+	}
+	out.WriteString(" {\n")
 	out.WriteString(o.Body.String())
 	out.WriteString("\n}")
 	return out.String()
@@ -120,8 +172,12 @@ func (o *String) Inspect() string {
 	return o.Value
 }
 
+// Builtin is a function implemented in Go. The evaluator charges the
+// guard for the result of Fn once it returns; Guarded, if set, is called
+// instead and charges for its allocations itself.
 type Builtin struct {
-	Fn BuiltinFunction
+	Fn      BuiltinFunction
+	Guarded GuardedFunction
 }
 
 func (o *Builtin) Type() ObjectType {
@@ -131,85 +187,120 @@ func (o *Builtin) Inspect() string {
 	return "builtin function"
 }
 
-type Array struct {
-	Elements []Object
+// Quote holds unevaluated code, as returned by quote.
+type Quote struct {
+	Node ast.Node
 }
 
-func (o *Array) Type() ObjectType {
-	return ArrayObj
+func (o *Quote) Type() ObjectType {
+	return QuoteObj
 }
-func (o *Array) Inspect() string {
-	var out bytes.Buffer
-
-	elements := []string{}
-	for _, e := range o.Elements {
-		elements = append(elements, e.Inspect())
-	}
-
-	out.WriteString("[")
-	out.WriteString(strings.Join(elements, ", "))
-	out.WriteString("]")
-
-	return out.String()
+func (o *Quote) Inspect() string {
+	return "QUOTE(" + o.Node.String() + ")"
 }
 
-type Hashable interface {
-	HashKey() HashKey
+// Macro is a function from code to code, applied to the unevaluated
+// arguments of its calls before the program runs.
+type Macro struct {
+	Parameters []*ast.Identifier
+	Body       *ast.BlockStatement
+	Env        *Environment
 }
 
-type HashKey struct {
-	Type  ObjectType
-	Value uint64
+func (o *Macro) Type() ObjectType {
+	return MacroObj
 }
-
-func (b *Boolean) HashKey() HashKey {
-	var value uint64
-
-	if b.Value {
-		value = 1
-	} else {
-		value = 0
+func (o *Macro) Inspect() string {
+	var out bytes.Buffer
+	params := []string{}
+	for _, p := range o.Parameters {
+		params = append(params, p.String())
 	}
-
-	return HashKey{Type: b.Type(), Value: value}
+	out.WriteString("macro")
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") {\n")
+	out.WriteString(o.Body.String())
+	out.WriteString("\n}")
+	return out.String()
 }
 
-func (i *Integer) HashKey() HashKey {
-	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
+// Time is an instant together with the location it is displayed in.
+type Time struct {
+	Value time.Time
 }
 
-func (s *String) HashKey() HashKey {
-	h := fnv.New64a()
-	h.Write([]byte(s.Value))
+func (o *Time) Type() ObjectType {
+	return TimeObj
+}
+func (o *Time) Inspect() string {
+	return o.Value.Format(time.RFC3339Nano)
+}
 
-	return HashKey{Type: s.Type(), Value: h.Sum64()}
+// Module groups related builtins under a name, such as json.parse.
+type Module struct {
+	Name    string
+	Members map[string]Object
 }
 
-type HashPair struct {
-	Key   Object
-	Value Object
+func (o *Module) Type() ObjectType {
+	return ModuleObj
+}
+func (o *Module) Inspect() string {
+	return "module " + o.Name
 }
 
-type Hash struct {
-	Pairs map[HashKey]HashPair
+type Array struct {
+	Elements []Object
 }
 
-func (h *Hash) Type() ObjectType {
-	return HashObj
+func (o *Array) Type() ObjectType {
+	return ArrayObj
+}
+func (o *Array) Inspect() string {
+	return inspect(o, map[Object]bool{})
 }
 
-func (h *Hash) Inspect() string {
+// inspect renders obj, printing containers that are already being rendered
+// as [...] or {...} so that self-referencing values terminate.
+func inspect(obj Object, seen map[Object]bool) string {
 	var out bytes.Buffer
 
-	pairs := []string{}
-	for _, pair := range h.Pairs {
-		pairs = append(
-			pairs,
-			fmt.Sprintf("%s: %s", pair.Key.Inspect(), pair.Value.Inspect()))
+	switch o := obj.(type) {
+	case *Array:
+		if seen[o] {
+			return "[...]"
+		}
+		seen[o] = true
+		defer delete(seen, o)
+
+		elements := []string{}
+		for _, e := range o.Elements {
+			elements = append(elements, inspect(e, seen))
+		}
+
+		out.WriteString("[")
+		out.WriteString(strings.Join(elements, ", "))
+		out.WriteString("]")
+	case *Hash:
+		if seen[o] {
+			return "{...}"
+		}
+		seen[o] = true
+		defer delete(seen, o)
+
+		pairs := []string{}
+		for _, pair := range o.order {
+			pairs = append(pairs, fmt.Sprintf(
+				"%s: %s", inspect(pair.Key, seen), inspect(pair.Value, seen)))
+		}
+
+		out.WriteString("{")
+		out.WriteString(strings.Join(pairs, ", "))
+		out.WriteString("}")
+	default:
+		out.WriteString(obj.Inspect())
 	}
-	out.WriteString("{")
-	out.WriteString(strings.Join(pairs, ", "))
-	out.WriteString("}")
 
 	return out.String()
 }
@@ -1,6 +1,7 @@
 package object
 
 import (
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -16,3 +17,173 @@ func TestStringHashKey(t *testing.T) {
 	require.Equal(t, diff1.HashKey(), diff2.HashKey())
 	require.NotEqual(t, hello1.HashKey(), diff1.HashKey())
 }
+
+func TestHashPreservesInsertionOrder(t *testing.T) {
+	h := NewHash()
+	h.Set(&String{Value: "b"}, &Integer{Value: 1})
+	h.Set(&Integer{Value: 7}, &Integer{Value: 2})
+	h.Set(&String{Value: "a"}, &Integer{Value: 3})
+	h.Set(&String{Value: "b"}, &Integer{Value: 4})
+	require.Equal(t, "{b: 4, 7: 2, a: 3}", h.Inspect())
+
+	pair, ok := h.Delete(&Integer{Value: 7})
+	require.True(t, ok)
+	require.Equal(t, "2", pair.Value.Inspect())
+	_, ok = h.Delete(&Integer{Value: 7})
+	require.False(t, ok)
+
+	h.Set(&Integer{Value: 7}, &Integer{Value: 5})
+	require.Equal(t, "{b: 4, a: 3, 7: 5}", h.Inspect())
+	require.Equal(t, 3, h.Len())
+}
+
+type collidingKey struct {
+	name string
+}
+
+func (k *collidingKey) Type() ObjectType { return "COLLIDING" }
+func (k *collidingKey) Inspect() string  { return k.name }
+func (k *collidingKey) HashKey() HashKey { return HashKey{Type: k.Type(), Value: 42} }
+
+func TestHashKeyCollisions(t *testing.T) {
+	a := &collidingKey{name: "a"}
+	b := &collidingKey{name: "b"}
+	require.Equal(t, a.HashKey(), b.HashKey())
+
+	h := NewHash()
+	h.Set(a, &Integer{Value: 1})
+	h.Set(b, &Integer{Value: 2})
+	require.Equal(t, 2, h.Len())
+
+	pair, ok := h.Get(a)
+	require.True(t, ok)
+	require.Equal(t, "1", pair.Value.Inspect())
+	pair, ok = h.Get(b)
+	require.True(t, ok)
+	require.Equal(t, "2", pair.Value.Inspect())
+
+	_, ok = h.Delete(a)
+	require.True(t, ok)
+	_, ok = h.Get(a)
+	require.False(t, ok)
+	pair, ok = h.Get(b)
+	require.True(t, ok)
+	require.Equal(t, "2", pair.Value.Inspect())
+}
+
+func TestCompositeHashKeys(t *testing.T) {
+	key1 := &Array{Elements: []Object{&Integer{Value: 1}, &String{Value: "a"}}}
+	key2 := &Array{Elements: []Object{&Integer{Value: 1}, &String{Value: "a"}}}
+	key3 := &Array{Elements: []Object{&String{Value: "a"}, &Integer{Value: 1}}}
+
+	hashKey1, ok := HashKeyOf(key1)
+	require.True(t, ok)
+	hashKey2, ok := HashKeyOf(key2)
+	require.True(t, ok)
+	hashKey3, ok := HashKeyOf(key3)
+	require.True(t, ok)
+	require.Equal(t, hashKey1, hashKey2)
+	require.NotEqual(t, hashKey1, hashKey3)
+
+	_, ok = HashKeyOf(&Array{Elements: []Object{&Hash{}}})
+	require.False(t, ok)
+}
+
+func TestFloatHashKey(t *testing.T) {
+	require.Equal(t, (&Float{Value: 1.5}).HashKey(), (&Float{Value: 1.5}).HashKey())
+	require.Equal(t, (&Float{Value: 0}).HashKey(), (&Float{Value: math.Copysign(0, -1)}).HashKey())
+	require.Equal(t, (&Float{Value: 1}).HashKey(), (&Integer{Value: 1}).HashKey())
+	require.Equal(t, (&Float{Value: math.Copysign(0, -1)}).HashKey(), (&Integer{Value: 0}).HashKey())
+	require.NotEqual(t, (&Float{Value: 1 << 63}).HashKey(), (&Integer{Value: math.MinInt64}).HashKey())
+
+	_, ok := HashKeyOf(&Float{Value: math.NaN()})
+	require.False(t, ok)
+	_, ok = HashKeyOf(&Array{Elements: []Object{&Float{Value: math.NaN()}}})
+	require.False(t, ok)
+}
+
+func TestEqual(t *testing.T) {
+	one := &Integer{Value: 1}
+	nan := &Float{Value: math.NaN()}
+	cyclic := &Array{}
+	cyclic.Elements = []Object{one, cyclic}
+	otherCyclic := &Array{}
+	otherCyclic.Elements = []Object{one, otherCyclic}
+	hash := NewHash()
+	hash.Set(&String{Value: "self"}, hash)
+	otherHash := NewHash()
+	otherHash.Set(&String{Value: "self"}, otherHash)
+
+	testCases := []struct {
+		a, b     Object
+		expected bool
+	}{
+		{one, &Integer{Value: 1}, true},
+		{one, &Float{Value: 1}, true},
+		{&Integer{Value: 1<<53 + 1}, &Float{Value: 1 << 53}, false},
+		{&Float{Value: 1 << 63}, &Integer{Value: math.MaxInt64}, false},
+		{one, &String{Value: "1"}, false},
+		{nan, nan, false},
+		{&Null{}, &Null{}, true},
+		{&Boolean{Value: true}, &Boolean{Value: true}, true},
+		{&Array{Elements: []Object{one}}, &Array{Elements: []Object{one}}, true},
+		{&Array{Elements: []Object{one}}, &Array{}, false},
+		{cyclic, otherCyclic, true},
+		{hash, otherHash, true},
+		{hash, NewHash(), false},
+	}
+
+	for i, tc := range testCases {
+		require.Equal(t, tc.expected, Equal(tc.a, tc.b), "test case %d", i)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	testCases := []struct {
+		a, b     Object
+		expected int
+	}{
+		{&Integer{Value: 1}, &Integer{Value: 2}, -1},
+		{&Integer{Value: 2}, &Float{Value: 1.5}, 1},
+		{&Float{Value: 1.5}, &Integer{Value: 2}, -1},
+		{&Integer{Value: -1}, &Float{Value: -1.5}, 1},
+		{&Integer{Value: 0}, &Float{Value: -0.5}, 1},
+		{&Integer{Value: 3}, &Float{Value: 3}, 0},
+		{&Integer{Value: 1<<53 + 1}, &Float{Value: 1 << 53}, 1},
+		{&Integer{Value: math.MaxInt64}, &Float{Value: math.MaxInt64}, -1},
+		{&Float{Value: math.Inf(-1)}, &Integer{Value: math.MinInt64}, -1},
+		{&String{Value: "b"}, &String{Value: "a"}, 1},
+		{&Boolean{Value: false}, &Boolean{Value: true}, -1},
+		{&Null{}, &Null{}, 0},
+		{
+			&Array{Elements: []Object{&Integer{Value: 1}}},
+			&Array{Elements: []Object{&Integer{Value: 1}, &Integer{Value: 0}}},
+			-1,
+		},
+	}
+
+	for _, tc := range testCases {
+		c, err := Compare(tc.a, tc.b)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, c)
+		require.Equal(t, c == 0, Equal(tc.a, tc.b), "%s and %s", tc.a.Inspect(), tc.b.Inspect())
+	}
+
+	_, err := Compare(NewHash(), NewHash())
+	require.EqualError(t, err, "cannot compare HASH and HASH")
+	_, err = Compare(&Integer{Value: 1}, &String{Value: "1"})
+	require.EqualError(t, err, "cannot compare INTEGER and STRING")
+	_, err = Compare(&Float{Value: math.NaN()}, &Integer{Value: 1})
+	require.Error(t, err)
+}
+
+func TestInspectSelfReference(t *testing.T) {
+	arr := &Array{Elements: []Object{&Integer{Value: 1}}}
+	arr.Elements = append(arr.Elements, arr)
+	require.Equal(t, "[1, [...]]", arr.Inspect())
+
+	hash := NewHash()
+	hash.Set(&String{Value: "self"}, hash)
+	hash.Set(&String{Value: "arr"}, arr)
+	require.Equal(t, "{self: {...}, arr: [1, [...]]}", hash.Inspect())
+}
@@ -12,6 +12,7 @@ import (
 const (
 	_ int = iota
 	LOWEST
+	ASSIGN       // x[i] = y
 	EQUALS       // ==
 	LESS_GREATER // < or >
 	SUM          // +
@@ -22,6 +23,7 @@ const (
 )
 
 var precedences = map[token.TokenType]int{
+	token.Assign:      ASSIGN,
 	token.Equal:       EQUALS,
 	token.NotEqual:    EQUALS,
 	token.LessThan:    LESS_GREATER,
@@ -32,6 +34,7 @@ var precedences = map[token.TokenType]int{
 	token.Asterisk:    PRODUCT,
 	token.LeftParen:   CALL,
 	token.LeftBracket: INDEX,
+	token.Dot:         INDEX,
 }
 
 type (
@@ -57,6 +60,7 @@ func New(l *lexer.Lexer) *Parser {
 	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
 	p.registerPrefix(token.Identifier, p.parseIdentifier)
 	p.registerPrefix(token.Integer, p.parseIntegerLiteral)
+	p.registerPrefix(token.Float, p.parseFloatLiteral)
 	p.registerPrefix(token.Bang, p.parsePrefixExpression)
 	p.registerPrefix(token.Minus, p.parsePrefixExpression)
 	p.registerPrefix(token.True, p.parseBoolean)
@@ -64,6 +68,7 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.LeftParen, p.parseGroupedExpression)
 	p.registerPrefix(token.If, p.parseIfExpression)
 	p.registerPrefix(token.Function, p.parseFunctionLiteral)
+	p.registerPrefix(token.Macro, p.parseMacroLiteral)
 	p.registerPrefix(token.String, p.parseStringLiteral)
 	p.registerPrefix(token.LeftBracket, p.parseArrayLiteral)
 	p.registerPrefix(token.LeftBrace, p.parseHashLiteral)
@@ -79,6 +84,8 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.GreaterThan, p.parseInfixExpression)
 	p.registerInfix(token.LeftParen, p.parseCallExpression)
 	p.registerInfix(token.LeftBracket, p.parseIndexExpression)
+	p.registerInfix(token.Assign, p.parseAssignExpression)
+	p.registerInfix(token.Dot, p.parseMemberExpression)
 	return p
 }
 
@@ -133,9 +140,13 @@ func (p *Parser) noPrefixParseFnError(t token.TokenType) {
 	p.errors = append(p.errors, msg)
 }
 
+// nextToken advances to the next token, skipping comments.
 func (p *Parser) nextToken() {
 	p.curToken = p.peekToken
 	p.peekToken = p.l.NextToken()
+	for p.peekToken.Type == token.Comment {
+		p.peekToken = p.l.NextToken()
+	}
 }
 
 func (p *Parser) parseStatement() ast.Statement {
@@ -164,6 +175,15 @@ func (p *Parser) parseLetStatement() ast.Statement {
 		Value: p.curToken.Literal,
 	}
 
+	if p.peekToken.Type == token.Colon {
+		p.nextToken()
+		p.nextToken()
+		stmt.Type = p.parseType()
+		if stmt.Type == nil {
+			return nil
+		}
+	}
+
 	if p.peekToken.Type != token.Assign {
 		p.peekError(token.Assign)
 		return nil
@@ -245,6 +265,19 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	return literal
 }
 
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	literal := &ast.FloatLiteral{Token: p.curToken}
+	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
+	if err != nil {
+		msg := fmt.Sprintf("could not parse %q as float", p.curToken.Literal)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	literal.Value = value
+	return literal
+}
+
 func (p *Parser) parsePrefixExpression() ast.Expression {
 	expression := &ast.PrefixExpression{
 		Token:    p.curToken,
@@ -364,7 +397,19 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 	}
 
 	p.nextToken()
-	literal.Parameters = p.parseFunctionParameters()
+	literal.Parameters, literal.ParameterTypes = p.parseFunctionParameters()
+	if literal.Parameters == nil {
+		return nil
+	}
+
+	if p.peekToken.Type == token.Arrow {
+		p.nextToken()
+		p.nextToken()
+		literal.ReturnType = p.parseType()
+		if literal.ReturnType == nil {
+			return nil
+		}
+	}
 
 	if p.peekToken.Type != token.LeftBrace {
 		p.peekError(token.LeftBrace)
@@ -377,41 +422,169 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 	return literal
 }
 
-func (p *Parser) parseFunctionParameters() []*ast.Identifier {
-	identifers := []*ast.Identifier{}
+func (p *Parser) parseMacroLiteral() ast.Expression {
+	literal := &ast.MacroLiteral{
+		Token: p.curToken,
+	}
 
-	if p.peekToken.Type == token.RightParen {
-		p.nextToken()
-		return identifers
+	if p.peekToken.Type != token.LeftParen {
+		p.peekError(token.LeftParen)
+		return nil
 	}
 
 	p.nextToken()
+	var types []ast.TypeExpression
+	literal.Parameters, types = p.parseFunctionParameters()
+	if types != nil {
+		p.errors = append(p.errors, "macro parameters cannot have type annotations")
+		return nil
+	}
 
-	identifer := &ast.Identifier{
-		Token: p.curToken,
-		Value: p.curToken.Literal,
+	if p.peekToken.Type != token.LeftBrace {
+		p.peekError(token.LeftBrace)
+		return nil
 	}
 
-	identifers = append(identifers, identifer)
+	p.nextToken()
+	literal.Body = p.parseBlockStatement()
 
-	for p.peekToken.Type == token.Comma {
+	return literal
+}
+
+// parseFunctionParameters parses the parameters of a function and their
+// type annotations. The annotations are nil if there are none.
+func (p *Parser) parseFunctionParameters() ([]*ast.Identifier, []ast.TypeExpression) {
+	identifers := []*ast.Identifier{}
+	var types []ast.TypeExpression
+
+	if p.peekToken.Type == token.RightParen {
 		p.nextToken()
+		return identifers, nil
+	}
+
+	for {
 		p.nextToken()
-		identifer = &ast.Identifier{
+		identifers = append(identifers, &ast.Identifier{
 			Token: p.curToken,
 			Value: p.curToken.Literal,
+		})
+
+		var t ast.TypeExpression
+		if p.peekToken.Type == token.Colon {
+			p.nextToken()
+			p.nextToken()
+			if t = p.parseType(); t == nil {
+				return nil, nil
+			}
+			if types == nil {
+				types = make([]ast.TypeExpression, len(identifers)-1)
+			}
+		}
+		if types != nil {
+			types = append(types, t)
+		}
+
+		if p.peekToken.Type != token.Comma {
+			break
 		}
-		identifers = append(identifers, identifer)
+		p.nextToken()
 	}
 
 	if p.peekToken.Type != token.RightParen {
 		p.peekError(token.RightParen)
-		return nil
+		return nil, nil
 	}
 
 	p.nextToken()
 
-	return identifers
+	return identifers, types
+}
+
+// typeNames are the names that may be used in type annotations, besides
+// single lowercase letters.
+var typeNames = map[string]bool{
+	"int":    true,
+	"float":  true,
+	"string": true,
+	"bool":   true,
+	"null":   true,
+	"time":   true,
+	"any":    true,
+}
+
+// parseType parses a type annotation starting at the current token.
+func (p *Parser) parseType() ast.TypeExpression {
+	switch p.curToken.Type {
+	case token.Identifier:
+		name := p.curToken.Literal
+		isVar := len(name) == 1 && name[0] >= 'a' && name[0] <= 'z'
+		if !typeNames[name] && !isVar {
+			p.errors = append(p.errors, fmt.Sprintf("unknown type %s", name))
+			return nil
+		}
+		return &ast.NamedType{Token: p.curToken, Name: name}
+
+	case token.LeftBracket:
+		t := &ast.ArrayType{Token: p.curToken}
+		p.nextToken()
+		if t.Elem = p.parseType(); t.Elem == nil || !p.expectPeek(token.RightBracket) {
+			return nil
+		}
+		return t
+
+	case token.LeftBrace:
+		t := &ast.HashType{Token: p.curToken}
+		p.nextToken()
+		if t.Key = p.parseType(); t.Key == nil || !p.expectPeek(token.Colon) {
+			return nil
+		}
+		p.nextToken()
+		if t.Value = p.parseType(); t.Value == nil || !p.expectPeek(token.RightBrace) {
+			return nil
+		}
+		return t
+
+	case token.Function:
+		t := &ast.FunctionType{Token: p.curToken, Parameters: []ast.TypeExpression{}}
+		if !p.expectPeek(token.LeftParen) {
+			return nil
+		}
+		for p.peekToken.Type != token.RightParen {
+			if len(t.Parameters) > 0 && !p.expectPeek(token.Comma) {
+				return nil
+			}
+			p.nextToken()
+			param := p.parseType()
+			if param == nil {
+				return nil
+			}
+			t.Parameters = append(t.Parameters, param)
+		}
+		p.nextToken()
+		if !p.expectPeek(token.Arrow) {
+			return nil
+		}
+		p.nextToken()
+		if t.Result = p.parseType(); t.Result == nil {
+			return nil
+		}
+		return t
+
+	default:
+		p.errors = append(p.errors, fmt.Sprintf("expected a type, got %s", p.curToken.Type))
+		return nil
+	}
+}
+
+// expectPeek advances to the next token if it has type t, and records an
+// error otherwise.
+func (p *Parser) expectPeek(t token.TokenType) bool {
+	if p.peekToken.Type != t {
+		p.peekError(t)
+		return false
+	}
+	p.nextToken()
+	return true
 }
 
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
@@ -469,9 +642,42 @@ func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	return exp
 }
 
+func (p *Parser) parseMemberExpression(left ast.Expression) ast.Expression {
+	exp := &ast.MemberExpression{Token: p.curToken, Left: left}
+
+	if p.peekToken.Type != token.Identifier {
+		p.peekError(token.Identifier)
+		return nil
+	}
+
+	p.nextToken()
+	exp.Property = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	return exp
+}
+
+func (p *Parser) parseAssignExpression(target ast.Expression) ast.Expression {
+	index, ok := target.(*ast.IndexExpression)
+	if !ok {
+		msg := "invalid assignment target"
+		if target != nil {
+			msg = fmt.Sprintf("cannot assign to %s", target.String())
+		}
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	expr := &ast.AssignExpression{Token: p.curToken, Target: index}
+
+	p.nextToken()
+	// Assignment is right-associative: a[0] = b[0] = 1.
+	expr.Value = p.parseExpression(ASSIGN - 1)
+
+	return expr
+}
+
 func (p *Parser) parseHashLiteral() ast.Expression {
 	hash := &ast.HashLiteral{Token: p.curToken}
-	hash.Pairs = make(map[ast.Expression]ast.Expression)
+	hash.Pairs = []ast.HashPair{}
 	for p.peekToken.Type != token.RightBrace {
 		p.nextToken()
 
@@ -484,7 +690,7 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 		p.nextToken()
 		p.nextToken()
 		value := p.parseExpression(LOWEST)
-		hash.Pairs[key] = value
+		hash.Pairs = append(hash.Pairs, ast.HashPair{Key: key, Value: value})
 
 		if p.peekToken.Type != token.RightBrace {
 			if p.peekToken.Type == token.Comma {
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"encoding/json"
 	"fmt"
 	"testing"
 
@@ -172,6 +173,24 @@ func TestIntegerLiteralExpression(t *testing.T) {
 	testIntegerLiteral(t, stmt.Expression, int64(5))
 }
 
+func TestFloatLiteralExpression(t *testing.T) {
+	input := "2.5;"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	require.Len(t, p.Errors(), 0)
+	require.Len(t, program.Statements, 1)
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	require.True(t, ok)
+
+	literal, ok := stmt.Expression.(*ast.FloatLiteral)
+	require.True(t, ok)
+	require.Equal(t, 2.5, literal.Value)
+	require.Equal(t, "2.5", literal.TokenLiteral())
+}
+
 func TestParsingPrefixExpressions(t *testing.T) {
 	testCases := []struct {
 		input    string
@@ -362,6 +381,94 @@ func TestFunctionLiteralParsing(t *testing.T) {
 	testInfixExpression(t, body.Expression, "x", "+", "y")
 }
 
+func TestTypeAnnotationParsing(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"let x: int = 5;", "let x: int = 5;"},
+		{"let xs: [string] = [];", "let xs: [string] = [];"},
+		{"let h: {string: [a]} = {};", "let h: {string: [a]} = {};"},
+		{"let f: fn(int, fn() -> null) -> bool = g;", "let f: fn(int, fn() -> null) -> bool = g;"},
+		{"fn(a: string, b: [int]) -> bool { true }", "fn(a: string,b: [int]) -> bool true"},
+		{"fn(a, b: float) { a }", "fn(a,b: float)a"},
+		{"fn(a) -> any { a }", "fn(a) -> any a"},
+	}
+
+	for _, tc := range testCases {
+		p := New(lexer.New(tc.input))
+		program := p.ParseProgram()
+		require.Len(t, p.Errors(), 0, tc.input)
+		require.Equal(t, tc.expected, program.String())
+	}
+}
+
+func TestFunctionParameterTypes(t *testing.T) {
+	p := New(lexer.New("fn(a, b: int, c) -> string {}; fn(a, b) {}"))
+	program := p.ParseProgram()
+	require.Len(t, p.Errors(), 0)
+
+	annotated := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+	require.Len(t, annotated.ParameterTypes, 3)
+	require.Nil(t, annotated.ParameterType(0))
+	require.Equal(t, "int", annotated.ParameterType(1).String())
+	require.Nil(t, annotated.ParameterType(2))
+	require.Equal(t, "string", annotated.ReturnType.String())
+
+	plain := program.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+	require.Nil(t, plain.ParameterTypes)
+	require.Nil(t, plain.ReturnType)
+}
+
+func TestTypeAnnotationErrors(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"let x: integer = 5;", "unknown type integer"},
+		{"let x: 5 = 5;", "expected a type, got INTEGER"},
+		{"let x: [int = 5;", "expected next token to be ], got = instead"},
+		{"let x: {int} = 5;", "expected next token to be :, got } instead"},
+		{"let f: fn(int) int = g;", "expected next token to be ->, got IDENTIFIER instead"},
+		{"fn(x:) {}", "expected a type, got )"},
+		{"fn(x) -> {}", "expected a type, got }"},
+		{"macro(x: int) { x }", "macro parameters cannot have type annotations"},
+	}
+
+	for _, tc := range testCases {
+		p := New(lexer.New(tc.input))
+		p.ParseProgram()
+		require.Contains(t, p.Errors(), tc.expected, tc.input)
+	}
+}
+
+func TestMacroLiteralParsing(t *testing.T) {
+	input := `macro(x, y) { x + y; }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	require.Len(t, p.Errors(), 0)
+	require.Len(t, program.Statements, 1)
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	require.True(t, ok)
+
+	macro, ok := stmt.Expression.(*ast.MacroLiteral)
+	require.True(t, ok)
+	require.Len(t, macro.Parameters, 2)
+
+	testLiteralExpression(t, macro.Parameters[0], "x")
+	testLiteralExpression(t, macro.Parameters[1], "y")
+
+	require.Len(t, macro.Body.Statements, 1)
+
+	body, ok := macro.Body.Statements[0].(*ast.ExpressionStatement)
+	require.True(t, ok)
+
+	testInfixExpression(t, body.Expression, "x", "+", "y")
+}
+
 func TestCallExpressionParsing(t *testing.T) {
 	input := "add(1, 2 * 3, 4 + 5);"
 
@@ -513,17 +620,27 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 }
 
 func TestStringLiteralExpression(t *testing.T) {
-	input := `"hello world";`
-	l := lexer.New(input)
-	p := New(l)
-	program := p.ParseProgram()
-	require.Len(t, p.Errors(), 0)
-	require.NotNil(t, program)
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{`"hello world";`, "hello world"},
+		{`"say \"hi\"\n";`, "say \"hi\"\n"},
+		{`"a\\b\tc";`, "a\\b\tc"},
+	}
 
-	stmt := program.Statements[0].(*ast.ExpressionStatement)
-	literal, ok := stmt.Expression.(*ast.StringLiteral)
-	require.True(t, ok)
-	require.Equal(t, "hello world", literal.Value)
+	for _, tc := range testCases {
+		l := lexer.New(tc.input)
+		p := New(l)
+		program := p.ParseProgram()
+		require.Len(t, p.Errors(), 0)
+		require.NotNil(t, program)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		literal, ok := stmt.Expression.(*ast.StringLiteral)
+		require.True(t, ok)
+		require.Equal(t, tc.expected, literal.Value)
+	}
 }
 
 func TestParsingArrayLiterals(t *testing.T) {
@@ -564,6 +681,78 @@ func TestParsingIndexExpressions(t *testing.T) {
 	testInfixExpression(t, indexExp.Index, 1, "+", 1)
 }
 
+func TestParsingMemberExpressions(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"json.parse", "json.parse"},
+		{"json.parse(s)", "json.parse(s)"},
+		{"a.b.c", "a.b.c"},
+		{"a.b[0]", "(a.b[0])"},
+		{"-a.b", "(-a.b)"},
+		{"a.b * c.d", "(a.b * c.d)"},
+		{"f(x).y", "f(x).y"},
+		{`{"k": 1}.k`, "{k:1}.k"},
+	}
+
+	for _, tc := range testCases {
+		l := lexer.New(tc.input)
+		p := New(l)
+		program := p.ParseProgram()
+		require.Len(t, p.Errors(), 0)
+		require.Equal(t, tc.expected, program.String())
+	}
+
+	l := lexer.New("a.1")
+	p := New(l)
+	p.ParseProgram()
+	require.Contains(t, p.Errors(), "expected next token to be IDENTIFIER, got INTEGER instead")
+}
+
+func TestParsingAssignExpressions(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"a[0] = 1", "(a[0]) = 1"},
+		{"a[i + 1] = b * 2", "(a[(i + 1)]) = (b * 2)"},
+		{"a[0] = b[0] = 1", "(a[0]) = (b[0]) = 1"},
+		{`h["k"] = fn(x) { x }`, "(h[k]) = fn(x)x"},
+	}
+
+	for _, tc := range testCases {
+		l := lexer.New(tc.input)
+		p := New(l)
+		program := p.ParseProgram()
+		require.Len(t, p.Errors(), 0)
+		require.Len(t, program.Statements, 1)
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		require.True(t, ok)
+		_, ok = stmt.Expression.(*ast.AssignExpression)
+		require.True(t, ok)
+		require.Equal(t, tc.expected, program.String())
+	}
+}
+
+func TestParsingInvalidAssignTargets(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"a = 1", "cannot assign to a"},
+		{"a + b[0] = 1", "cannot assign to (a + (b[0]))"},
+	}
+
+	for _, tc := range testCases {
+		l := lexer.New(tc.input)
+		p := New(l)
+		p.ParseProgram()
+		require.Contains(t, p.Errors(), tc.expected)
+	}
+}
+
 func TestParsingHashLiteralsStringKeys(t *testing.T) {
 	input := `{"one": 1, "two": 2, "three": 3}`
 	l := lexer.New(input)
@@ -584,7 +773,8 @@ func TestParsingHashLiteralsStringKeys(t *testing.T) {
 		"two":   2,
 		"three": 3,
 	}
-	for key, value := range hash.Pairs {
+	for _, pair := range hash.Pairs {
+		key, value := pair.Key, pair.Value
 		literal, ok := key.(*ast.StringLiteral)
 		require.True(t, ok)
 		expectedValue := expected[literal.String()]
@@ -629,7 +819,8 @@ func TestParsingHashLiteralsBooleanKeys(t *testing.T) {
 
 	require.Equal(t, len(expected), len(hash.Pairs))
 
-	for key, value := range hash.Pairs {
+	for _, pair := range hash.Pairs {
+		key, value := pair.Key, pair.Value
 		boolean, ok := key.(*ast.Boolean)
 		require.True(t, ok)
 
@@ -660,7 +851,8 @@ func TestParsingHashLiteralsIntegerKeys(t *testing.T) {
 
 	require.Equal(t, len(expected), len(hash.Pairs))
 
-	for key, value := range hash.Pairs {
+	for _, pair := range hash.Pairs {
+		key, value := pair.Key, pair.Value
 		integer, ok := key.(*ast.IntegerLiteral)
 		require.True(t, ok)
 
@@ -698,7 +890,8 @@ func TestParsingHashLiteralsWithExpressions(t *testing.T) {
 		},
 	}
 
-	for key, value := range hash.Pairs {
+	for _, pair := range hash.Pairs {
+		key, value := pair.Key, pair.Value
 		literal, ok := key.(*ast.StringLiteral)
 		require.True(t, ok)
 
@@ -708,3 +901,22 @@ func TestParsingHashLiteralsWithExpressions(t *testing.T) {
 		testFunc(value)
 	}
 }
+
+func TestProgramJSONRoundTrip(t *testing.T) {
+	input := `
+let fib = fn(n) { if (n < 2) { return n; } else { fib(n - 1) + fib(n - 2) } };
+let h = {"a": [1, 2.5, -3], true: !false, 4: json.parse("{}")};
+h["a"][0] = fib(10); // comment
+let f: fn([int], a) -> {string: a} = fn(xs: [int], y, z: a) -> {string: a} { {"y": z} };
+`
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+	require.Len(t, p.Errors(), 0)
+
+	data, err := json.Marshal(program)
+	require.NoError(t, err)
+
+	decoded := &ast.Program{}
+	require.NoError(t, json.Unmarshal(data, decoded))
+	require.Equal(t, program, decoded)
+}
@@ -0,0 +1,598 @@
+// Package printer renders syntax trees as Monkey source code.
+//
+// Unlike the String methods of the ast package, which are meant for
+// debugging, the output of Print parses back into an equivalent tree.
+// Format additionally keeps the comments and blank lines of the source it
+// reformats.
+package printer
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/vancanhuit/monkey/internal/ast"
+	"github.com/vancanhuit/monkey/internal/lexer"
+	"github.com/vancanhuit/monkey/internal/parser"
+	"github.com/vancanhuit/monkey/internal/token"
+)
+
+const (
+	indent = "    "
+	// Width is the line length beyond which call arguments, array elements
+	// and hash pairs are written one per line.
+	Width = 80
+)
+
+// Binding strength of expressions, mirroring the precedences used by the
+// parser. Parentheses are only added where a weaker expression appears in
+// the operand of a stronger one.
+const (
+	_ int = iota
+	lowest
+	assign
+	equals
+	lessGreater
+	sum
+	product
+	prefix
+	call
+	index
+	primary
+)
+
+var precedences = map[string]int{
+	"==": equals,
+	"!=": equals,
+	"<":  lessGreater,
+	">":  lessGreater,
+	"+":  sum,
+	"-":  sum,
+	"*":  product,
+	"/":  product,
+}
+
+// Print returns the source code for node in canonical layout: one statement
+// per line, blocks indented by four spaces, blocks holding a single short
+// statement kept on one line, and lists wrapped to stay within Width.
+func Print(node ast.Node) string {
+	p := &printer{}
+	p.node(node)
+	return p.out.String()
+}
+
+// Format parses src and returns it in the layout produced by Print, keeping
+// its comments, its "#!" line and single blank lines between statements.
+func Format(src []byte) ([]byte, error) {
+	input := string(src)
+	shebang := ""
+	if strings.HasPrefix(input, "#!") {
+		end := strings.IndexByte(input, '\n') + 1
+		if end == 0 {
+			end = len(input)
+		}
+		shebang = strings.TrimRight(input[:end], "\r\n") + "\n"
+	}
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return nil, errors.New(strings.Join(p.Errors(), "\n"))
+	}
+
+	pr := &printer{positions: make(map[pos]int)}
+	l := lexer.New(input)
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		if tok.Type == token.Comment {
+			pr.comments = append(pr.comments, tok)
+			continue
+		}
+		pr.positions[posOf(tok)] = len(pr.tokens)
+		pr.tokens = append(pr.tokens, tok)
+	}
+	pr.node(program)
+	return []byte(shebang + pr.out.String()), nil
+}
+
+// pos is a position in the source. The zero pos is used for nodes that did
+// not come from source, which never have comments around them.
+type pos struct {
+	line, column int
+}
+
+func posOf(tok token.Token) pos {
+	return pos{tok.Line, tok.Column}
+}
+
+func (a pos) before(b pos) bool {
+	return a.line < b.line || a.line == b.line && a.column < b.column
+}
+
+// end is a position after any other.
+var end = pos{line: int(^uint(0) >> 1)}
+
+type printer struct {
+	out   strings.Builder
+	depth int
+	col   int
+
+	// The fields below are only set by Format. tokens holds the source
+	// tokens other than comments, indexed by position in positions, and
+	// comments[next:] are the comments not yet written. lastLine is the
+	// source line of the last token or comment written.
+	tokens    []token.Token
+	positions map[pos]int
+	comments  []token.Token
+	next      int
+	lastLine  int
+}
+
+func (p *printer) write(s string) {
+	p.out.WriteString(s)
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		p.col = utf8.RuneCountInString(s[i+1:])
+	} else {
+		p.col += utf8.RuneCountInString(s)
+	}
+}
+
+// startLine begins a new line for something found on the given source
+// line, keeping one blank line before it if there was at least one in the
+// source and it is not the first thing in its block or list.
+func (p *printer) startLine(line int, first bool) {
+	if p.out.Len() == 0 {
+		return
+	}
+	if !first && p.lastLine > 0 && line > p.lastLine+1 {
+		p.write("\n")
+	}
+	p.write("\n" + strings.Repeat(indent, p.depth))
+}
+
+// trial returns a printer that continues from the state of p but writes to
+// a separate buffer, so that a layout can be tried and then committed or
+// discarded.
+func (p *printer) trial() *printer {
+	return &printer{
+		depth:     p.depth,
+		col:       p.col,
+		tokens:    p.tokens,
+		positions: p.positions,
+		comments:  p.comments,
+		next:      p.next,
+		lastLine:  p.lastLine,
+	}
+}
+
+func (p *printer) commit(t *printer) {
+	p.out.WriteString(t.out.String())
+	p.col, p.next, p.lastLine = t.col, t.next, t.lastLine
+}
+
+// commentBefore returns the next comment to be written if it comes before
+// limit.
+func (p *printer) commentBefore(limit pos) (token.Token, bool) {
+	if p.next < len(p.comments) && posOf(p.comments[p.next]).before(limit) {
+		return p.comments[p.next], true
+	}
+	return token.Token{}, false
+}
+
+func (p *printer) hasCommentBetween(from, to pos) bool {
+	for _, c := range p.comments[p.next:] {
+		if !posOf(c).before(to) {
+			break
+		}
+		if from.before(posOf(c)) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeCommentsBefore writes each comment that comes before limit on a line
+// of its own, and reports whether it wrote any.
+func (p *printer) writeCommentsBefore(limit pos, first bool) bool {
+	wrote := false
+	for c, ok := p.commentBefore(limit); ok; c, ok = p.commentBefore(limit) {
+		p.startLine(c.Line, first && !wrote)
+		p.write(c.Literal)
+		p.lastLine = c.Line
+		p.next++
+		wrote = true
+	}
+	return wrote
+}
+
+// writeTrailingComment writes the next comment at the end of the current
+// line if it followed, on the same line, the last source token before limit.
+func (p *printer) writeTrailingComment(limit pos) {
+	p.lastLine = p.lineBefore(limit)
+	if c, ok := p.commentBefore(limit); ok && c.Line == p.lastLine {
+		p.write(" " + c.Literal)
+		p.next++
+	}
+}
+
+// lineBefore returns the source line of the last token before limit.
+func (p *printer) lineBefore(limit pos) int {
+	i := sort.Search(len(p.tokens), func(i int) bool {
+		return !posOf(p.tokens[i]).before(limit)
+	})
+	if i == 0 {
+		return 0
+	}
+	return p.tokens[i-1].Line
+}
+
+// closing returns the position of the bracket closing the one opened by
+// open, or end if open did not come from the source.
+func (p *printer) closing(open token.Token) pos {
+	i, ok := p.positions[posOf(open)]
+	if !ok {
+		return end
+	}
+	depth := 0
+	for ; i < len(p.tokens); i++ {
+		switch p.tokens[i].Type {
+		case token.LeftParen, token.LeftBracket, token.LeftBrace:
+			depth++
+		case token.RightParen, token.RightBracket, token.RightBrace:
+			depth--
+			if depth == 0 {
+				return posOf(p.tokens[i])
+			}
+		}
+	}
+	return end
+}
+
+func (p *printer) node(node ast.Node) {
+	switch n := node.(type) {
+	case *ast.Program:
+		p.statements(n.Statements, end, false)
+		if p.out.Len() > 0 {
+			p.write("\n")
+		}
+	case ast.Statement:
+		p.statement(n)
+	case ast.Expression:
+		p.expression(n, lowest)
+	}
+}
+
+// statements writes stmts one per line, along with the comments that come
+// before close. The last statement of a block is followed by "}" and needs
+// no terminator, unlike that of a program.
+func (p *printer) statements(stmts []ast.Statement, close pos, inBlock bool) {
+	isFirst := true
+	for i, stmt := range stmts {
+		start := posOf(ast.Start(stmt))
+		if p.writeCommentsBefore(start, isFirst) {
+			isFirst = false
+		}
+		p.startLine(start.line, isFirst)
+		isFirst = false
+
+		p.statement(stmt)
+		limit := close
+		if i < len(stmts)-1 {
+			limit = posOf(ast.Start(stmts[i+1]))
+			if needsSemicolon(stmt, stmts[i+1:]) {
+				p.write(";")
+			}
+		} else if !inBlock && needsSemicolon(stmt, nil) {
+			p.write(";")
+		}
+		p.writeTrailingComment(limit)
+	}
+	p.writeCommentsBefore(close, isFirst)
+}
+
+// needsSemicolon reports whether stmt, followed by the statements in rest,
+// is written with a terminating semicolon. Statements ending in a block, as
+// an if expression does, are only terminated when the next statement could
+// otherwise be read as an operand of them.
+func needsSemicolon(stmt ast.Statement, rest []ast.Statement) bool {
+	es, ok := stmt.(*ast.ExpressionStatement)
+	if !ok {
+		return true
+	}
+	if _, ok := es.Expression.(*ast.IfExpression); !ok {
+		return true
+	}
+	if len(rest) == 0 {
+		return false
+	}
+	next := Print(rest[0])
+	return strings.HasPrefix(next, "(") || strings.HasPrefix(next, "[") ||
+		strings.HasPrefix(next, "-")
+}
+
+func (p *printer) statement(stmt ast.Statement) {
+	switch s := stmt.(type) {
+	case *ast.LetStatement:
+		p.write("let " + s.Name.Value)
+		if s.Type != nil {
+			p.write(": " + s.Type.String())
+		}
+		p.write(" = ")
+		p.expression(s.Value, lowest)
+	case *ast.ReturnStatement:
+		p.write("return")
+		if s.Value != nil {
+			p.write(" ")
+			p.expression(s.Value, lowest)
+		}
+	case *ast.ExpressionStatement:
+		p.expression(s.Expression, lowest)
+	case *ast.BlockStatement:
+		p.block(s)
+	}
+}
+
+func (p *printer) block(block *ast.BlockStatement) {
+	var stmts []ast.Statement
+	if block != nil {
+		stmts = block.Statements
+	}
+	open := ast.Start(block)
+	close := p.closing(open)
+	hasComments := p.hasCommentBetween(posOf(open), close)
+
+	if len(stmts) == 0 && !hasComments {
+		p.write("{}")
+		return
+	}
+	if len(stmts) == 1 && !hasComments {
+		t := p.trial()
+		t.write("{ ")
+		t.statement(stmts[0])
+		t.write(" }")
+		if s := t.out.String(); !strings.Contains(s, "\n") && t.col <= Width {
+			p.commit(t)
+			return
+		}
+	}
+
+	p.write("{")
+	p.depth++
+	p.lastLine = open.Line
+	p.statements(stmts, close, true)
+	p.depth--
+	p.write("\n" + strings.Repeat(indent, p.depth) + "}")
+	p.lastLine = close.line
+}
+
+// expression writes expr, wrapped in parentheses if it binds less tightly
+// than min requires.
+func (p *printer) expression(expr ast.Expression, min int) {
+	if precedence(expr) < min {
+		p.write("(")
+		p.expression(expr, lowest)
+		p.write(")")
+		return
+	}
+
+	switch e := expr.(type) {
+	case *ast.Identifier:
+		p.write(e.Value)
+	case *ast.IntegerLiteral:
+		p.write(strconv.FormatInt(e.Value, 10))
+	case *ast.FloatLiteral:
+		s := strconv.FormatFloat(e.Value, 'f', -1, 64)
+		if !strings.Contains(s, ".") {
+			s += ".0"
+		}
+		p.write(s)
+	case *ast.Boolean:
+		p.write(strconv.FormatBool(e.Value))
+	case *ast.StringLiteral:
+		p.write(Quote(e.Value))
+	case *ast.PrefixExpression:
+		p.write(e.Operator)
+		p.expression(e.Right, prefix)
+	case *ast.InfixExpression:
+		prec := precedences[e.Operator]
+		p.expression(e.Left, prec)
+		p.write(" " + e.Operator + " ")
+		p.expression(e.Right, prec+1)
+	case *ast.AssignExpression:
+		p.expression(e.Target, assign+1)
+		p.write(" = ")
+		p.expression(e.Value, assign)
+	case *ast.IfExpression:
+		p.write("if (")
+		p.expression(e.Condition, lowest)
+		p.write(") ")
+		p.block(e.Consequence)
+		if e.Alternative != nil {
+			p.write(" else ")
+			p.block(e.Alternative)
+		}
+	case *ast.FunctionLiteral:
+		p.write("fn")
+		p.parameters(e.Parameters, e.ParameterTypes, e.ReturnType)
+		p.block(e.Body)
+	case *ast.MacroLiteral:
+		p.write("macro")
+		p.parameters(e.Parameters, nil, nil)
+		p.block(e.Body)
+	case *ast.CallExpression:
+		p.expression(e.Function, call)
+		p.list(e.Token, "(", ")", expressionItems(e.Arguments))
+	case *ast.ArrayLiteral:
+		p.list(e.Token, "[", "]", expressionItems(e.Elements))
+	case *ast.IndexExpression:
+		p.expression(e.Left, call)
+		p.write("[")
+		p.expression(e.Index, lowest)
+		p.write("]")
+	case *ast.MemberExpression:
+		p.expression(e.Left, call)
+		p.write("." + e.Property.Value)
+	case *ast.HashLiteral:
+		items := make([]listItem, len(e.Pairs))
+		for i, pair := range e.Pairs {
+			pair := pair
+			items[i] = listItem{
+				start: ast.Start(pair.Key),
+				hug:   endsInFunction(pair.Value),
+				write: func(p *printer) {
+					p.expression(pair.Key, lowest)
+					p.write(": ")
+					p.expression(pair.Value, lowest)
+				},
+			}
+		}
+		p.list(e.Token, "{", "}", items)
+	}
+}
+
+// parameters prints a parameter list and the annotations given, which are
+// nil when there are none.
+func (p *printer) parameters(params []*ast.Identifier, types []ast.TypeExpression, result ast.TypeExpression) {
+	p.write("(")
+	for i, param := range params {
+		if i > 0 {
+			p.write(", ")
+		}
+		p.write(param.Value)
+		if i < len(types) && types[i] != nil {
+			p.write(": " + types[i].String())
+		}
+	}
+	p.write(")")
+	if result != nil {
+		p.write(" -> " + result.String())
+	}
+	p.write(" ")
+}
+
+// listItem is an element of a bracketed, comma-separated list. hug is set
+// for items ending in a function body, which may span several lines while
+// the list stays on one.
+type listItem struct {
+	start token.Token
+	hug   bool
+	write func(p *printer)
+}
+
+// endsInFunction reports whether expr is a function or a macro, or a call
+// whose last argument ends in one.
+func endsInFunction(expr ast.Expression) bool {
+	switch e := expr.(type) {
+	case *ast.FunctionLiteral, *ast.MacroLiteral:
+		return true
+	case *ast.CallExpression:
+		return len(e.Arguments) > 0 && endsInFunction(e.Arguments[len(e.Arguments)-1])
+	}
+	return false
+}
+
+func expressionItems(exprs []ast.Expression) []listItem {
+	items := make([]listItem, len(exprs))
+	for i, expr := range exprs {
+		expr := expr
+		items[i] = listItem{
+			start: ast.Start(expr),
+			hug:   endsInFunction(expr),
+			write: func(p *printer) { p.expression(expr, lowest) },
+		}
+	}
+	return items
+}
+
+// list writes items between the brackets left and right, whose opening
+// bracket in the source is open. The items go on one line if they fit
+// within Width, or if only the last of them spans several lines, being a
+// function, and the first line fits. Otherwise, and whenever the list holds
+// comments, each item goes on a line of its own.
+func (p *printer) list(open token.Token, left, right string, items []listItem) {
+	close := p.closing(open)
+	if !p.hasCommentBetween(posOf(open), close) {
+		t := p.trial()
+		t.write(left)
+		lastStart := 0
+		for i, item := range items {
+			if i > 0 {
+				t.write(", ")
+			}
+			lastStart = t.out.Len()
+			item.write(t)
+		}
+		t.write(right)
+
+		s := t.out.String()
+		firstLine, _, multiline := strings.Cut(s, "\n")
+		if !multiline && t.col <= Width ||
+			multiline && items[len(items)-1].hug && !strings.Contains(s[:lastStart], "\n") &&
+				p.col+utf8.RuneCountInString(firstLine) <= Width {
+			p.commit(t)
+			return
+		}
+	}
+
+	p.write(left)
+	p.depth++
+	p.lastLine = open.Line
+	for i, item := range items {
+		start := posOf(item.start)
+		wrote := p.writeCommentsBefore(start, i == 0)
+		p.startLine(start.line, i == 0 && !wrote)
+		item.write(p)
+		limit := close
+		if i < len(items)-1 {
+			p.write(",")
+			limit = posOf(items[i+1].start)
+		}
+		p.writeTrailingComment(limit)
+	}
+	p.writeCommentsBefore(close, len(items) == 0)
+	p.depth--
+	p.write("\n" + strings.Repeat(indent, p.depth) + right)
+	p.lastLine = close.line
+}
+
+func precedence(expr ast.Expression) int {
+	switch e := expr.(type) {
+	case *ast.AssignExpression:
+		return assign
+	case *ast.InfixExpression:
+		return precedences[e.Operator]
+	case *ast.PrefixExpression:
+		return prefix
+	case *ast.CallExpression:
+		return call
+	case *ast.IndexExpression, *ast.MemberExpression:
+		return index
+	}
+	return primary
+}
+
+// Quote returns s as a string literal, escaping the characters that the
+// lexer would otherwise misread.
+func Quote(s string) string {
+	var out strings.Builder
+	out.WriteByte('"')
+	for _, ch := range s {
+		switch ch {
+		case '"':
+			out.WriteString(`\"`)
+		case '\\':
+			out.WriteString(`\\`)
+		case '\n':
+			out.WriteString(`\n`)
+		case '\r':
+			out.WriteString(`\r`)
+		case '\t':
+			out.WriteString(`\t`)
+		default:
+			out.WriteRune(ch)
+		}
+	}
+	out.WriteByte('"')
+	return out.String()
+}
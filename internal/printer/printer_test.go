@@ -0,0 +1,201 @@
+package printer
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vancanhuit/monkey/internal/ast"
+	"github.com/vancanhuit/monkey/internal/lexer"
+	"github.com/vancanhuit/monkey/internal/parser"
+	"github.com/vancanhuit/monkey/internal/token"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	require.Empty(t, p.Errors(), input)
+	return program
+}
+
+func TestPrint(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"let x=1", "let x = 1;\n"},
+		{"1+2*3; (1+2)*3", "1 + 2 * 3;\n(1 + 2) * 3;\n"},
+		{"a-(b-c); (a-b)-c", "a - (b - c);\na - b - c;\n"},
+		{"-(a+b); -a+b; !-x", "-(a + b);\n-a + b;\n!-x;\n"},
+		{"(-a)[0]; -a[0]; f(1)[0]; (a+b)(1)", "(-a)[0];\n-a[0];\nf(1)[0];\n(a + b)(1);\n"},
+		{"a[0]=b[1]=2; (a[0]=1)+1", "a[0] = b[1] = 2;\n(a[0] = 1) + 1;\n"},
+		{"1.50; 2.0; true", "1.5;\n2.0;\ntrue;\n"},
+		{`"a\"b\\c\n"`, `"a\"b\\c\n";` + "\n"},
+		{`{"a": [1, 2], 3: fn(){}}`, `{"a": [1, 2], 3: fn() {}};` + "\n"},
+		{"json.stringify(x.y)", "json.stringify(x.y);\n"},
+		{"let f = fn(x, y) { x + y }", "let f = fn(x, y) { x + y };\n"},
+		{"let m = macro(x, y) { quote(unquote(y) - unquote(x)) }", "let m = macro(x, y) { quote(unquote(y) - unquote(x)) };\n"},
+		{"if (x) { 1 } else { return 2; }", "if (x) { 1 } else { return 2 }\n"},
+		{
+			"let f = fn(x) { let y = x * 2; if (y > 2) { puts(y); y } else { 0 } };",
+			"let f = fn(x) {\n    let y = x * 2;\n    if (y > 2) {\n        puts(y);\n        y\n    } else { 0 }\n};\n",
+		},
+		{"if (x) { 1 }; [1]; if (y) { 2 } a", "if (x) { 1 };\n[1];\nif (y) { 2 }\na;\n"},
+		{"let x:int=1", "let x: int = 1;\n"},
+		{"let f=fn(a:[int],b)->{string:a}{b}", "let f = fn(a: [int], b) -> {string: a} { b };\n"},
+		{"let g:fn(int,fn()->null)->bool=h", "let g: fn(int, fn() -> null) -> bool = h;\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			require.Equal(t, tc.expected, Print(parse(t, tc.input)))
+		})
+	}
+}
+
+func TestPrintRoundTrip(t *testing.T) {
+	inputs := []string{
+		`let fib = fn(n) { if (n < 2) { return n; }; fib(n - 1) + fib(n - 2) }; puts(fib(10));`,
+		`let m = {"k": [1, -2.5, "s\t"], true: fn(a) { a[0] = a[1] = !a[2] }}; m["k"][2]`,
+		`let apply = fn(f, xs) { map(xs, fn(x) { f(f(x)) }) }; apply(fn(x) { -x * (x - 1) / 2 }, range(3))`,
+		`if (a == b) { c } else { if (d != e) { f } }; -(g)`,
+		`let f: fn(int) -> [a] = fn(n: int, xs: [a]) -> [a] { if (n > 0) { xs } else { [] } };`,
+	}
+
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			original := parse(t, input)
+			printed := Print(original)
+			reparsed := parse(t, printed)
+			require.Equal(t, original.String(), reparsed.String())
+			require.Equal(t, printed, Print(reparsed))
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"empty", "", ""},
+		{"shebang", "#!/usr/bin/env monkey run\nputs( 1 )", "#!/usr/bin/env monkey run\nputs(1);\n"},
+		{"only comments", "// a\n\n\n// b", "// a\n\n// b\n"},
+		{
+			"blank lines",
+			"let a = 1;\n\n\n\nlet b = 2;\nlet c = 3;\n",
+			"let a = 1;\n\nlet b = 2;\nlet c = 3;\n",
+		},
+		{
+			"comments around statements",
+			"// leading\nlet a = 1; // trailing\n\n// before b\nlet b = 2;\n// end",
+			"// leading\nlet a = 1; // trailing\n\n// before b\nlet b = 2;\n// end\n",
+		},
+		{
+			"comments in blocks",
+			"let f = fn() {\n\n// first\nlet a = 1; // one\n\na // result\n// last\n}",
+			"let f = fn() {\n    // first\n    let a = 1; // one\n\n    a // result\n    // last\n};\n",
+		},
+		{
+			"comment keeps a block open",
+			"if (x) { // why\n1 }",
+			"if (x) {\n    // why\n    1\n}\n",
+		},
+		{
+			"comment in empty block",
+			"let f = fn() {\n// todo\n};",
+			"let f = fn() {\n    // todo\n};\n",
+		},
+		{
+			"comments in lists",
+			"let h = {\"a\": 1, // first\n// between\n\"b\": 2};\nf(x, // x\ny)",
+			"let h = {\n    \"a\": 1, // first\n    // between\n    \"b\": 2\n};\nf(\n    x, // x\n    y\n);\n",
+		},
+		{
+			"long call",
+			"someFunction(firstArgumentName, secondArgumentName, thirdArgumentName, fourthArgument)",
+			"someFunction(\n    firstArgumentName,\n    secondArgumentName,\n    thirdArgumentName,\n    fourthArgument\n);\n",
+		},
+		{
+			"long nested literal",
+			`let config = {"name": "a fairly long configuration name", "values": [1, 2, 3, 4, 5, 6]};`,
+			"let config = {\n    \"name\": \"a fairly long configuration name\",\n    \"values\": [1, 2, 3, 4, 5, 6]\n};\n",
+		},
+		{
+			"trailing function argument",
+			"puts(map([1, 2, 3], fn(x) { let y = x * 2; y + 1 }));",
+			"puts(map([1, 2, 3], fn(x) {\n    let y = x * 2;\n    y + 1\n}));\n",
+		},
+		{
+			"long single statement block",
+			"let f = fn(x) { someFunction(firstArgumentName, secondArgumentName, thirdArgument) };",
+			"let f = fn(x) {\n    someFunction(firstArgumentName, secondArgumentName, thirdArgument)\n};\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			formatted, err := Format([]byte(tc.input))
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, string(formatted))
+		})
+	}
+}
+
+func TestFormatErrors(t *testing.T) {
+	_, err := Format([]byte("let = 1;"))
+	require.EqualError(t, err, "expected next token to be IDENTIFIER, got = instead\nno prefix parse function for = found")
+}
+
+// TestFormatRoundTrip checks, for each program, that formatting preserves
+// its meaning and its comments, and that formatting again changes nothing.
+func TestFormatRoundTrip(t *testing.T) {
+	inputs := []string{
+		`let fib = fn(n) { if (n < 2) { return n; }; fib(n - 1) + fib(n - 2) }; puts(fib(10));`,
+		`let m = {"k": [1, -2.5, "s\t"], true: fn(a) { a[0] = a[1] = !a[2] }}; m["k"][2]`,
+		`let apply = fn(f, xs) { map(xs, fn(x) { f(f(x)) }) }; apply(fn(x) { -x * (x - 1) / 2 }, range(3))`,
+		`if (a == b) { c } else { if (d != e) { f } }; -(g)`,
+		"// Computes things.\nlet compute = fn(values, options) { // entry point\n" +
+			"  let total = reduce(values, fn(acc, v) { acc + v * options[\"scale\"] }, 0);\n\n" +
+			"  // Report the result somewhere visible.\n" +
+			"  puts(format(\"total of %d values with a scale of %d is %d\", len(values), options[\"scale\"], total));\n" +
+			"  total\n};\n\ncompute([1, 2, 3], {\"scale\": 2, // doubled\n\"unused\": [[1, 2], [3, 4]]})\n",
+		"let x = 1 + // inside an expression\n  2;\nlet y = [\n// nothing yet\n];\n",
+		"let nested = [[1111111111, 2222222222, 3333333333], [4444444444, 5555555555, 6666666666], 7777777777];",
+		"if (ok) { 1 };\n[1, 2][0];\nif (ok) { 2 };\n-1",
+	}
+
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			formatted, err := Format([]byte(input))
+			require.NoError(t, err)
+
+			require.Equal(t, parse(t, input).String(), parse(t, string(formatted)).String())
+			require.Equal(t, countComments(input), countComments(string(formatted)))
+
+			again, err := Format(formatted)
+			require.NoError(t, err)
+			require.Equal(t, string(formatted), string(again))
+			for _, line := range strings.Split(string(formatted), "\n") {
+				if !strings.Contains(line, "//") {
+					require.LessOrEqual(t, utf8.RuneCountInString(line), Width, line)
+				}
+			}
+		})
+	}
+}
+
+func countComments(src string) int {
+	n := 0
+	l := lexer.New(src)
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		if tok.Type == token.Comment {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,222 @@
+package repl
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/vancanhuit/monkey/internal/ast"
+	"github.com/vancanhuit/monkey/internal/evaluator"
+	"github.com/vancanhuit/monkey/internal/lexer"
+	"github.com/vancanhuit/monkey/internal/object"
+	"github.com/vancanhuit/monkey/internal/parser"
+	"github.com/vancanhuit/monkey/internal/token"
+)
+
+// command is a REPL meta-command, typed as ":name arg".
+type command struct {
+	name string
+	arg  string
+	help string
+	run  func(s *session, arg string)
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"help", "", "show this help", (*session).help},
+		{"env", "", "list the bindings in the environment", (*session).listEnv},
+		{"ast", "EXPR", "print the syntax tree of EXPR", (*session).printAST},
+		{"tokens", "EXPR", "print the tokens of EXPR", (*session).printTokens},
+		{"type", "EXPR", "print the type of the value of EXPR", (*session).printType},
+		{"load", "FILE", "evaluate the program in FILE", (*session).load},
+		{"reset", "", "discard every binding", (*session).reset},
+		{"save", "FILE", "save every binding and macro to FILE", (*session).save},
+		{"restore", "FILE", "replace the bindings and macros with those saved in FILE", (*session).restore},
+		{"time", "EXPR", "evaluate EXPR and report how long it took", (*session).time},
+		{"quit", "", "leave the REPL", (*session).exit},
+	}
+}
+
+func (s *session) runCommand(line string) {
+	name, arg, _ := strings.Cut(strings.TrimPrefix(line, ":"), " ")
+	arg = strings.TrimSpace(arg)
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		if cmd.arg != "" && arg == "" {
+			fmt.Fprintf(s.out, "usage: :%s %s\n", cmd.name, cmd.arg)
+			return
+		}
+		cmd.run(s, arg)
+		return
+	}
+	fmt.Fprintf(s.out, "unknown command :%s (type :help for a list)\n", name)
+}
+
+func (s *session) help(string) {
+	for _, cmd := range commands {
+		usage := ":" + cmd.name
+		if cmd.arg != "" {
+			usage += " " + cmd.arg
+		}
+		fmt.Fprintf(s.out, "%-14s %s\n", usage, cmd.help)
+	}
+}
+
+func (s *session) listEnv(string) {
+	for _, name := range s.env.Names() {
+		value, _ := s.env.Get(name)
+		fmt.Fprintf(s.out, "%s = %s\n", name, summarize(value))
+	}
+}
+
+// summarize describes obj on a single line, showing only the signature of
+// functions rather than their bodies.
+func summarize(obj object.Object) string {
+	if fn, ok := obj.(*object.Function); ok {
+		params := make([]string, len(fn.Parameters))
+		for i, p := range fn.Parameters {
+			params[i] = p.String()
+		}
+		return "fn(" + strings.Join(params, ", ") + ")"
+	}
+	return obj.Inspect()
+}
+
+func (s *session) printAST(arg string) {
+	p := parser.New(lexer.New(arg))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		for _, msg := range p.Errors() {
+			fmt.Fprintf(s.out, "\t%s\n", msg)
+		}
+		return
+	}
+	writeTree(s.out, "", reflect.ValueOf(program), 0)
+}
+
+var nodeType = reflect.TypeOf((*ast.Node)(nil)).Elem()
+
+// writeTree prints the node held by v, labelled with the field it was found
+// in, followed by its child nodes indented one level deeper.
+func writeTree(out io.Writer, label string, v reflect.Value, depth int) {
+	if v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		if v.Kind() == reflect.Interface {
+			v = v.Elem()
+		}
+	}
+	node := reflect.Indirect(v)
+
+	line := strings.Repeat("  ", depth)
+	if label != "" {
+		line += label + ": "
+	}
+	line += node.Type().Name()
+	if op := node.FieldByName("Operator"); op.IsValid() && op.Kind() == reflect.String {
+		line += " " + op.String()
+	}
+	if value := node.FieldByName("Value"); value.IsValid() {
+		switch value.Kind() {
+		case reflect.String:
+			line += fmt.Sprintf(" %q", value.String())
+		case reflect.Int64, reflect.Float64, reflect.Bool:
+			line += fmt.Sprintf(" %v", value.Interface())
+		}
+	}
+	fmt.Fprintln(out, line)
+
+	for i := 0; i < node.NumField(); i++ {
+		field, name := node.Field(i), node.Type().Field(i).Name
+		switch {
+		case field.Type().Implements(nodeType):
+			writeTree(out, name, field, depth+1)
+		case field.Kind() == reflect.Slice:
+			for j := 0; j < field.Len(); j++ {
+				elem := field.Index(j)
+				if elem.Type().Implements(nodeType) || elem.Kind() == reflect.Struct {
+					writeTree(out, fmt.Sprintf("%s[%d]", name, j), elem, depth+1)
+				}
+			}
+		}
+	}
+}
+
+func (s *session) printTokens(arg string) {
+	l := lexer.New(arg)
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		fmt.Fprintf(s.out, "%-10s %q\n", tok.Type, tok.Literal)
+	}
+}
+
+func (s *session) printType(arg string) {
+	if value := s.eval(arg); value != nil {
+		fmt.Fprintln(s.out, value.Type())
+	}
+}
+
+func (s *session) load(arg string) {
+	data, err := os.ReadFile(arg)
+	if err != nil {
+		fmt.Fprintf(s.out, "cannot load %s: %s\n", arg, err)
+		return
+	}
+	if value := s.eval(string(data)); value != nil {
+		if _, ok := value.(*object.Error); ok {
+			fmt.Fprintln(s.out, value.Inspect())
+		}
+	}
+}
+
+func (s *session) reset(string) {
+	s.env = newEnvironment()
+	s.macros = newMacroEnvironment()
+}
+
+func (s *session) save(arg string) {
+	data, err := evaluator.SaveEnvironment(s.env, s.macros)
+	// Like the history, the bindings may hold secrets typed at the prompt,
+	// so only the user may read the snapshot.
+	if err == nil {
+		err = os.WriteFile(arg, data, 0o600)
+	}
+	if err != nil {
+		fmt.Fprintf(s.out, "cannot save %s: %s\n", arg, err)
+	}
+}
+
+func (s *session) restore(arg string) {
+	data, err := os.ReadFile(arg)
+	if err != nil {
+		fmt.Fprintf(s.out, "cannot restore %s: %s\n", arg, err)
+		return
+	}
+	env, macros := newEnvironment(), newMacroEnvironment()
+	if err := evaluator.RestoreEnvironment(data, env, macros); err != nil {
+		fmt.Fprintf(s.out, "cannot restore %s: %s\n", arg, err)
+		return
+	}
+	s.env, s.macros = env, macros
+}
+
+func (s *session) time(arg string) {
+	start := time.Now()
+	value := s.eval(arg)
+	elapsed := time.Since(start)
+	if value != nil {
+		fmt.Fprintln(s.out, value.Inspect())
+	}
+	fmt.Fprintf(s.out, "took %s\n", elapsed)
+}
+
+func (s *session) exit(string) {
+	s.quit = true
+}
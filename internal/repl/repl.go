@@ -2,8 +2,10 @@ package repl
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/vancanhuit/monkey/internal/evaluator"
 	"github.com/vancanhuit/monkey/internal/lexer"
@@ -11,37 +13,155 @@ import (
 	"github.com/vancanhuit/monkey/internal/parser"
 )
 
-const PROMPT = ">> "
+const (
+	PROMPT          = ">> "
+	CONTINUE_PROMPT = ".. "
+)
+
+// session is the state shared by the read-eval-print loop and the
+// meta-commands. Macros live apart from the other bindings, since they are
+// expanded before the input is evaluated.
+type session struct {
+	env    *object.Environment
+	macros *object.Environment
+	out    io.Writer
+	quit   bool
+}
+
+func newSession(out io.Writer) *session {
+	return &session{env: newEnvironment(), macros: newMacroEnvironment(), out: out}
+}
+
+func newEnvironment() *object.Environment {
+	return evaluator.NewEnvironment(evaluator.Config{})
+}
+
+func newMacroEnvironment() *object.Environment {
+	return evaluator.NewMacroEnvironment(evaluator.Config{})
+}
+
+// lineReader reads the lines typed by the user, showing prompt first. It
+// returns io.EOF at the end of the input and errInterrupted if the user
+// abandons the current input.
+type lineReader interface {
+	ReadLine(prompt string) (string, error)
+}
+
+var errInterrupted = errors.New("interrupted")
+
+type scannerReader struct {
+	scanner *bufio.Scanner
+	out     io.Writer
+}
+
+func (r *scannerReader) ReadLine(prompt string) (string, error) {
+	fmt.Fprint(r.out, prompt)
+	if !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return r.scanner.Text(), nil
+}
 
+// Start runs the REPL on plain input and output streams, without line
+// editing. See StartTerminal for interactive use.
 func Start(in io.Reader, out io.Writer) {
-	scanner := bufio.NewScanner(in)
-	env := object.NewEnvironment()
-	for {
-		fmt.Fprint(out, PROMPT)
-		scanned := scanner.Scan()
-		if !scanned {
+	s := newSession(out)
+	s.run(&scannerReader{scanner: bufio.NewScanner(in), out: out})
+}
+
+func (s *session) run(r lineReader) {
+	var input strings.Builder
+	for !s.quit {
+		prompt := PROMPT
+		if input.Len() != 0 {
+			prompt = CONTINUE_PROMPT
+		}
+		line, err := r.ReadLine(prompt)
+		if err == errInterrupted {
+			input.Reset()
+			continue
+		}
+		if err != nil {
 			return
 		}
 
-		line := scanner.Text()
-		l := lexer.New(line)
-		p := parser.New(l)
+		if input.Len() == 0 && strings.HasPrefix(line, ":") {
+			s.runCommand(line)
+			continue
+		}
 
-		prorgam := p.ParseProgram()
-		if len(p.Errors()) != 0 {
-			for _, msg := range p.Errors() {
-				io.WriteString(out, "\t"+msg+"\n")
-			}
+		input.WriteString(line)
+		input.WriteString("\n")
+		if !isComplete(input.String()) {
 			continue
 		}
+		source := input.String()
+		input.Reset()
+
+		if value := s.eval(source); value != nil {
+			io.WriteString(s.out, value.Inspect())
+			io.WriteString(s.out, "\n")
+		}
+	}
+}
+
+// eval evaluates source in the session's environment. Parse errors are
+// written to the output and reported as a nil result.
+func (s *session) eval(source string) object.Object {
+	p := parser.New(lexer.New(source))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		for _, msg := range p.Errors() {
+			io.WriteString(s.out, "\t"+msg+"\n")
+		}
+		return nil
+	}
+
+	evaluator.DefineMacros(program, s.macros)
+	expanded, err := evaluator.ExpandMacros(program, s.macros)
+	if err != nil {
+		return &object.Error{Message: err.Error()}
+	}
+	return evaluator.Eval(expanded, s.env)
+}
 
-		value := evaluator.Eval(prorgam, env)
-		if value != nil {
-			io.WriteString(out, value.Inspect())
-			io.WriteString(out, "\n")
+// isComplete reports whether input can be parsed as it is, or whether it
+// ends inside a string literal or before every opening bracket is closed.
+// Brackets inside comments are ignored.
+// Input with more closing than opening brackets counts as complete so that
+// the parser reports the error.
+func isComplete(input string) bool {
+	depth := 0
+	inString := false
+	for i := 0; i < len(input); i++ {
+		ch := input[i]
+		if inString {
+			switch ch {
+			case '\\':
+				i++
+			case '"':
+				inString = false
+			}
+			continue
 		}
 
-		// io.WriteString(out, prorgam.String())
-		// io.WriteString(out, "\n")
+		switch ch {
+		case '"':
+			inString = true
+		case '/':
+			if i+1 < len(input) && input[i+1] == '/' {
+				for i < len(input) && input[i] != '\n' {
+					i++
+				}
+			}
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		}
 	}
+	return !inString && depth <= 0
 }
@@ -0,0 +1,126 @@
+package repl
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsComplete(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected bool
+	}{
+		{"let x = 1;", true},
+		{"let f = fn(x) {", false},
+		{"let f = fn(x) {\n  x + 1\n}", true},
+		{"[1, 2,", false},
+		{"add(1,", false},
+		{`"abc`, false},
+		{`"a { b"`, true},
+		{`"a \" {`, false},
+		{`"a \\" + (`, false},
+		{"}", true},
+		{"let f = fn() { // {\n", false},
+		{"let f = fn() { // {\n}", true},
+		{"1 / 2", true},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, isComplete(tc.input), tc.input)
+	}
+}
+
+func TestStartMultiline(t *testing.T) {
+	in := strings.NewReader("let add = fn(a, b) {\n  a + b\n};\nadd(1,\n2)\n\"a\nb\"\n")
+	var out strings.Builder
+	Start(in, &out)
+
+	expected := ">> .. .. >> .. 3\n>> .. a\nb\n>> "
+	require.Equal(t, expected, out.String())
+}
+
+func TestCommands(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "lib.mk")
+	require.NoError(t, os.WriteFile(file, []byte("let double = fn(x) { x * 2 };"), 0o644))
+	saved := filepath.Join(dir, "session.json")
+
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"let x = 5;\nlet f = fn(a, b) { a };\n:env", "f = fn(a, b)\nmath = module math\ntime = module time\nx = 5\n"},
+		{":ast -a", "Program\n  Statements[0]: ExpressionStatement\n    Expression: PrefixExpression -\n      Right: Identifier \"a\"\n"},
+		{":tokens x + 1", "IDENTIFIER \"x\"\n+          \"+\"\nINTEGER    \"1\"\n"},
+		{":type [1]", "ARRAY\n"},
+		{":load " + file + "\ndouble(4)", "8\n"},
+		{"let x = 1;\n:reset\nx", "ERROR: identifier not found: x\n"},
+		{
+			"let unless = macro(c, x) { quote(if (!(unquote(c))) { unquote(x) }) };\nunless(false, 7)\nunless(1)\n:reset\nunless(false, 7)",
+			"7\nERROR: macro unless: wrong number of arguments. got=1, want=2\nERROR: identifier not found: unless\n",
+		},
+		{":quit\n1", ""},
+		{
+			"let add = fn(x) { fn(y) { x + y } }(2);\n:save " + saved + "\n:reset\n:restore " + saved + "\nadd(3)",
+			"5\n",
+		},
+		{
+			"let twice = macro(x) { quote(unquote(x) + unquote(x)) };\n:save " + saved + "\n:reset\n:restore " + saved + "\ntwice(4)",
+			"8\n",
+		},
+		{":restore " + filepath.Join(dir, "missing"), "cannot restore " + filepath.Join(dir, "missing") + ": open " + filepath.Join(dir, "missing") + ": no such file or directory\n"},
+		{":ast", "usage: :ast EXPR\n"},
+		{":nope", "unknown command :nope (type :help for a list)\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			var out strings.Builder
+			Start(strings.NewReader(tc.input+"\n"), &out)
+			require.Equal(t, tc.expected, strings.ReplaceAll(out.String(), PROMPT, ""))
+		})
+	}
+}
+
+func TestComplete(t *testing.T) {
+	s := newSession(nil)
+	s.eval("let lengths = [1]; let lenient = true; let m = json;")
+
+	testCases := []struct {
+		line     string
+		pos      int
+		head     string
+		expected []string
+		tail     string
+	}{
+		{"le", 2, "", []string{"len", "lengths", "lenient", "let"}, ""},
+		{"puts(leng)", 9, "puts(", []string{"lengths"}, ")"},
+		{"json.st", 7, "", []string{"json.stringify"}, ""},
+		{"m.p", 3, "", []string{"m.parse"}, ""},
+		{"time.s", 6, "", []string{"time.second", "time.since", "time.sleep", "time.sub"}, ""},
+		{"\"é\" + ret", 9, "\"é\" + ", []string{"return"}, ""},
+		{"x + ", 4, "x + ", nil, ""},
+		{"nothing", 7, "", nil, ""},
+	}
+
+	for _, tc := range testCases {
+		head, completions, tail := s.complete(tc.line, tc.pos)
+		require.Equal(t, tc.head, head, tc.line)
+		require.Equal(t, tc.expected, completions, tc.line)
+		require.Equal(t, tc.tail, tail, tc.line)
+	}
+}
+
+func TestSaveIsPrivate(t *testing.T) {
+	saved := filepath.Join(t.TempDir(), "session.json")
+	Start(strings.NewReader("let password = \"secret\";\n:save "+saved+"\n"), io.Discard)
+
+	info, err := os.Stat(saved)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
@@ -0,0 +1,118 @@
+package repl
+
+import (
+	"errors"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/peterh/liner"
+	"github.com/vancanhuit/monkey/internal/evaluator"
+	"github.com/vancanhuit/monkey/internal/object"
+	"github.com/vancanhuit/monkey/internal/token"
+)
+
+// StartTerminal runs the REPL on the terminal attached to stdin and stdout
+// with line editing, reverse search (Ctrl-R) and tab completion. History is
+// loaded from and saved to historyFile unless it is empty.
+func StartTerminal(historyFile string) error {
+	line := liner.NewLiner()
+	defer line.Close()
+	line.SetCtrlCAborts(true)
+
+	if historyFile != "" {
+		if f, err := os.Open(historyFile); err == nil {
+			_, _ = line.ReadHistory(f)
+			f.Close()
+		}
+	}
+
+	s := newSession(os.Stdout)
+	line.SetWordCompleter(s.complete)
+	s.run(&linerReader{state: line})
+
+	if historyFile == "" {
+		return nil
+	}
+	// The history may hold secrets typed at the prompt, so only the user
+	// may read it.
+	f, err := os.OpenFile(historyFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = line.WriteHistory(f)
+	return err
+}
+
+type linerReader struct {
+	state *liner.State
+}
+
+func (r *linerReader) ReadLine(prompt string) (string, error) {
+	line, err := r.state.Prompt(prompt)
+	if errors.Is(err, liner.ErrPromptAborted) {
+		return "", errInterrupted
+	}
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(line) != "" {
+		r.state.AppendHistory(line)
+	}
+	return line, nil
+}
+
+// complete is a liner.WordCompleter offering the keywords, the builtins and
+// the names bound in the session for the word before the cursor. After a
+// dot it offers the members of the module named before the dot. As for any
+// liner.WordCompleter, pos counts runes rather than bytes.
+func (s *session) complete(line string, pos int) (string, []string, string) {
+	runes := []rune(line)
+	start := pos
+	for start > 0 && isWordChar(runes[start-1]) {
+		start--
+	}
+	head, word, tail := string(runes[:start]), string(runes[start:pos]), string(runes[pos:])
+	if word == "" {
+		return head, nil, tail
+	}
+
+	var candidates []string
+	if dot := strings.LastIndexByte(word, '.'); dot >= 0 {
+		if m, ok := s.lookup(word[:dot]).(*object.Module); ok {
+			for name := range m.Members {
+				candidates = append(candidates, word[:dot+1]+name)
+			}
+		}
+	} else {
+		candidates = append(candidates, token.Keywords()...)
+		candidates = append(candidates, evaluator.GlobalNames()...)
+		candidates = append(candidates, s.env.Names()...)
+		candidates = append(candidates, s.macros.Names()...)
+	}
+
+	seen := make(map[string]bool)
+	var completions []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, word) && !seen[c] {
+			seen[c] = true
+			completions = append(completions, c)
+		}
+	}
+	sort.Strings(completions)
+	return head, completions, tail
+}
+
+func (s *session) lookup(name string) object.Object {
+	if obj, ok := s.env.Get(name); ok {
+		return obj
+	}
+	obj, _ := evaluator.Global(name)
+	return obj
+}
+
+func isWordChar(ch rune) bool {
+	return unicode.IsLetter(ch) || unicode.IsDigit(ch) || ch == '_' || ch == '!' || ch == '.'
+}
@@ -1,18 +1,26 @@
 package token
 
+import "sort"
+
 type TokenType string
 
+// Token is a lexical token. Line and Column give the 1-based position of
+// its first character in the input, counting columns in bytes.
 type Token struct {
-	Type    TokenType
-	Literal string
+	Type    TokenType `json:"type"`
+	Literal string    `json:"literal"`
+	Line    int       `json:"line"`
+	Column  int       `json:"column"`
 }
 
 const (
 	EOF     = "EOF"
 	Illegal = "ILLEGAL"
+	Comment = "COMMENT"
 
 	Identifier = "IDENTIFIER"
 	Integer    = "INTEGER"
+	Float      = "FLOAT"
 	String     = "STRING"
 
 	Assign   = "="
@@ -26,6 +34,7 @@ const (
 	GreaterThan = ">"
 
 	Comma     = ","
+	Dot       = "."
 	Semicolon = ";"
 	Colon     = ":"
 
@@ -37,6 +46,7 @@ const (
 	RightBracket = "]"
 
 	Function = "FUNCTION"
+	Macro    = "MACRO"
 	Let      = "LET"
 	Return   = "RETURN"
 	If       = "IF"
@@ -46,10 +56,12 @@ const (
 
 	Equal    = "=="
 	NotEqual = "!="
+	Arrow    = "->"
 )
 
 var keywords = map[string]TokenType{
 	"fn":     Function,
+	"macro":  Macro,
 	"let":    Let,
 	"if":     If,
 	"else":   Else,
@@ -64,3 +76,13 @@ func LookupIdentifier(identifier string) TokenType {
 	}
 	return Identifier
 }
+
+// Keywords returns the reserved words in sorted order.
+func Keywords() []string {
+	names := make([]string, 0, len(keywords))
+	for name := range keywords {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
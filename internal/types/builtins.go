@@ -0,0 +1,141 @@
+package types
+
+// The signatures of the builtin functions, in the syntax read by Parse.
+// They are kept in step with the evaluator's builtins by a test.
+var builtinSignatures = map[string]string{
+	"len":     "sized a => fn(a) -> int",
+	"first":   "fn([a]) -> a",
+	"last":    "fn([a]) -> a",
+	"rest":    "fn([a]) -> [a]",
+	"push":    "fn([a], a) -> [a]",
+	"append!": "fn([a], ...a) -> [a]",
+	"pop":     "fn([a]) -> a",
+	"delete":  "fn({k: v}, k) -> v",
+	"keys":    "fn({k: v}) -> [k]",
+	"values":  "fn({k: v}) -> [v]",
+	"puts":    "fn(...any) -> null",
+
+	"map":      "fn([a], fn(a) -> b) -> [b]",
+	"filter":   "fn([a], fn(a) -> any) -> [a]",
+	"reduce":   "fn([a], fn(b, a) -> b, b?) -> b",
+	"each":     "fn([a], fn(a) -> any) -> null",
+	"find":     "fn([a], fn(a) -> any) -> a",
+	"any":      "fn([a], fn(a) -> any) -> bool",
+	"all":      "fn([a], fn(a) -> any) -> bool",
+	"zip":      "fn(...[any]) -> [[any]]",
+	"range":    "fn(int, int?, int?) -> [int]",
+	"sort":     "fn([a], fn(a, a) -> any?) -> [a]",
+	"reverse":  "fn(a) -> a",
+	"flatten":  "fn([any], int?) -> [any]",
+	"uniq":     "fn([a]) -> [a]",
+	"groupBy":  "hashable k => fn([a], fn(a) -> k) -> {k: [a]}",
+	"contains": "fn([a], a) -> bool",
+	"indexOf":  "fn([a], a) -> int",
+}
+
+// The signatures of the members of the builtin modules.
+var moduleSignatures = map[string]map[string]string{
+	"strings": {
+		"split":      "fn(string, string) -> [string]",
+		"join":       "fn([string], string) -> string",
+		"trim":       "fn(string, string?) -> string",
+		"upper":      "fn(string) -> string",
+		"lower":      "fn(string) -> string",
+		"replace":    "fn(string, string, string) -> string",
+		"contains":   "fn(string, string) -> bool",
+		"startsWith": "fn(string, string) -> bool",
+		"endsWith":   "fn(string, string) -> bool",
+		"indexOf":    "fn(string, string) -> int",
+		"repeat":     "fn(string, int) -> string",
+		"format":     "fn(string, ...any) -> string",
+		"chars":      "fn(string) -> [string]",
+	},
+	"json": {
+		"parse":     "fn(string) -> any",
+		"stringify": "fn(a, any?) -> string",
+	},
+	"math": {
+		"pi":      "float",
+		"e":       "float",
+		"inf":     "float",
+		"abs":     "num a => fn(a) -> a",
+		"min":     "fn(...any) -> any",
+		"max":     "fn(...any) -> any",
+		"pow":     "num a => fn(a, a) -> a",
+		"floor":   "num a => fn(a) -> int",
+		"ceil":    "num a => fn(a) -> int",
+		"round":   "num a => fn(a) -> int",
+		"sqrt":    "num a => fn(a) -> float",
+		"exp":     "num a => fn(a) -> float",
+		"log":     "num a => fn(a) -> float",
+		"log10":   "num a => fn(a) -> float",
+		"sin":     "num a => fn(a) -> float",
+		"cos":     "num a => fn(a) -> float",
+		"tan":     "num a => fn(a) -> float",
+		"asin":    "num a => fn(a) -> float",
+		"acos":    "num a => fn(a) -> float",
+		"atan":    "num a => fn(a) -> float",
+		"atan2":   "num a, num b => fn(a, b) -> float",
+		"seed":    "fn(int) -> null",
+		"random":  "fn() -> float",
+		"randInt": "fn(int, int?) -> int",
+	},
+	"regex": {
+		"match":   "fn(string, string) -> bool",
+		"find":    "fn(string, string) -> string",
+		"findAll": "fn(string, string) -> [string]",
+		"replace": "fn(string, string, string) -> string",
+		"split":   "fn(string, string) -> [string]",
+	},
+	"time": {
+		"nanosecond":     "int",
+		"microsecond":    "int",
+		"millisecond":    "int",
+		"second":         "int",
+		"minute":         "int",
+		"hour":           "int",
+		"now":            "fn() -> time",
+		"since":          "fn(time) -> int",
+		"sleep":          "fn(int) -> null",
+		"add":            "fn(time, int) -> time",
+		"addDate":        "fn(time, int, int, int) -> time",
+		"sub":            "fn(time, time) -> int",
+		"unix":           "fn(time) -> int",
+		"fromUnix":       "fn(int) -> time",
+		"inZone":         "fn(time, string) -> time",
+		"format":         "fn(time, string) -> string",
+		"parse":          "fn(string, string, string?) -> time",
+		"duration":       "fn(string) -> int",
+		"formatDuration": "fn(int) -> string",
+		"parts":          "fn(time) -> {string: any}",
+	},
+	"fs": {
+		"read":   "fn(string) -> string",
+		"write":  "fn(string, string) -> null",
+		"list":   "fn(string) -> [string]",
+		"exists": "fn(string) -> bool",
+		"mkdir":  "fn(string) -> null",
+	},
+	"http": {
+		"get":     "fn(string, {string: string}?) -> {string: any}",
+		"post":    "fn(string, string, {string: string}?) -> {string: any}",
+		"request": "fn({string: any}) -> {string: any}",
+	},
+}
+
+// builtinEnv binds the builtin functions and modules. Every Env returned
+// by NewEnv is enclosed by it.
+var builtinEnv = &Env{names: map[string]*Scheme{}}
+
+func init() {
+	for name, sig := range builtinSignatures {
+		builtinEnv.names[name] = &Scheme{Type: MustParse(sig)}
+	}
+	for name, members := range moduleSignatures {
+		m := &Module{Name: name, Members: map[string]*Scheme{}}
+		for member, sig := range members {
+			m.Members[member] = &Scheme{Type: MustParse(sig)}
+		}
+		builtinEnv.names[name] = &Scheme{Type: m}
+	}
+}
@@ -0,0 +1,713 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/vancanhuit/monkey/internal/ast"
+	"github.com/vancanhuit/monkey/internal/token"
+)
+
+// Error is a type error found at a position in the source.
+type Error struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+}
+
+// Env binds names to the types of their values.
+type Env struct {
+	outer *Env
+	names map[string]*Scheme
+	// later holds the names bound by let statements further on in the
+	// scope being checked. A function may refer to them before they are
+	// bound, since it only runs once they are.
+	later map[string]bool
+}
+
+// NewEnv returns a top-level environment in which the builtin functions
+// and modules are bound.
+func NewEnv() *Env {
+	return newEnclosedEnv(builtinEnv)
+}
+
+func newEnclosedEnv(outer *Env) *Env {
+	return &Env{outer: outer, names: map[string]*Scheme{}, later: map[string]bool{}}
+}
+
+// Define binds name to t, for values the host provides to programs. The
+// type variables of a type returned by Parse take fresh types at every use
+// of the name.
+func (e *Env) Define(name string, t Type) {
+	e.names[name] = &Scheme{Type: t}
+}
+
+// Lookup returns the type bound to name in e or the environments
+// enclosing it.
+func (e *Env) Lookup(name string) (*Scheme, bool) {
+	s, _ := e.lookup(name)
+	return s, s != nil
+}
+
+// lookup is like Lookup, but also reports whether the name is bound later
+// in a scope being checked, in which case the scheme is nil.
+func (e *Env) lookup(name string) (*Scheme, bool) {
+	for ; e != nil; e = e.outer {
+		if s, ok := e.names[name]; ok {
+			return s, true
+		}
+		if e.later[name] {
+			return nil, true
+		}
+	}
+	return nil, false
+}
+
+// Check infers the types of the statements of program, binding the names
+// they define at the top level in env, and returns the type errors it
+// finds, sorted by position. Macros must have been expanded beforehand;
+// calls to the macros that remain are not checked.
+func Check(program *ast.Program, env *Env) []*Error {
+	c := &checker{}
+	env.later = map[string]bool{}
+	c.declare(env, program.Statements)
+	for i, stmt := range program.Statements {
+		if let, ok := stmt.(*ast.LetStatement); ok {
+			if _, ok := let.Value.(*ast.MacroLiteral); ok {
+				env.names[let.Name.Value] = &Scheme{Type: Macro}
+				continue
+			}
+		}
+		c.statement(stmt, env, i == len(program.Statements)-1)
+	}
+	c.solve()
+	env.later = nil
+	return c.sortedErrors()
+}
+
+type checker struct {
+	unifier
+	errors  []*Error
+	level   int
+	pending []constraint
+	// results holds the types returned so far by each enclosing function,
+	// innermost last, or nil before a function's first return statement.
+	results []Type
+}
+
+func (c *checker) errorf(node ast.Node, format string, a ...interface{}) {
+	c.errorAt(ast.Start(node), format, a...)
+}
+
+func (c *checker) errorAt(tok token.Token, format string, a ...interface{}) {
+	c.errors = append(c.errors, &Error{Line: tok.Line, Column: tok.Column, Message: fmt.Sprintf(format, a...)})
+}
+
+// sortedErrors returns the errors in order of position, without the
+// duplicates reported when generic code is checked at several uses.
+func (c *checker) sortedErrors() []*Error {
+	seen := map[Error]bool{}
+	errors := c.errors[:0]
+	for _, e := range c.errors {
+		if !seen[*e] {
+			seen[*e] = true
+			errors = append(errors, e)
+		}
+	}
+	sort.SliceStable(errors, func(i, j int) bool {
+		if errors[i].Line != errors[j].Line {
+			return errors[i].Line < errors[j].Line
+		}
+		return errors[i].Column < errors[j].Column
+	})
+	return errors
+}
+
+// describe prints types for a message, giving their variables the same
+// names throughout.
+func describe(types ...Type) []interface{} {
+	n := newNamer()
+	names := make([]interface{}, len(types))
+	for i, t := range types {
+		names[i] = n.name(t)
+	}
+	return names
+}
+
+func (c *checker) newVar() *Var {
+	return &Var{level: c.level}
+}
+
+// declare records the names bound by let statements in stmts, including
+// those inside if blocks, which share the scope of their function.
+func (c *checker) declare(env *Env, stmts []ast.Statement) {
+	for _, stmt := range stmts {
+		ast.Inspect(stmt, func(node ast.Node) bool {
+			switch n := node.(type) {
+			case *ast.LetStatement:
+				if n.Name != nil {
+					env.later[n.Name.Value] = true
+				}
+			case *ast.FunctionLiteral, *ast.MacroLiteral:
+				return false
+			}
+			return true
+		})
+	}
+}
+
+// statement returns the type of the value of stmt. Only the value of the
+// last statement of a block is used; the branches of an unused if need
+// not agree.
+func (c *checker) statement(stmt ast.Statement, env *Env, used bool) Type {
+	switch s := stmt.(type) {
+	case *ast.LetStatement:
+		c.let(s, env)
+	case *ast.ReturnStatement:
+		var t Type = Null
+		if s.Value != nil {
+			t = c.expression(s.Value, env)
+		}
+		if n := len(c.results); n > 0 {
+			if c.results[n-1] == nil {
+				c.results[n-1] = t
+			} else {
+				c.results[n-1] = c.agree(s, "return values", c.results[n-1], t)
+			}
+		}
+		// The code after a return never sees its value.
+		return c.newVar()
+	case *ast.ExpressionStatement:
+		if s.Expression == nil {
+			break
+		}
+		if n, ok := s.Expression.(*ast.IfExpression); ok {
+			return c.ifExpression(n, env, used)
+		}
+		return c.expression(s.Expression, env)
+	}
+	return Null
+}
+
+func (c *checker) block(block *ast.BlockStatement, env *Env, used bool) Type {
+	var t Type = Null
+	for i, stmt := range block.Statements {
+		t = c.statement(stmt, env, used && i == len(block.Statements)-1)
+	}
+	return t
+}
+
+// let binds the name of s. Functions are generalized, so that each use
+// of the name may apply the function to different types; while its body is
+// checked, a function refers to itself with a single type.
+func (c *checker) let(s *ast.LetStatement, env *Env) {
+	if s.Name == nil || s.Value == nil {
+		return
+	}
+	name := s.Name.Value
+
+	c.level++
+	var t Type
+	fn, isFunc := s.Value.(*ast.FunctionLiteral)
+	if isFunc {
+		self := c.newVar()
+		env.names[name] = &Scheme{Type: self}
+		t = c.function(fn, env, self)
+	} else {
+		t = c.expression(s.Value, env)
+	}
+	if s.Type != nil {
+		want := c.annotation(s.Type, map[string]*Var{})
+		if c.unify(want, t) != nil || narrows(want, t) {
+			c.errorf(s.Value, "value of %s must be %s, got %s", append([]interface{}{name}, describe(want, t)...)...)
+		}
+		// The name has the declared type, even if its value does not.
+		t = want
+	}
+	c.level--
+	c.solve()
+
+	if isFunc {
+		env.names[name] = c.generalize(t)
+	} else {
+		c.lower(t)
+		env.names[name] = &Scheme{Type: t}
+	}
+}
+
+// generalize returns the scheme of t over the variables created since the
+// current level was entered, along with the constraints on them that are
+// still pending.
+func (c *checker) generalize(t Type) *Scheme {
+	c.mark(t)
+	s := &Scheme{Type: t}
+	pending := c.pending[:0]
+	for _, k := range c.pending {
+		if v, ok := prune(k.subject()).(*Var); ok && v.level == generic {
+			for _, t := range k.types() {
+				c.mark(t)
+			}
+			s.constraints = append(s.constraints, k)
+			continue
+		}
+		pending = append(pending, k)
+	}
+	c.pending = pending
+	return s
+}
+
+// mark makes the variables of t above the current level generic.
+func (c *checker) mark(t Type) {
+	visit(t, func(v *Var) {
+		if v.level > c.level {
+			v.level = generic
+		}
+	})
+}
+
+// lower brings the variables of t down to the current level, so that they
+// are not generalized by an enclosing let.
+func (c *checker) lower(t Type) {
+	visit(t, func(v *Var) {
+		if v.level > c.level {
+			v.level = c.level
+		}
+	})
+}
+
+// visit calls f for each unbound variable in t.
+func visit(t Type, f func(*Var)) {
+	switch t := prune(t).(type) {
+	case *Var:
+		f(t)
+	case *Array:
+		visit(t.Elem, f)
+	case *Hash:
+		visit(t.Key, f)
+		visit(t.Value, f)
+	case *Func:
+		for _, p := range t.Params {
+			visit(p, f)
+		}
+		if t.Variadic != nil {
+			visit(t.Variadic, f)
+		}
+		visit(t.Result, f)
+	}
+}
+
+// instantiate returns the type of s with fresh variables in place of the
+// generic ones, and adds its constraints on them to the pending ones.
+func (c *checker) instantiate(s *Scheme) Type {
+	fresh := map[*Var]*Var{}
+	subst := func(t Type) Type { return c.copyType(t, fresh) }
+	t := subst(s.Type)
+	for _, k := range s.constraints {
+		c.pending = append(c.pending, k.substitute(subst))
+	}
+	return t
+}
+
+func (c *checker) copyType(t Type, fresh map[*Var]*Var) Type {
+	switch t := prune(t).(type) {
+	case *Var:
+		if t.level != generic {
+			return t
+		}
+		v, ok := fresh[t]
+		if !ok {
+			v = &Var{level: c.level, classes: t.classes}
+			fresh[t] = v
+		}
+		return v
+	case *Array:
+		return &Array{Elem: c.copyType(t.Elem, fresh)}
+	case *Hash:
+		return &Hash{Key: c.copyType(t.Key, fresh), Value: c.copyType(t.Value, fresh)}
+	case *Func:
+		f := &Func{Params: make([]Type, len(t.Params)), Optional: t.Optional, declared: t.declared}
+		for i, p := range t.Params {
+			f.Params[i] = c.copyType(p, fresh)
+		}
+		if t.Variadic != nil {
+			f.Variadic = c.copyType(t.Variadic, fresh)
+		}
+		f.Result = c.copyType(t.Result, fresh)
+		return f
+	default:
+		return t
+	}
+}
+
+func (c *checker) expression(expr ast.Expression, env *Env) Type {
+	switch n := expr.(type) {
+	case *ast.IntegerLiteral:
+		return Int
+	case *ast.FloatLiteral:
+		return Float
+	case *ast.StringLiteral:
+		return String
+	case *ast.Boolean:
+		return Bool
+	case *ast.Identifier:
+		return c.identifier(n, env)
+	case *ast.PrefixExpression:
+		return c.prefixExpression(n, env)
+	case *ast.InfixExpression:
+		return c.infixExpression(n, env)
+	case *ast.IfExpression:
+		return c.ifExpression(n, env, true)
+	case *ast.FunctionLiteral:
+		return c.function(n, env, nil)
+	case *ast.MacroLiteral:
+		c.errorf(n, "macros can only be defined by a top-level let statement")
+		return Any
+	case *ast.CallExpression:
+		return c.callExpression(n, env)
+	case *ast.ArrayLiteral:
+		return c.arrayLiteral(n, env)
+	case *ast.HashLiteral:
+		return c.hashLiteral(n, env)
+	case *ast.IndexExpression:
+		return c.indexExpression(n, env)
+	case *ast.MemberExpression:
+		result := c.newVar()
+		c.constrain(&memberConstraint{node: n, left: c.expression(n.Left, env), result: result})
+		return result
+	case *ast.AssignExpression:
+		left := c.expression(n.Target.Left, env)
+		index := c.expression(n.Target.Index, env)
+		value := c.expression(n.Value, env)
+		c.constrain(&indexConstraint{node: n.Target, left: left, index: index, result: value, assign: true})
+		return value
+	}
+	return Any
+}
+
+func (c *checker) identifier(n *ast.Identifier, env *Env) Type {
+	s, ok := env.lookup(n.Value)
+	switch {
+	case !ok:
+		c.errorf(n, "identifier not found: %s", n.Value)
+		return Any
+	case s == nil:
+		return Any
+	}
+	t := c.instantiate(s)
+	c.solve()
+	return t
+}
+
+func (c *checker) prefixExpression(n *ast.PrefixExpression, env *Env) Type {
+	right := c.expression(n.Right, env)
+	if n.Operator == "!" {
+		return Bool
+	}
+	c.solve()
+	if err := c.satisfy(right, num); err != nil {
+		c.errorAt(n.Token, "unknown operator: %s%s", n.Operator, right)
+		return Any
+	}
+	return right
+}
+
+func (c *checker) infixExpression(n *ast.InfixExpression, env *Env) Type {
+	left := c.expression(n.Left, env)
+	right := c.expression(n.Right, env)
+	c.solve()
+
+	var cls class
+	switch n.Operator {
+	case "==", "!=":
+		return Bool
+	case "+":
+		cls = addable
+	case "<", ">":
+		cls = ordered
+	default:
+		cls = num
+	}
+
+	err := c.unify(left, right)
+	if err == nil {
+		err = c.satisfy(left, cls)
+	}
+	if err != nil {
+		names := describe(left, right)
+		problem := "unknown operator"
+		if err == errMismatch || err == errInfinite {
+			problem = "type mismatch"
+		}
+		c.errorAt(n.Token, "%s: %s %s %s", problem, names[0], n.Operator, names[1])
+		return Any
+	}
+
+	switch {
+	case cls == ordered:
+		return Bool
+	case prune(left) == Float || prune(right) == Float:
+		return Float
+	default:
+		return left
+	}
+}
+
+func (c *checker) ifExpression(n *ast.IfExpression, env *Env, used bool) Type {
+	c.expression(n.Condition, env)
+	consequence := c.block(n.Consequence, env, used)
+	var alternative Type = Null
+	if n.Alternative != nil {
+		alternative = c.block(n.Alternative, env, used)
+	}
+	if !used {
+		return Null
+	}
+	return c.agree(n, "branches of if", consequence, alternative)
+}
+
+// agree returns the common type of a and b, the types of the values an
+// expression takes along different paths. A program using such a value
+// most likely expects one type, so unlike the elements of a literal the
+// values must agree: if they cannot, the error is reported at node and the
+// value is typed as any. Null, the value of an if without an else, may be
+// mixed with any type, which makes the value any.
+func (c *checker) agree(node ast.Node, what string, a, b Type) Type {
+	if prune(a) == Null || prune(b) == Null {
+		return c.join(a, b)
+	}
+	if c.unify(a, b) != nil {
+		c.errorf(node, "%s must have the same type, got %s and %s", append([]interface{}{what}, describe(a, b)...)...)
+		return Any
+	}
+	return a
+}
+
+// function returns the type of n. When n is bound by a let, self is the
+// type its name has in its own body.
+func (c *checker) function(n *ast.FunctionLiteral, env *Env, self Type) Type {
+	scope := newEnclosedEnv(env)
+	f := &Func{Params: make([]Type, len(n.Parameters)), Result: c.newVar()}
+	vars := map[string]*Var{}
+	for i, p := range n.Parameters {
+		var t Type = c.newVar()
+		if a := n.ParameterType(i); a != nil {
+			t = c.annotation(a, vars)
+			if f.declared == nil {
+				f.declared = make([]bool, len(n.Parameters))
+			}
+			f.declared[i] = true
+		}
+		f.Params[i] = t
+		scope.names[p.Value] = &Scheme{Type: t}
+	}
+	var result Type
+	if n.ReturnType != nil {
+		result = c.annotation(n.ReturnType, vars)
+		_ = c.unify(f.Result, result)
+	}
+	if self != nil {
+		_ = c.unify(self, f)
+	}
+
+	c.declare(scope, n.Body.Statements)
+	c.results = append(c.results, nil)
+	body := c.block(n.Body, scope, true)
+	returned := c.results[len(c.results)-1]
+	c.results = c.results[:len(c.results)-1]
+	if returned != nil {
+		var last ast.Node = n
+		if stmts := n.Body.Statements; len(stmts) > 0 {
+			last = stmts[len(stmts)-1]
+		}
+		body = c.agree(last, "return values", returned, body)
+	}
+	if result != nil {
+		if c.unify(result, body) != nil || narrows(result, body) {
+			c.errorf(n, "return value must be %s, got %s", describe(result, body)...)
+		}
+		return f
+	}
+	// Unifying with any binds nothing, but the result must be any rather
+	// than a variable later uses could constrain.
+	if v, ok := prune(f.Result).(*Var); ok && prune(body) == Any {
+		v.instance = Any
+	}
+	// A recursive call may already have fixed the result to a type the
+	// body does not agree with; the call sites have been checked against
+	// it, so it is left as it is.
+	_ = c.unify(f.Result, body)
+	return f
+}
+
+// annotation returns the type written in t. Its type variables are fresh,
+// but shared by the annotations of one function through vars.
+func (c *checker) annotation(t ast.TypeExpression, vars map[string]*Var) Type {
+	switch t := t.(type) {
+	case *ast.NamedType:
+		if named, ok := namedTypes[t.Name]; ok {
+			return named
+		}
+		v, ok := vars[t.Name]
+		if !ok {
+			v = c.newVar()
+			vars[t.Name] = v
+		}
+		return v
+	case *ast.ArrayType:
+		return &Array{Elem: c.annotation(t.Elem, vars)}
+	case *ast.HashType:
+		return &Hash{Key: c.annotation(t.Key, vars), Value: c.annotation(t.Value, vars)}
+	case *ast.FunctionType:
+		f := &Func{Params: make([]Type, len(t.Parameters)), Result: c.annotation(t.Result, vars)}
+		for i, p := range t.Parameters {
+			f.Params[i] = c.annotation(p, vars)
+		}
+		return f
+	}
+	return Any
+}
+
+func (c *checker) callExpression(n *ast.CallExpression, env *Env) Type {
+	if ident, ok := n.Function.(*ast.Identifier); ok && ident.Value == "quote" {
+		if len(n.Arguments) != 1 {
+			c.errorf(n, "wrong number of arguments. got=%d, want=1", len(n.Arguments))
+		}
+		return Quote
+	}
+
+	callee := c.expression(n.Function, env)
+	if prune(callee) == Macro {
+		return Any
+	}
+	args := make([]Type, len(n.Arguments))
+	for i, arg := range n.Arguments {
+		args[i] = c.expression(arg, env)
+	}
+	c.solve()
+
+	switch f := prune(callee).(type) {
+	case *Var:
+		result := c.newVar()
+		call := &Func{Params: args, Result: result}
+		err := c.unify(f, call)
+		if err == nil {
+			return result
+		}
+		if err == errInfinite {
+			c.errorf(n, "infinite type: %s = %s", describe(f, call)...)
+			return Any
+		}
+	case *Func:
+		if !f.Accepts(len(args)) {
+			c.errorf(n, "wrong number of arguments. got=%d, want=%s", len(args), f.Arity())
+			return f.Result
+		}
+		name := calleeName(n.Function)
+		for i, arg := range args {
+			param := f.param(i)
+			err := c.unify(param, arg)
+			if err == nil && !(i < len(f.declared) && f.declared[i] && narrows(param, arg)) {
+				continue
+			}
+			names := describe(param, arg)
+			want, got := names[0], names[1]
+			if err, ok := err.(*classError); ok {
+				want = classNames[err.class]
+			}
+			c.errorf(n.Arguments[i], "argument %d to `%s` must be %s, got %s", i+1, name, want, got)
+			break
+		}
+		c.solve()
+		return f.Result
+	case *Con:
+		if f == Any {
+			return Any
+		}
+	}
+	c.errorf(n, "not a function: %s", callee)
+	return Any
+}
+
+// calleeName returns the name of the function called in messages.
+func calleeName(fn ast.Expression) string {
+	switch fn := fn.(type) {
+	case *ast.Identifier:
+		return fn.Value
+	case *ast.MemberExpression:
+		return fn.String()
+	default:
+		return "function"
+	}
+}
+
+func (c *checker) arrayLiteral(n *ast.ArrayLiteral, env *Env) Type {
+	var elem Type = c.newVar()
+	for i, e := range n.Elements {
+		t := c.expression(e, env)
+		if i == 0 {
+			elem = t
+		} else {
+			elem = c.join(elem, t)
+		}
+	}
+	return &Array{Elem: elem}
+}
+
+func (c *checker) hashLiteral(n *ast.HashLiteral, env *Env) Type {
+	h := &Hash{Key: &Var{level: c.level, classes: hashable}, Value: c.newVar()}
+	for i, pair := range n.Pairs {
+		key := c.expression(pair.Key, env)
+		value := c.expression(pair.Value, env)
+		c.solve()
+		if c.satisfy(key, hashable) != nil {
+			c.errorf(pair.Key, "unusable as hash key: %s", key)
+			key = Any
+		}
+		if i == 0 {
+			h.Key, h.Value = key, value
+		} else {
+			h.Key, h.Value = c.join(h.Key, key), c.join(h.Value, value)
+		}
+	}
+	return h
+}
+
+func (c *checker) indexExpression(n *ast.IndexExpression, env *Env) Type {
+	result := c.newVar()
+	c.constrain(&indexConstraint{
+		node:   n,
+		left:   c.expression(n.Left, env),
+		index:  c.expression(n.Index, env),
+		result: result,
+	})
+	return result
+}
+
+// constrain checks k now if it can be, and otherwise once the type it
+// depends on is known.
+func (c *checker) constrain(k constraint) {
+	c.solve()
+	if !k.solve(c) {
+		c.pending = append(c.pending, k)
+	}
+}
+
+// solve checks the pending constraints whose types have become known.
+func (c *checker) solve() {
+	for progress := true; progress; {
+		progress = false
+		current := c.pending
+		c.pending = nil
+		for _, k := range current {
+			if k.solve(c) {
+				progress = true
+			} else {
+				c.pending = append(c.pending, k)
+			}
+		}
+	}
+}
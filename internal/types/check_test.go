@@ -0,0 +1,411 @@
+package types_test
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vancanhuit/monkey/internal/evaluator"
+	"github.com/vancanhuit/monkey/internal/lexer"
+	"github.com/vancanhuit/monkey/internal/object"
+	"github.com/vancanhuit/monkey/internal/parser"
+	"github.com/vancanhuit/monkey/internal/types"
+)
+
+func check(t *testing.T, input string) (*types.Env, []string) {
+	t.Helper()
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	require.Empty(t, p.Errors())
+
+	env := types.NewEnv()
+	var errors []string
+	for _, err := range types.Check(program, env) {
+		errors = append(errors, err.Error())
+	}
+	return env, errors
+}
+
+func TestInferredTypes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x = 5;", "int"},
+		{"let x = 5.5;", "float"},
+		{"let x = 1 + 2.5;", "float"},
+		{`let x = "a" + "b";`, "string"},
+		{"let x = !5;", "bool"},
+		{"let x = -5;", "int"},
+		{"let x = 1 < 2;", "bool"},
+		{`let x = 1 == "a";`, "bool"},
+		{"let x = [1, 2, 3];", "[int]"},
+		{`let x = [1, "a"];`, "[any]"},
+		{"let x = [];", "[a]"},
+		{`let x = {"a": 1};`, "{string: int}"},
+		{`let x = {"a": 1, 2: true};`, "{any: any}"},
+		{"let x = [1, 2][0];", "int"},
+		{`let x = {"a": [1]}["a"];`, "[int]"},
+		{`let x = {"a": 1}[2];`, "null"},
+		{"let x = if (true) { 1 } else { 2 };", "int"},
+		{"let x = if (true) { 1 };", "any"},
+		{"let id = fn(x) { x };", "fn(a) -> a"},
+		{"let add = fn(a, b) { a + b };", "add a => fn(a, a) -> a"},
+		{"let neg = fn(a) { -a };", "num a => fn(a) -> a"},
+		{"let less = fn(a, b) { a < b };", "ord a => fn(a, a) -> bool"},
+		{"let apply = fn(f, x) { f(x) };", "fn(fn(a) -> b, a) -> b"},
+		{"let compose = fn(f, g) { fn(x) { g(f(x)) } };", "fn(fn(a) -> b, fn(b) -> c) -> fn(a) -> c"},
+		{"let first2 = fn(xs) { xs[0] };", "fn(a) -> b"},
+		{"let inc = fn(xs) { map(xs, fn(x) { x + 1 }) };", "fn([int]) -> [int]"},
+		{"let f = fn(n) { if (n < 2) { return n; } f(n - 1) + f(n - 2) };", "fn(int) -> int"},
+		{"let f = fn(a, b) { if (a < b) { return a; } f(a - b, b) };", "num a, ord a => fn(a, a) -> a"},
+		{`let f = fn(n) { if (n) { return 1; } };`, "fn(a) -> any"},
+		{"let x = len([1]);", "int"},
+		{`let x = strings.split("a b", " ");`, "[string]"},
+		{`let x = push([1], 2);`, "[int]"},
+		{`let x = reduce([1, 2], fn(acc, x) { acc + x }, 0);`, "int"},
+		{`let x = groupBy(["a"], fn(s) { len(s) });`, "{int: [string]}"},
+		{"let x = math.sqrt(2);", "float"},
+		{"let x = math.pi;", "float"},
+		{`let x = json.parse("{}");`, "any"},
+		{`let x = json.parse("{}").name;`, "a"},
+		{"let x = time.now();", "time"},
+		{`let x = {"a": 1}.a;`, "int"},
+		{`let x = quote(1 + 2);`, "quote"},
+		{"let m = macro(x) { x };", "macro"},
+		{"let x = fn() { y }; let y = 1;", "fn() -> any"},
+		{"let x = fn() { let y = 1; y };", "fn() -> int"},
+		{"let x = fn(a) { a[0] = 1 };", "fn(a) -> int"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			env, errors := check(t, tt.input)
+			require.Empty(t, errors)
+
+			s, ok := env.Lookup("x")
+			if !ok {
+				for _, name := range []string{"id", "add", "neg", "less", "apply", "compose", "first2", "inc", "f", "m"} {
+					if s, ok = env.Lookup(name); ok {
+						break
+					}
+				}
+			}
+			require.True(t, ok)
+			require.Equal(t, tt.expected, s.String())
+		})
+	}
+}
+
+func TestPolymorphism(t *testing.T) {
+	input := `
+	let id = fn(x) { x };
+	let a = id(1);
+	let b = id("a");
+	let pair = fn(x, y) { [x, y] };
+	let c = pair(1, 2);
+	let d = pair("a", "b");
+	`
+
+	env, errors := check(t, input)
+	require.Empty(t, errors)
+
+	expected := map[string]string{
+		"a": "int",
+		"b": "string",
+		"c": "[int]",
+		"d": "[string]",
+	}
+	for name, want := range expected {
+		s, ok := env.Lookup(name)
+		require.True(t, ok, name)
+		require.Equal(t, want, s.String(), name)
+	}
+}
+
+func TestTypeErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{`1 + "a"`, []string{"1:3: type mismatch: int + string"}},
+		{`"a" - "b"`, []string{"1:5: unknown operator: string - string"}},
+		{"true + false", []string{"1:6: unknown operator: bool + bool"}},
+		{`-"a"`, []string{"1:1: unknown operator: -string"}},
+		{"[1] < {}", []string{"1:5: type mismatch: [int] < {a: b}"}},
+		{"foo", []string{"1:1: identifier not found: foo"}},
+		{"5(1)", []string{"1:1: not a function: int"}},
+		{"let f = fn(x) { x }; f(1, 2)", []string{"1:22: wrong number of arguments. got=2, want=1"}},
+		{"len(1, 2)", []string{"1:1: wrong number of arguments. got=2, want=1"}},
+		{"range()", []string{"1:1: wrong number of arguments. got=0, want=1 to 3"}},
+		{`strings.format()`, []string{"1:1: wrong number of arguments. got=0, want=at least 1"}},
+		{"len(1)", []string{"1:5: argument 1 to `len` must be string, array or hash, got int"}},
+		{`push([1], "a")`, []string{"1:11: argument 2 to `push` must be int, got string"}},
+		{`strings.upper(1)`, []string{"1:15: argument 1 to `strings.upper` must be string, got int"}},
+		{`math.sqrt("a")`, []string{"1:11: argument 1 to `math.sqrt` must be int or float, got string"}},
+		{`let f = fn(x) { x + 1 }; f("a")`, []string{`1:28: argument 1 to ` + "`f`" + ` must be int, got string`}},
+		{"let add = fn(a, b) { a + b }; add(true, false)", []string{"1:35: argument 1 to `add` must be int, float or string, got bool"}},
+		{"1[0]", []string{"1:1: index operator not supported: int"}},
+		{`[1]["a"]`, []string{"1:5: array index must be int, got string"}},
+		{`{"a": 1}[[fn(x) { x }]]`, []string{"1:10: unusable as hash key: [fn(a) -> a]"}},
+		{`{fn(x) { x }: 1}`, []string{"1:2: unusable as hash key: fn(a) -> a"}},
+		{"math.foo", []string{"1:6: module math has no member foo"}},
+		{"let x = 1; x.foo", []string{"1:12: member access not supported: int"}},
+		{"let f = fn(x) { x[0] }; f(1)", []string{"1:17: index operator not supported: int"}},
+		{"let f = fn(x) { x.name }; f(1)", []string{"1:17: member access not supported: int"}},
+		{"let f = fn() { macro(x) { x } }", []string{"1:16: macros can only be defined by a top-level let statement"}},
+		{"quote(1, 2)", []string{"1:1: wrong number of arguments. got=2, want=1"}},
+		{
+			`let c = true; let x = if (c) { 1 } else { "a" }; x + 1`,
+			[]string{"1:23: branches of if must have the same type, got int and string"},
+		},
+		{
+			`let f = fn(n) { if (n < 1) { return 0; } "s" }`,
+			[]string{"1:42: return values must have the same type, got int and string"},
+		},
+		{
+			`let f = fn(n) { if (n < 1) { return 0; } return "s"; }`,
+			[]string{"1:42: return values must have the same type, got int and string"},
+		},
+		{`let x = if (true) { json.parse("1") } else { "a" }; x + 1`, nil},
+		{"fn(x) { x(x) }", []string{"1:9: infinite type: a = fn(a) -> b"}},
+		{
+			`let a = [1, 2]; a[0] = "x"; puts(a[0] + 1);`,
+			[]string{"1:17: cannot assign string to an element of [int]"},
+		},
+		{
+			`let h = {"a": 1}; h["b"] = true;`,
+			[]string{"1:19: cannot assign bool to a value of {string: int}"},
+		},
+		{
+			"let x = 1 + true;\nlet y = x(2);\nfoo;",
+			[]string{
+				"1:11: type mismatch: int + bool",
+				"3:1: identifier not found: foo",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, errors := check(t, tt.input)
+			require.Equal(t, tt.expected, errors)
+		})
+	}
+}
+
+func TestTypeAnnotations(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		errors   []string
+	}{
+		{"let x: float = 1;", "float", nil},
+		{"let x: [int] = [];", "[int]", nil},
+		{"let f = fn(x: int) { x };", "fn(int) -> int", nil},
+		{"let f = fn(x) -> string { x };", "fn(string) -> string", nil},
+		{"let f: fn(int) -> int = fn(x) { x };", "fn(int) -> int", nil},
+		{"let f = fn(x: a, y: a) -> [a] { [x, y] };", "fn(a, a) -> [a]", nil},
+		{"let f = fn(x: any) { x + 1 };", "fn(any) -> any", nil},
+		{`let x: string = 5;`, "string", []string{"1:17: value of x must be string, got int"}},
+		{
+			"let f = fn(x: int) { x }; f(\"a\");",
+			"fn(int) -> int",
+			[]string{"1:29: argument 1 to `f` must be int, got string"},
+		},
+		{`let f = fn(x: string) { x + 1 };`, "fn(string) -> any", []string{"1:27: type mismatch: string + int"}},
+		{`let f = fn() -> int { "a" };`, "fn() -> int", []string{"1:9: return value must be int, got string"}},
+		{`let f = fn() -> int { return "a"; };`, "fn() -> int", []string{"1:9: return value must be int, got string"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			env, errors := check(t, tc.input)
+			s, ok := env.Lookup(tc.input[4:5])
+			require.True(t, ok)
+			require.Equal(t, tc.expected, s.String())
+			require.Equal(t, tc.errors, errors)
+		})
+	}
+}
+
+// TestAnnotationsAgreeWithRuntime checks that the checker rejects exactly
+// the annotated programs the evaluator rejects: an int is a float, but a
+// float is not an int.
+func TestAnnotationsAgreeWithRuntime(t *testing.T) {
+	tests := []struct {
+		input string
+		fails bool
+	}{
+		{"let f = fn(x: int) { x }; f(1.5)", true},
+		{"let x: int = 2.5;", true},
+		{"let y = 1.5; let x: int = y + 1;", true},
+		{"let f = fn() -> int { 2.5 }; f()", true},
+		{"let f = fn(xs: [int]) { xs }; f([1, 2.5])", true},
+		{"let xs: [int] = [1, 2.5];", true},
+		{`let h: {string: int} = {"a": 1.5};`, true},
+		{"let f = fn(x: int) { x }; f(1)", false},
+		{"let f = fn(x: float) { x }; f(1)", false},
+		{"let x: float = 1;", false},
+		{"let xs: [float] = [1, 2.5];", false},
+		{"let f = fn() -> float { 2 }; f()", false},
+		{"let f = fn(x) { x + 1 }; f(1.5)", false},
+		{"let f = fn(x: int) { x + 1.5 }; f(1)", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, errors := check(t, tt.input)
+			require.Equal(t, tt.fails, len(errors) != 0, "checker: %v", errors)
+
+			program := parser.New(lexer.New(tt.input)).ParseProgram()
+			result := evaluator.Eval(program, evaluator.NewEnvironment(evaluator.Config{}))
+			_, failed := result.(*object.Error)
+			require.Equal(t, tt.fails, failed, "runtime: %v", result)
+		})
+	}
+}
+
+func TestNoFalsePositives(t *testing.T) {
+	tests := []string{
+		`let xs = [1, "a", true]; puts(xs[0]);`,
+		`let h = {}; h["a"] = 1; h[2] = "b";`,
+		`let a = [1, 2]; a[0] = 3; a[1] = 1.5; let h = {"a": 1}; h[1] = "b";`,
+		`let xs = [1, "a"]; xs[0] = true;`,
+		`let f = fn(x) { if (x) { return 1; } }; f(true) + 1;`,
+		`let x = json.parse("[1]"); x[0] + 1; x.a.b;`,
+		`let isEven = fn(n) { if (n == 0) { true } else { isOdd(n - 1) } };
+		 let isOdd = fn(n) { if (n == 0) { false } else { isEven(n - 1) } };
+		 isEven(10);`,
+		`let counter = fn() { let n = 0; fn() { n + 1 } }; counter()();`,
+		`if (true) { let y = 1; }; y + 1;`,
+		`let m = macro(x) { quote(unquote(x) + 1) }; m(1);`,
+		`1.5 + 2; 2 * 3.5; "a" < "b"; [1] < [2]; time.now() < time.now();`,
+		`let t = time.parse("2006", "2024"); time.format(t, "2006");`,
+		`sort([3, 1, 2]); sort(["b"], fn(a, b) { a < b });`,
+		`let total = reduce([1.5, 2], fn(a, b) { a + b });`,
+		`fn(x) { x }(1)`,
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			_, errors := check(t, input)
+			require.Empty(t, errors)
+		})
+	}
+}
+
+func TestDefine(t *testing.T) {
+	p := parser.New(lexer.New(`let n = len(args); exit(n); exit("a");`))
+	program := p.ParseProgram()
+	require.Empty(t, p.Errors())
+
+	env := types.NewEnv()
+	env.Define("args", &types.Array{Elem: types.String})
+	env.Define("exit", types.MustParse("fn(int?) -> null"))
+
+	errors := types.Check(program, env)
+	require.Len(t, errors, 1)
+	require.Equal(t, "1:34: argument 1 to `exit` must be int, got string", errors[0].Error())
+}
+
+func TestParse(t *testing.T) {
+	tests := []string{
+		"int",
+		"[string]",
+		"{string: [int]}",
+		"fn() -> null",
+		"fn(a, int?, ...any) -> a",
+		"num a => fn(a) -> a",
+		"hashable a, ord b => fn({a: b}) -> [b]",
+		"fn(fn(a) -> b) -> fn(a) -> b",
+	}
+
+	for _, sig := range tests {
+		t.Run(sig, func(t *testing.T) {
+			typ, err := types.Parse(sig)
+			require.NoError(t, err)
+			require.Equal(t, sig, (&types.Scheme{Type: typ}).String())
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[int", `expected "]", got end of signature`},
+		{"fn(int) int", `expected "->", got "int"`},
+		{"foo a => a", `unknown class "foo"`},
+		{"fn(int?, int) -> int", "required parameter after optional one"},
+		{"fn(...int, int) -> int", "variadic parameter must be last"},
+		{"integer", `expected a type variable, got "integer"`},
+		{"int int", `unexpected "int" in "int int"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := types.Parse(tt.input)
+			require.EqualError(t, err, tt.expected)
+		})
+	}
+}
+
+// TestBuiltinSignatures checks that every builtin function and module
+// member the evaluator provides has a signature.
+func TestBuiltinSignatures(t *testing.T) {
+	env := types.NewEnv()
+	host := evaluator.NewEnvironment(evaluator.Config{
+		FS:   &evaluator.FSConfig{Roots: []string{t.TempDir()}},
+		HTTP: roundTripper{},
+	})
+	for _, name := range evaluator.GlobalNames() {
+		s, ok := env.Lookup(name)
+		require.True(t, ok, name)
+
+		// A module the host binds, such as math, is checked below.
+		if _, ok := host.Get(name); ok {
+			continue
+		}
+		obj, _ := evaluator.Global(name)
+		if module, ok := obj.(*object.Module); ok {
+			requireMembers(t, s, module)
+		}
+	}
+
+	for _, name := range host.Names() {
+		s, ok := env.Lookup(name)
+		require.True(t, ok, name)
+
+		obj, _ := host.Get(name)
+		requireMembers(t, s, obj.(*object.Module))
+	}
+}
+
+func requireMembers(t *testing.T, s *types.Scheme, module *object.Module) {
+	t.Helper()
+
+	typ, ok := s.Type.(*types.Module)
+	require.True(t, ok, module.Name)
+
+	var want, got []string
+	for name := range module.Members {
+		want = append(want, name)
+	}
+	for name := range typ.Members {
+		got = append(got, name)
+	}
+	sort.Strings(want)
+	sort.Strings(got)
+	require.Equal(t, want, got, module.Name)
+}
+
+type roundTripper struct{}
+
+func (roundTripper) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("no network")
+}
@@ -0,0 +1,108 @@
+package types
+
+import "github.com/vancanhuit/monkey/internal/ast"
+
+// constraint is a check that depends on the type of a value that may not
+// be known yet, such as indexing a function's parameter. It is checked
+// once the type of its subject is known.
+type constraint interface {
+	// solve checks the constraint and reports whether it has been, which
+	// it cannot be while its subject is an unbound variable.
+	solve(c *checker) bool
+	subject() Type
+	types() []Type
+	substitute(f func(Type) Type) constraint
+}
+
+// indexConstraint is left[index], whose value has type result. When
+// assign is set, a value of type result is stored at left[index] instead,
+// and must have the type of the elements or values of left.
+type indexConstraint struct {
+	node   *ast.IndexExpression
+	left   Type
+	index  Type
+	result Type
+	assign bool
+}
+
+func (k *indexConstraint) subject() Type { return k.left }
+func (k *indexConstraint) types() []Type { return []Type{k.left, k.index, k.result} }
+
+func (k *indexConstraint) substitute(f func(Type) Type) constraint {
+	return &indexConstraint{node: k.node, left: f(k.left), index: f(k.index), result: f(k.result), assign: k.assign}
+}
+
+func (k *indexConstraint) solve(c *checker) bool {
+	switch left := prune(k.left).(type) {
+	case *Var:
+		return false
+	case *Array:
+		if c.unify(k.index, Int) != nil {
+			c.errorf(k.node.Index, "array index must be int, got %s", k.index)
+			return true
+		}
+		if c.unify(k.result, left.Elem) != nil && k.assign {
+			c.errorf(k.node, "cannot assign %s to an element of %s", describe(k.result, left)...)
+		}
+	case *Hash:
+		if c.satisfy(k.index, hashable) != nil {
+			c.errorf(k.node.Index, "unusable as hash key: %s", k.index)
+			return true
+		}
+		// Looking up a key of another type finds nothing.
+		if c.unify(k.index, left.Key) != nil {
+			if !k.assign {
+				_ = c.unify(k.result, Null)
+			}
+			return true
+		}
+		if c.unify(k.result, left.Value) != nil && k.assign {
+			c.errorf(k.node, "cannot assign %s to a value of %s", describe(k.result, left)...)
+		}
+	default:
+		if left != Any {
+			c.errorf(k.node, "index operator not supported: %s", left)
+		}
+	}
+	return true
+}
+
+// memberConstraint is left.name, whose value has type result.
+type memberConstraint struct {
+	node   *ast.MemberExpression
+	left   Type
+	result Type
+}
+
+func (k *memberConstraint) subject() Type { return k.left }
+func (k *memberConstraint) types() []Type { return []Type{k.left, k.result} }
+
+func (k *memberConstraint) substitute(f func(Type) Type) constraint {
+	return &memberConstraint{node: k.node, left: f(k.left), result: f(k.result)}
+}
+
+func (k *memberConstraint) solve(c *checker) bool {
+	name := k.node.Property.Value
+	switch left := prune(k.left).(type) {
+	case *Var:
+		return false
+	case *Module:
+		member, ok := left.Members[name]
+		if !ok {
+			c.errorf(k.node.Property, "module %s has no member %s", left.Name, name)
+			return true
+		}
+		_ = c.unify(k.result, c.instantiate(member))
+	case *Hash:
+		if c.unify(left.Key, String) != nil {
+			_ = c.unify(k.result, Null)
+			return true
+		}
+		_ = c.unify(k.result, left.Value)
+	default:
+		if left != Any {
+			c.errorf(k.node, "member access not supported: %s", left)
+		}
+	}
+	return true
+}
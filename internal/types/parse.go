@@ -0,0 +1,240 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Parse returns the type written in signature, in the syntax types are
+// printed in: int, [string], {string: int}, fn(a, int?, ...any) -> a and
+// so on. Single letters are type variables, which may be given classes
+// before a "=>", as in "num a, ord b => fn(a, b) -> a". The classes are
+// num, add, ord, hashable and sized. The variables are generic, so a
+// binding of the type takes fresh variables wherever it is used.
+func Parse(signature string) (Type, error) {
+	p := &sigParser{tokens: tokenize(signature), vars: map[string]*Var{}}
+	if p.hasConstraints() {
+		for {
+			if err := p.parseConstraint(); err != nil {
+				return nil, err
+			}
+			if p.peek() != "," {
+				break
+			}
+			p.next()
+		}
+		if err := p.expect("=>"); err != nil {
+			return nil, err
+		}
+	}
+	t, err := p.parseType()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != "" {
+		return nil, fmt.Errorf("unexpected %q in %q", p.peek(), signature)
+	}
+	return t, nil
+}
+
+// MustParse is like Parse but panics if the signature is invalid.
+func MustParse(signature string) Type {
+	t, err := Parse(signature)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+var namedTypes = map[string]Type{
+	"int":    Int,
+	"float":  Float,
+	"string": String,
+	"bool":   Bool,
+	"null":   Null,
+	"time":   Time,
+	"any":    Any,
+	"quote":  Quote,
+	"macro":  Macro,
+}
+
+type sigParser struct {
+	tokens []string
+	pos    int
+	vars   map[string]*Var
+}
+
+func tokenize(s string) []string {
+	var tokens []string
+	for i := 0; i < len(s); {
+		r := rune(s[i])
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case strings.HasPrefix(s[i:], "->"), strings.HasPrefix(s[i:], "=>"):
+			tokens = append(tokens, s[i:i+2])
+			i += 2
+		case strings.HasPrefix(s[i:], "..."):
+			tokens = append(tokens, "...")
+			i += 3
+		case unicode.IsLetter(r):
+			j := i
+			for j < len(s) && (unicode.IsLetter(rune(s[j])) || unicode.IsDigit(rune(s[j]))) {
+				j++
+			}
+			tokens = append(tokens, s[i:j])
+			i = j
+		default:
+			tokens = append(tokens, s[i:i+1])
+			i++
+		}
+	}
+	return tokens
+}
+
+func (p *sigParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *sigParser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *sigParser) expect(tok string) error {
+	if got := p.next(); got != tok {
+		if got == "" {
+			return fmt.Errorf("expected %q, got end of signature", tok)
+		}
+		return fmt.Errorf("expected %q, got %q", tok, got)
+	}
+	return nil
+}
+
+func (p *sigParser) hasConstraints() bool {
+	for _, t := range p.tokens {
+		if t == "=>" {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *sigParser) parseConstraint() error {
+	name := p.next()
+	var c class
+	for cls, keyword := range classKeywords {
+		if keyword == name {
+			c = cls
+		}
+	}
+	if c == 0 {
+		return fmt.Errorf("unknown class %q", name)
+	}
+	v, err := p.parseVar()
+	if err != nil {
+		return err
+	}
+	v.classes |= c
+	return nil
+}
+
+func (p *sigParser) parseVar() (*Var, error) {
+	name := p.next()
+	if len(name) != 1 || !unicode.IsLower(rune(name[0])) {
+		return nil, fmt.Errorf("expected a type variable, got %q", name)
+	}
+	v, ok := p.vars[name]
+	if !ok {
+		v = &Var{level: generic}
+		p.vars[name] = v
+	}
+	return v, nil
+}
+
+func (p *sigParser) parseType() (Type, error) {
+	switch tok := p.peek(); tok {
+	case "[":
+		p.next()
+		elem, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		return &Array{Elem: elem}, p.expect("]")
+	case "{":
+		p.next()
+		key, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		value, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		return &Hash{Key: key, Value: value}, p.expect("}")
+	case "fn":
+		p.next()
+		return p.parseFunc()
+	default:
+		if t, ok := namedTypes[tok]; ok {
+			p.next()
+			return t, nil
+		}
+		return p.parseVar()
+	}
+}
+
+func (p *sigParser) parseFunc() (Type, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	f := &Func{}
+	for p.peek() != ")" {
+		if len(f.Params) > 0 || f.Variadic != nil {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		if f.Variadic != nil {
+			return nil, fmt.Errorf("variadic parameter must be last")
+		}
+		variadic := p.peek() == "..."
+		if variadic {
+			p.next()
+		}
+		t, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case variadic:
+			f.Variadic = t
+		case p.peek() == "?":
+			p.next()
+			f.Params = append(f.Params, t)
+			f.Optional++
+		case f.Optional > 0:
+			return nil, fmt.Errorf("required parameter after optional one")
+		default:
+			f.Params = append(f.Params, t)
+		}
+	}
+	p.next()
+	if err := p.expect("->"); err != nil {
+		return nil, err
+	}
+	result, err := p.parseType()
+	if err != nil {
+		return nil, err
+	}
+	f.Result = result
+	return f, nil
+}
@@ -0,0 +1,241 @@
+// Package types infers static types for Monkey programs using
+// Hindley-Milner inference, and reports the operations that would fail at
+// runtime with a type error before the program runs.
+//
+// Monkey itself is dynamically typed, so the checker only complains about
+// code that cannot work: adding a string to an integer, calling something
+// that is not a function, passing the wrong arguments to a builtin or to a
+// function whose parameters are known, and so on. Where a program mixes
+// types in ways the runtime allows, such as an array holding both integers
+// and strings, the checker gives up on that value and types it as any. The
+// branches of an if whose value is used, and the values a function
+// returns, must have the same type, though, unless one of them is any.
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Type is the static type of a Monkey value.
+type Type interface {
+	String() string
+}
+
+// Con is a type without parameters, such as int.
+type Con struct {
+	Name string
+}
+
+// The types of the values that have no parameters. Integers and floats
+// unify, as they mix in arithmetic, but a float is not accepted where an
+// annotation declares an int. Any is the type of values the checker knows
+// nothing about; it matches every other type.
+var (
+	Int    = &Con{Name: "int"}
+	Float  = &Con{Name: "float"}
+	String = &Con{Name: "string"}
+	Bool   = &Con{Name: "bool"}
+	Null   = &Con{Name: "null"}
+	Time   = &Con{Name: "time"}
+	Any    = &Con{Name: "any"}
+	Quote  = &Con{Name: "quote"}
+	Macro  = &Con{Name: "macro"}
+)
+
+// Var is a type variable, standing for a type that is not known yet. Once
+// the variable is bound to a type, it is that type.
+type Var struct {
+	level    int
+	classes  class
+	instance Type
+}
+
+// Array is the type of arrays whose elements have type Elem.
+type Array struct {
+	Elem Type
+}
+
+// Hash is the type of hashes from Key to Value.
+type Hash struct {
+	Key   Type
+	Value Type
+}
+
+// Func is the type of a function. The last Optional parameters may be left
+// out, and if Variadic is set the function takes any number of further
+// arguments of that type.
+type Func struct {
+	Params   []Type
+	Optional int
+	Variadic Type
+	Result   Type
+	// declared marks the parameters whose types are annotations, which
+	// arguments must not narrow.
+	declared []bool
+}
+
+// Module is the type of a builtin module such as json.
+type Module struct {
+	Name    string
+	Members map[string]*Scheme
+}
+
+// Scheme is the type of a binding. The variables it has been generalized
+// over take a fresh type wherever the binding is used, which lets a
+// function such as fn(x) { x } be applied to values of any type.
+type Scheme struct {
+	Type        Type
+	constraints []constraint
+}
+
+// String prints the type of s, preceded by the classes of its variables
+// as in "num a => fn(a) -> a".
+func (s *Scheme) String() string {
+	n := newNamer()
+	t := n.name(s.Type)
+	var classes []string
+	for _, v := range n.order {
+		for c := class(1); c != 0 && c <= sized; c <<= 1 {
+			if v.classes&c != 0 {
+				classes = append(classes, classKeywords[c]+" "+n.names[v])
+			}
+		}
+	}
+	if len(classes) == 0 {
+		return t
+	}
+	return strings.Join(classes, ", ") + " => " + t
+}
+
+func (t *Con) String() string    { return typeString(t) }
+func (t *Var) String() string    { return typeString(t) }
+func (t *Array) String() string  { return typeString(t) }
+func (t *Hash) String() string   { return typeString(t) }
+func (t *Func) String() string   { return typeString(t) }
+func (t *Module) String() string { return typeString(t) }
+
+func typeString(t Type) string {
+	return newNamer().name(t)
+}
+
+// namer prints types, naming their variables a, b, c and so on in the
+// order they first appear. Types printed by the same namer share names.
+type namer struct {
+	names map[*Var]string
+	order []*Var
+}
+
+func newNamer() *namer {
+	return &namer{names: map[*Var]string{}}
+}
+
+func (n *namer) name(t Type) string {
+	var out strings.Builder
+	n.write(&out, t)
+	return out.String()
+}
+
+func (n *namer) write(out *strings.Builder, t Type) {
+	switch t := prune(t).(type) {
+	case *Con:
+		out.WriteString(t.Name)
+	case *Var:
+		name, ok := n.names[t]
+		if !ok {
+			name = varName(len(n.names))
+			n.names[t] = name
+			n.order = append(n.order, t)
+		}
+		out.WriteString(name)
+	case *Array:
+		out.WriteString("[")
+		n.write(out, t.Elem)
+		out.WriteString("]")
+	case *Hash:
+		out.WriteString("{")
+		n.write(out, t.Key)
+		out.WriteString(": ")
+		n.write(out, t.Value)
+		out.WriteString("}")
+	case *Func:
+		out.WriteString("fn(")
+		required := len(t.Params) - t.Optional
+		for i, p := range t.Params {
+			if i > 0 {
+				out.WriteString(", ")
+			}
+			n.write(out, p)
+			if i >= required {
+				out.WriteString("?")
+			}
+		}
+		if t.Variadic != nil {
+			if len(t.Params) > 0 {
+				out.WriteString(", ")
+			}
+			out.WriteString("...")
+			n.write(out, t.Variadic)
+		}
+		out.WriteString(") -> ")
+		n.write(out, t.Result)
+	case *Module:
+		out.WriteString("module " + t.Name)
+	}
+}
+
+// varName returns a, b, ..., z, a1, b1 and so on.
+func varName(i int) string {
+	name := string(rune('a' + i%26))
+	if i >= 26 {
+		name += fmt.Sprint(i / 26)
+	}
+	return name
+}
+
+// prune returns the type that t stands for, following bound variables.
+func prune(t Type) Type {
+	for {
+		v, ok := t.(*Var)
+		if !ok || v.instance == nil {
+			return t
+		}
+		t = v.instance
+	}
+}
+
+func isNumber(t Type) bool {
+	return t == Int || t == Float
+}
+
+// Accepts reports whether t can be called with n arguments.
+func (t *Func) Accepts(n int) bool {
+	if n < len(t.Params)-t.Optional {
+		return false
+	}
+	return n <= len(t.Params) || t.Variadic != nil
+}
+
+// param returns the type of the i-th argument to f.
+func (t *Func) param(i int) Type {
+	if i < len(t.Params) {
+		return t.Params[i]
+	}
+	return t.Variadic
+}
+
+// Arity describes the numbers of arguments t accepts, as in the runtime's
+// messages: "2", "1 or 2", "1 to 3" or "at least 1".
+func (t *Func) Arity() string {
+	required := len(t.Params) - t.Optional
+	switch {
+	case t.Variadic != nil:
+		return fmt.Sprintf("at least %d", required)
+	case t.Optional == 0:
+		return fmt.Sprint(required)
+	case t.Optional == 1:
+		return fmt.Sprintf("%d or %d", required, len(t.Params))
+	default:
+		return fmt.Sprintf("%d to %d", required, len(t.Params))
+	}
+}
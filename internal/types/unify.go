@@ -0,0 +1,292 @@
+package types
+
+import (
+	"errors"
+	"math"
+)
+
+// generic is the level of the variables a scheme is generalized over.
+const generic = math.MaxInt32
+
+// class is a set of operations a type variable must support. A variable
+// with classes can only be bound to a type that supports all of them.
+type class uint8
+
+const (
+	num      class = 1 << iota // arithmetic: int or float
+	addable                    // +: int, float or string
+	ordered                    // < and >
+	hashable                   // usable as a hash key
+	sized                      // len: string, array or hash
+)
+
+var classNames = map[class]string{
+	num:      "int or float",
+	addable:  "int, float or string",
+	ordered:  "comparable",
+	hashable: "hashable",
+	sized:    "string, array or hash",
+}
+
+// classKeywords are the names of the classes in signatures.
+var classKeywords = map[class]string{
+	num:      "num",
+	addable:  "add",
+	ordered:  "ord",
+	hashable: "hashable",
+	sized:    "sized",
+}
+
+var errMismatch = errors.New("type mismatch")
+
+// errInfinite reports a variable that would have to contain itself, as the
+// parameter of fn(x) { x(x) } would.
+var errInfinite = errors.New("infinite type")
+
+// classError reports a type that does not support the operations of a
+// class.
+type classError struct {
+	class class
+}
+
+func (e *classError) Error() string {
+	return "must be " + classNames[e.class]
+}
+
+// unifier makes types equal by binding their variables. Failed attempts
+// are rolled back, so that a type can be tried against another to see
+// whether they match.
+type unifier struct {
+	trail []func()
+}
+
+// unify makes a and b the same type, or leaves both unchanged and returns
+// an error if they cannot be.
+func (u *unifier) unify(a, b Type) error {
+	mark := len(u.trail)
+	err := u.unifyTypes(a, b)
+	if err != nil {
+		for i := len(u.trail) - 1; i >= mark; i-- {
+			u.trail[i]()
+		}
+	}
+	u.trail = u.trail[:mark]
+	return err
+}
+
+// satisfy makes t support the operations of c, or returns an error.
+func (u *unifier) satisfy(t Type, c class) error {
+	mark := len(u.trail)
+	err := u.satisfies(t, c)
+	if err != nil {
+		for i := len(u.trail) - 1; i >= mark; i-- {
+			u.trail[i]()
+		}
+	}
+	u.trail = u.trail[:mark]
+	return err
+}
+
+// join returns the type of a value that is either a or b: their common
+// type if they have one, and any otherwise. It types the elements of array
+// and hash literals, which may mix types as the runtime allows. An int
+// joined with a float is a float.
+func (u *unifier) join(a, b Type) Type {
+	if u.unify(a, b) != nil {
+		return Any
+	}
+	if prune(a) == Int && prune(b) == Float {
+		return b
+	}
+	return a
+}
+
+// narrows reports whether got has a float where want has an int. The two
+// unify, but a value of type got does not have the declared type want.
+func narrows(want, got Type) bool {
+	switch w := prune(want).(type) {
+	case *Con:
+		return w == Int && prune(got) == Float
+	case *Array:
+		if g, ok := prune(got).(*Array); ok {
+			return narrows(w.Elem, g.Elem)
+		}
+	case *Hash:
+		if g, ok := prune(got).(*Hash); ok {
+			return narrows(w.Key, g.Key) || narrows(w.Value, g.Value)
+		}
+	}
+	return false
+}
+
+func (u *unifier) unifyTypes(a, b Type) error {
+	a, b = prune(a), prune(b)
+	if a == b || a == Any || b == Any {
+		return nil
+	}
+	if v, ok := a.(*Var); ok {
+		return u.bind(v, b)
+	}
+	if v, ok := b.(*Var); ok {
+		return u.bind(v, a)
+	}
+
+	switch a := a.(type) {
+	case *Con:
+		if b, ok := b.(*Con); ok && isNumber(a) && isNumber(b) {
+			return nil
+		}
+	case *Array:
+		if b, ok := b.(*Array); ok {
+			return u.unifyTypes(a.Elem, b.Elem)
+		}
+	case *Hash:
+		if b, ok := b.(*Hash); ok {
+			if err := u.unifyTypes(a.Key, b.Key); err != nil {
+				return err
+			}
+			return u.unifyTypes(a.Value, b.Value)
+		}
+	case *Func:
+		if b, ok := b.(*Func); ok {
+			return u.unifyFuncs(a, b)
+		}
+	case *Module:
+		if b, ok := b.(*Module); ok && a.Name == b.Name {
+			return nil
+		}
+	}
+	return errMismatch
+}
+
+// unifyFuncs unifies the parameters both functions take and their results.
+// Functions whose arities do not overlap do not match.
+func (u *unifier) unifyFuncs(a, b *Func) error {
+	n := len(a.Params)
+	if len(b.Params) > n {
+		n = len(b.Params)
+	}
+	required := len(a.Params) - a.Optional
+	if r := len(b.Params) - b.Optional; r > required {
+		required = r
+	}
+	if !a.Accepts(required) || !b.Accepts(required) {
+		return errMismatch
+	}
+	for i := 0; i < n; i++ {
+		pa, pb := a.param(i), b.param(i)
+		if pa == nil || pb == nil {
+			break
+		}
+		if err := u.unifyTypes(pa, pb); err != nil {
+			return err
+		}
+	}
+	return u.unifyTypes(a.Result, b.Result)
+}
+
+func (u *unifier) bind(v *Var, t Type) error {
+	if w, ok := t.(*Var); ok {
+		u.setLevel(w, v.level)
+		u.setClasses(w, w.classes|v.classes)
+		u.setInstance(v, w)
+		return nil
+	}
+	if u.occurs(v, t) {
+		return errInfinite
+	}
+	for c := class(1); c != 0 && c <= sized; c <<= 1 {
+		if v.classes&c != 0 {
+			if err := u.satisfies(t, c); err != nil {
+				return err
+			}
+		}
+	}
+	u.setInstance(v, t)
+	return nil
+}
+
+// occurs reports whether v appears in t. It also lowers the levels of the
+// variables in t to that of v, since they become reachable wherever v is.
+func (u *unifier) occurs(v *Var, t Type) bool {
+	switch t := prune(t).(type) {
+	case *Var:
+		if t == v {
+			return true
+		}
+		u.setLevel(t, v.level)
+	case *Array:
+		return u.occurs(v, t.Elem)
+	case *Hash:
+		return u.occurs(v, t.Key) || u.occurs(v, t.Value)
+	case *Func:
+		for _, p := range t.Params {
+			if u.occurs(v, p) {
+				return true
+			}
+		}
+		if t.Variadic != nil && u.occurs(v, t.Variadic) {
+			return true
+		}
+		return u.occurs(v, t.Result)
+	}
+	return false
+}
+
+func (u *unifier) satisfies(t Type, c class) error {
+	switch t := prune(t).(type) {
+	case *Var:
+		u.setClasses(t, t.classes|c)
+		return nil
+	case *Con:
+		if t == Any || conClasses[t]&c != 0 {
+			return nil
+		}
+	case *Array:
+		switch c {
+		case sized:
+			return nil
+		case ordered, hashable:
+			if err := u.satisfies(t.Elem, c); err == nil {
+				return nil
+			}
+		}
+	case *Hash:
+		if c == sized {
+			return nil
+		}
+	}
+	return &classError{class: c}
+}
+
+var conClasses = map[*Con]class{
+	Int:    num | addable | ordered | hashable,
+	Float:  num | addable | ordered | hashable,
+	String: addable | ordered | hashable | sized,
+	Bool:   ordered | hashable,
+	Null:   ordered,
+	Time:   ordered,
+}
+
+func (u *unifier) setInstance(v *Var, t Type) {
+	u.trail = append(u.trail, func() { v.instance = nil })
+	v.instance = t
+}
+
+func (u *unifier) setLevel(v *Var, level int) {
+	if level >= v.level {
+		return
+	}
+	old := v.level
+	u.trail = append(u.trail, func() { v.level = old })
+	v.level = level
+}
+
+func (u *unifier) setClasses(v *Var, classes class) {
+	if classes == v.classes {
+		return
+	}
+	old := v.classes
+	u.trail = append(u.trail, func() { v.classes = old })
+	v.classes = classes
+}